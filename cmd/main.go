@@ -3,19 +3,31 @@ package main
 import (
 	"booktrading/internal/config"
 	httpHandler "booktrading/internal/delivery/http"
+	domainRepository "booktrading/internal/domain/repository"
 	"booktrading/internal/pkg/cache"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/health"
+	"booktrading/internal/pkg/jwt"
 	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/metrics"
+	"booktrading/internal/pkg/migrate"
+	"booktrading/internal/pkg/notify"
+	"booktrading/internal/pkg/storage"
+	"booktrading/internal/pkg/tracing"
 	"booktrading/internal/repository"
 	"booktrading/internal/repository/mysql"
 	"booktrading/internal/usecase"
+	"booktrading/migrations"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	_ "booktrading/docs" // This is required for Swagger
-
-	"github.com/go-chi/jwtauth/v5"
 )
 
 // @title Book Trading API
@@ -25,6 +37,9 @@ import (
 // @BasePath /
 // @schemes http
 
+// readinessCheckTimeout ограничивает время каждой проверки готовности
+const readinessCheckTimeout = 3 * time.Second
+
 // @securityDefinitions.apikey Bearer
 // @in header
 // @name Authorization
@@ -39,6 +54,17 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to load config", err)
 	}
+	logger.Init(cfg.Logging.Level, cfg.Logging.Format)
+
+	// Контекст отменяется по SIGINT/SIGTERM и останавливает фоновые задачи
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Трассировка OpenTelemetry, по умолчанию отключена
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", err)
+	}
 
 	// Получаем *gorm.DB напрямую
 	db, err := mysql.InitDB(fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
@@ -51,31 +77,126 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed to connect to database", err)
 	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Fatal("Failed to get database connection", err)
+	}
 
 	repo := repository.NewRepository(db)
 
-	// Создаем JWTAuth из go-chi/jwtauth
-	jwtAuth := jwtauth.New("HS256", []byte(cfg.JWT.SecretKey), nil)
+	// Сервис токенов: access JWT и одноразовые refresh токены
+	tokenService := jwt.NewService(cfg.JWT.SecretKey, cfg.JWT.AccessTTL, cfg.JWT.RefreshTTL, repo.Token, repo.User)
+
+	// Периодически удаляем истекшие refresh токены
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := tokenService.CleanupExpiredTokens(ctx); err != nil {
+					logger.Error("Failed to cleanup expired refresh tokens", err)
+				}
+			}
+		}
+	}()
 
 	// Инициализация кеша
-	cache := cache.NewCache()
+	appCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		logger.Fatal("Failed to initialize cache", err)
+	}
+	// Загрузка значений при промахе: одна загрузка на ключ, фоновое обновление устаревших
+	// записей и кеширование отсутствующих записей. Все usecase инвалидируют кеш через
+	// cacheLoader, чтобы загрузки, начатые до инвалидации, не записали устаревшие значения
+	cacheLoader := cache.NewLoader(appCache, cfg.Cache, domainRepository.ErrNotFound)
+
+	// Метрики пула соединений, кеша и бизнес-показателей
+	metrics.RegisterDBStats(sqlDB, cfg.Database.DBName)
+	metrics.RegisterCache(appCache)
+	metrics.RegisterBusiness(repo.Book, repo.Trade)
+
+	// Хранилище изображений
+	blobStore, err := storage.NewBlobStore(&cfg.Storage)
+	if err != nil {
+		logger.Fatal("Failed to initialize media storage", err)
+	}
+	media := storage.NewMedia(blobStore, cfg.Storage.MediaURL)
 
 	// Инициализация usecase
+	hub := notify.NewHub()
+	notificationUsecase := usecase.NewNotificationUseCase(repo.Notification, hub)
+	wishlistMatcher := usecase.NewWishlistMatcher(repo.Wishlist, repo.State, notificationUsecase)
 	bookUsecase := usecase.NewBookUseCase(
 		repo.Book.(*mysql.BookRepository),
 		repo.Tag.(*mysql.TagRepository),
 		repo.State.(*mysql.StateRepository),
-		cache,
+		cacheLoader,
+		media,
+		wishlistMatcher,
 	)
 
 	tagUsecase := usecase.NewTagUseCase(
 		repo.Tag.(*mysql.TagRepository),
 		repo.Book.(*mysql.BookRepository),
-		cache,
+		cacheLoader,
+		media,
 	)
 
-	stateUsecase := usecase.NewStateUseCase(repo.State.(*mysql.StateRepository))
-	userUsecase := usecase.NewUserUseCase(repo.User, jwtAuth)
+	stateUsecase := usecase.NewStateUseCase(repo.State.(*mysql.StateRepository), cacheLoader)
+	userUsecase := usecase.NewUserUseCase(repo.User, repo.Review, repo.Trade, tokenService, media, cacheLoader)
+	tradeUsecase := usecase.NewTradeUseCase(repo.Trade, repo.Book, repo.User, repo.State, cacheLoader, notificationUsecase)
+	wishlistUsecase := usecase.NewWishlistUseCase(repo.Wishlist, repo.Tag)
+	cycleUsecase := usecase.NewCycleUseCase(repo.Trade, repo.Book, repo.Wishlist, repo.State, cacheLoader, notificationUsecase, cfg.Cycles.MaxLength)
+	conversationUsecase := usecase.NewConversationUseCase(repo.Conversation, repo.Book, repo.Trade, notificationUsecase)
+	reviewUsecase := usecase.NewReviewUseCase(repo.Review, repo.Trade, repo.User)
+	idempotencyUsecase := usecase.NewIdempotencyUseCase(repo.Idempotency, cfg.Idempotency.TTL)
+
+	// Периодически удаляем истекшие ключи идемпотентности
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := idempotencyUsecase.CleanupExpired(ctx); err != nil {
+					logger.Error("Failed to cleanup expired idempotency keys", err)
+				}
+			}
+		}
+	}()
+
+	// Периодически ищем кольцевые обмены по вишлистам и доступным книгам
+	if cfg.Cycles.ScanInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(cfg.Cycles.ScanInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, err := cycleUsecase.FindCycles(ctx); err != nil {
+						logger.Error("Failed to find trade cycles", err)
+					}
+				}
+			}
+		}()
+	}
+
+	// Проверки готовности: база отвечает, схема актуальна, кеш работает
+	migrationList, err := migrate.Load(migrations.FS)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", err)
+	}
+	checker := health.NewChecker(readinessCheckTimeout)
+	checker.Add("database", sqlDB.PingContext)
+	checker.Add("migrations", migrate.New(sqlDB, migrationList).Check)
+	checker.Add("cache", appCache.Ping)
 
 	// Инициализация HTTP обработчика
 	handler := httpHandler.NewHandler(
@@ -83,46 +204,60 @@ func main() {
 		tagUsecase,
 		stateUsecase,
 		userUsecase,
+		tradeUsecase,
+		wishlistUsecase,
+		cycleUsecase,
+		conversationUsecase,
+		notificationUsecase,
+		reviewUsecase,
+		idempotencyUsecase,
+		cursor.NewSigner(cfg.Pagination.CursorSecret),
+		media,
+		checker,
 	)
 
 	// Инициализация роутера
-	router := httpHandler.NewRouter(handler, jwtAuth)
+	router := httpHandler.NewRouter(handler, tokenService.GetTokenAuth(), cfg.Database.QueryTimeout)
 
 	// Запуск сервера
-	addr := cfg.Server.Host + ":" + strconv.Itoa(cfg.Server.Port)
-	logger.Info("Server starting on " + addr)
-	if err := http.ListenAndServe(addr, router); err != nil {
-		logger.Fatal("Failed to start server", err)
+	server := &http.Server{
+		Addr:         cfg.Server.Host + ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
-}
-
-// loggerMiddleware логирует HTTP запросы
-func loggerMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	// Shutdown не прерывает выполняющиеся запросы, поэтому потоки уведомлений
+	// завершаются закрытием подписок
+	server.RegisterOnShutdown(hub.Close)
 
-		// Создаем кастомный ResponseWriter для отслеживания статус-кода
-		ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	go func() {
+		logger.Info("Server starting", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("Failed to start server", err)
+		}
+	}()
 
-		next.ServeHTTP(ww, r)
+	<-ctx.Done()
+	stop()
+	logger.Info("Shutting down server")
+	checker.Shutdown()
 
-		duration := time.Since(start)
-		logger.Info(fmt.Sprintf("HTTP request: method=%s path=%s status=%d duration=%v",
-			r.Method,
-			r.URL.Path,
-			ww.statusCode,
-			duration,
-		))
-	})
-}
-
-// responseWriter кастомный ResponseWriter для отслеживания статус-кода
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
+	// Ждем завершения выполняющихся запросов, но не дольше ShutdownTimeout
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to shut down server gracefully", err)
+	}
 
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
+	if err := appCache.Close(); err != nil {
+		logger.Error("Failed to close cache", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("Failed to flush traces", err)
+	}
+	if err := sqlDB.Close(); err != nil {
+		logger.Error("Failed to close database connection", err)
+	}
+	logger.Info("Server stopped")
 }
@@ -0,0 +1,146 @@
+// Команда migrate-photos переносит изображения, которые хранятся в базе данных
+// в виде base64 data URI, в хранилище изображений и заменяет их ссылками /media/{hash}.
+//
+// Обрабатываются book_photos.photo_url, users.avatar и tags.photo. Для фотографий
+// книг также строятся миниатюра и вариант среднего размера, в том числе для уже
+// перенесенных изображений без вариантов. Команду можно запускать повторно:
+// обработанные строки пропускаются.
+//
+//	go run ./cmd/migrate-photos [-dry-run] [-batch-size 100]
+package main
+
+import (
+	"booktrading/internal/config"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/storage"
+	"booktrading/internal/repository/mysql"
+	"context"
+	"flag"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// column описывает колонку с изображениями
+type column struct {
+	table string
+	name  string
+	// variants - строить ли уменьшенные варианты (колонки medium_url и thumbnail_url)
+	variants bool
+}
+
+var columns = []column{
+	{table: "book_photos", name: "photo_url", variants: true},
+	{table: "users", name: "avatar"},
+	{table: "tags", name: "photo"},
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count rows that would be migrated")
+	batchSize := flag.Int("batch-size", 100, "number of rows loaded at once")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", err)
+	}
+
+	db, err := mysql.InitGormDB(&cfg.Database)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+
+	blobStore, err := storage.NewBlobStore(&cfg.Storage)
+	if err != nil {
+		logger.Fatal("Failed to initialize media storage", err)
+	}
+	media := storage.NewMedia(blobStore, cfg.Storage.MediaURL)
+
+	failedTotal := 0
+	for _, col := range columns {
+		migrated, failed, err := migrateColumn(db, media, col, *batchSize, *dryRun)
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("Failed to migrate %s.%s", col.table, col.name), err)
+		}
+		logger.Info(fmt.Sprintf("%s.%s: migrated %d, failed %d", col.table, col.name, migrated, failed))
+		failedTotal += failed
+	}
+
+	if failedTotal > 0 {
+		logger.Fatal("Some images were not migrated", fmt.Errorf("%d rows failed, see log above", failedTotal))
+	}
+}
+
+// migrateColumn переносит изображения одной колонки пачками по batchSize строк
+func migrateColumn(db *gorm.DB, media *storage.Media, col column, batchSize int, dryRun bool) (int, int, error) {
+	type row struct {
+		ID    uint
+		Value string
+	}
+
+	ctx := context.Background()
+	migrated, failed := 0, 0
+	var lastID uint
+
+	pending := col.name + " LIKE ?"
+	if col.variants {
+		pending = "(" + col.name + " LIKE ? OR thumbnail_url = '')"
+	}
+
+	for {
+		var rows []row
+		if err := db.Table(col.table).
+			Select("id, "+col.name+" AS value").
+			Where(pending+" AND id > ?", "data:%", lastID).
+			Order("id").
+			Limit(batchSize).
+			Scan(&rows).Error; err != nil {
+			return migrated, failed, err
+		}
+		if len(rows) == 0 {
+			return migrated, failed, nil
+		}
+
+		for _, r := range rows {
+			lastID = r.ID
+
+			if dryRun {
+				migrated++
+				continue
+			}
+
+			updates, err := storeImage(ctx, media, col, r.Value)
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to store image %s.%s id=%d", col.table, col.name, r.ID), err)
+				failed++
+				continue
+			}
+
+			if err := db.Table(col.table).Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+				return migrated, failed, err
+			}
+			migrated++
+		}
+	}
+}
+
+// storeImage сохраняет изображение в хранилище и возвращает новые значения колонок
+func storeImage(ctx context.Context, media *storage.Media, col column, value string) (map[string]interface{}, error) {
+	if !col.variants {
+		url, err := media.SaveDataURI(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{col.name: url}, nil
+	}
+
+	image, err := media.SaveImageDataURI(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		col.name:        image.URL,
+		"medium_url":    image.MediumURL,
+		"thumbnail_url": image.ThumbnailURL,
+	}, nil
+}
@@ -0,0 +1,182 @@
+// Команда migrate управляет схемой базы данных: применяет и откатывает SQL миграции
+// из каталога migrations и показывает их состояние. Примененные версии хранятся
+// в таблице schema_migrations вместе с контрольными суммами файлов.
+//
+//	go run ./cmd/migrate [-dry-run] up [N]        применить все или N следующих миграций
+//	go run ./cmd/migrate [-dry-run] down [N]      откатить последнюю или N последних миграций
+//	go run ./cmd/migrate status                   показать состояние миграций
+//	go run ./cmd/migrate create NAME              создать файлы новой миграции
+//	go run ./cmd/migrate [-dry-run] baseline N    отметить миграции до N примененными, не выполняя их
+//
+// С флагом -dry-run команда только печатает миграции и их SQL, не изменяя базу данных.
+package main
+
+import (
+	"booktrading/internal/config"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/migrate"
+	"booktrading/migrations"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "print migrations that would be applied or rolled back without changing the database")
+	dir := flag.String("dir", "migrations", "directory for new migration files (create command)")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	// Создание файлов не требует подключения к базе
+	if command == "create" {
+		if len(args) != 1 {
+			usage()
+			os.Exit(2)
+		}
+		upPath, downPath, err := migrate.Create(*dir, args[0])
+		if err != nil {
+			logger.Fatal("Failed to create migration", err)
+		}
+		fmt.Println(upPath)
+		fmt.Println(downPath)
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load config", err)
+	}
+
+	// multiStatements нужен, чтобы файл миграции мог содержать несколько запросов
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local&multiStatements=true",
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.DBName,
+	))
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer db.Close()
+
+	all, err := migrate.Load(migrations.FS)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", err)
+	}
+	migrator := migrate.New(db, all)
+	migrator.DryRun = *dryRun
+
+	ctx := context.Background()
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx, countArg(args, 0))
+		report(done, *dryRun, "Applied", "Would apply", func(m *migrate.Migration) string { return m.Up })
+		if err != nil {
+			logger.Fatal("Failed to apply migrations", err)
+		}
+	case "down":
+		done, err := migrator.Down(ctx, countArg(args, 1))
+		report(done, *dryRun, "Rolled back", "Would roll back", func(m *migrate.Migration) string { return m.Down })
+		if err != nil {
+			logger.Fatal("Failed to roll back migrations", err)
+		}
+	case "baseline":
+		if len(args) != 1 {
+			usage()
+			os.Exit(2)
+		}
+		done, err := migrator.Baseline(ctx, uint(countArg(args, 0)))
+		report(done, *dryRun, "Marked as applied", "Would mark as applied", nil)
+		if err != nil {
+			logger.Fatal("Failed to baseline migrations", err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logger.Fatal("Failed to get migration status", err)
+		}
+		printStatus(statuses)
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// countArg разбирает необязательный аргумент с числом миграций
+func countArg(args []string, def int) int {
+	if len(args) == 0 {
+		return def
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		logger.Fatal("Invalid number of migrations", fmt.Errorf("expected a positive number, got %q", args[0]))
+	}
+	return n
+}
+
+// report печатает обработанные миграции. В режиме dry-run печатается и их SQL
+func report(done []*migrate.Migration, dryRun bool, action, dryRunAction string, script func(m *migrate.Migration) string) {
+	if len(done) == 0 {
+		logger.Info("No migrations to process")
+		return
+	}
+	for _, m := range done {
+		if !dryRun {
+			logger.Info(fmt.Sprintf("%s %s", action, m))
+			continue
+		}
+		logger.Info(fmt.Sprintf("%s %s", dryRunAction, m))
+		if script != nil {
+			fmt.Println(script(m))
+		}
+	}
+}
+
+// printStatus печатает таблицу состояния миграций
+func printStatus(statuses []migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		status, appliedAt := "pending", ""
+		if s.AppliedAt != nil {
+			status, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		switch {
+		case s.Modified:
+			status = "modified"
+		case s.Unknown:
+			status = "unknown"
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+	}
+	w.Flush()
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up [N]        apply all or the next N pending migrations
+  down [N]      roll back the last or the last N applied migrations
+  status        show the state of every migration
+  create NAME   create empty up and down files of a new migration
+  baseline N    mark migrations up to N as applied without running them
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
@@ -69,7 +69,7 @@ const docTemplate = `{
         },
         "/api/v1/auth/logout": {
             "post": {
-                "description": "Logout user and invalidate refresh token. Requires a valid refresh token in the X-Refresh-Token header.",
+                "description": "Logout user and revoke the refresh token together with all tokens issued from it. Requires a valid refresh token in the X-Refresh-Token header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -112,6 +112,7 @@ const docTemplate = `{
                         "RefreshToken": []
                     }
                 ],
+                "description": "Exchange a refresh token for a new token pair. Each refresh token can be used only once; presenting an already used token revokes the whole session.",
                 "consumes": [
                     "application/json"
                 ],
@@ -166,6 +167,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/user.CreateUserDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -187,6 +194,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "422": {
+                        "description": "Idempotency-Key has been used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -198,7 +211,7 @@ const docTemplate = `{
         },
         "/api/v1/books": {
             "get": {
-                "description": "Get paginated list of all books",
+                "description": "Get paginated list of all books. Passing cursor or limit switches to keyset pagination ordered by creation time: the response then contains next_cursor and prev_cursor, and the Link header points to the neighbouring pages.",
                 "produces": [
                     "application/json"
                 ],
@@ -218,6 +231,24 @@ const docTemplate = `{
                         "description": "Items per page (default: 10, max: 100)",
                         "name": "pageSize",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Alias of pageSize",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (empty for the first page)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page in cursor mode (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -226,6 +257,18 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages in cursor mode"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "500": {
@@ -262,6 +305,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/book.CreateBookDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -283,6 +332,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key has been used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -294,35 +355,110 @@ const docTemplate = `{
         },
         "/api/v1/books/search": {
             "get": {
-                "description": "Search books by provided tag IDs",
+                "description": "Full-text search over title, author and description with filters, sorting and facet counts by tags and states",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Books"
                 ],
-                "summary": "Search books by tags",
+                "summary": "Search books",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Full-text query over title, author and description",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author name (substring match)",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "State ID",
+                        "name": "state_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Owner ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or before (RFC3339 or YYYY-MM-DD)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
                     {
                         "type": "array",
                         "items": {
                             "type": "integer"
                         },
-                        "collectionFormat": "csv",
+                        "collectionFormat": "multi",
                         "description": "Tag IDs",
+                        "name": "tag_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tag IDs (legacy name of tag_ids)",
                         "name": "tagIds",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "or",
+                            "and"
+                        ],
+                        "type": "string",
+                        "description": "How tags are combined: or (any tag, default) or and (all tags)",
+                        "name": "tag_mode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "relevance",
+                            "recent"
+                        ],
+                        "type": "string",
+                        "description": "Sort order: relevance (default, requires q) or recent",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Returns books, facets and pagination info",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/book.Book"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -357,6 +493,18 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response; 304 is returned if it still matches",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Used only without If-None-Match",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -364,8 +512,21 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/book.Book"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong entity tag of the representation"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change of the representation"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -386,7 +547,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Update existing book information. Only the book owner can update it.",
+                "description": "Update existing book information. Only the book owner or an admin can update it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -413,6 +574,13 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/book.UpdateBookDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -435,7 +603,7 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Forbidden - User is not the book owner",
+                        "description": "Forbidden - User is not the book owner or an admin",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
@@ -446,6 +614,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Transition to the requested state is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -460,7 +646,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Delete book by ID",
+                "description": "Delete book by ID. Books that are part of a pending or accepted trade cannot be deleted",
                 "tags": [
                     "Books"
                 ],
@@ -472,6 +658,13 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -490,12 +683,36 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Book is part of an active trade",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -505,16 +722,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/books/{id}/state": {
-            "patch": {
+        "/api/v1/books/{id}/photos": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update the state of an existing book. Only the book owner can update its state.",
+                "description": "Upload one or more JPEG/PNG photos as multipart/form-data (field \"photo\", can be repeated). Each file must not exceed 5MB and a book can have at most 5 photos including the existing ones. Photos are appended to the end of the gallery; if any file is rejected, none of them is added.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -522,7 +739,7 @@ const docTemplate = `{
                 "tags": [
                     "Books"
                 ],
-                "summary": "Update book state",
+                "summary": "Upload book photos",
                 "parameters": [
                     {
                         "type": "integer",
@@ -532,36 +749,50 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "New state data",
-                        "name": "state",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/book.UpdateBookStateDTO"
-                        }
+                        "type": "string",
+                        "description": "ETag of the current book representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Photo file (JPEG or PNG, up to 5MB)",
+                        "name": "photo",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Make the first uploaded photo the main one",
+                        "name": "is_main",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Updated book with new state",
+                    "201": {
+                        "description": "Uploaded photos",
                         "schema": {
-                            "$ref": "#/definitions/book.Book"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/book.BookPhoto"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request data or validation failed",
+                        "description": "Invalid image, too many photos or malformed form",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized - Invalid or missing token",
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "403": {
-                        "description": "Forbidden - User is not the book owner",
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
@@ -572,8 +803,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "412": {
+                        "description": "Book has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body is too large",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
@@ -581,14 +830,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/books/{id}/tags": {
-            "post": {
+        "/api/v1/books/{id}/photos/order": {
+            "put": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Add new tags to an existing book",
+                "description": "Set the gallery order of the book photos. The list must contain every photo of the book exactly once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -598,7 +847,7 @@ const docTemplate = `{
                 "tags": [
                     "Books"
                 ],
-                "summary": "Add tags to book",
+                "summary": "Reorder book photos",
                 "parameters": [
                     {
                         "type": "integer",
@@ -608,23 +857,30 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Tag IDs to add",
-                        "name": "tagIds",
+                        "type": "string",
+                        "description": "ETag of the current book representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Photo IDs in the new order",
+                        "name": "order",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "integer"
-                            }
+                            "$ref": "#/definitions/book.ReorderPhotosDTO"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Book photos in gallery order",
                         "schema": {
-                            "$ref": "#/definitions/book.Book"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/book.BookPhoto"
+                            }
                         }
                     },
                     "400": {
@@ -639,39 +895,28 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Book not found",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "412": {
+                        "description": "Book has been modified since it was read",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/states": {
-            "get": {
-                "description": "Get list of all book states",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "States"
-                ],
-                "summary": "Get all states",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/state.State"
-                            }
+                            "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "500": {
@@ -681,41 +926,46 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/v1/books/{id}/photos/{photoId}": {
+            "delete": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Create a new book state",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete a single photo of the book. If the main photo is deleted, the first remaining photo becomes the main one.",
                 "tags": [
-                    "States"
+                    "Books"
                 ],
-                "summary": "Create new state",
+                "summary": "Delete book photo",
                 "parameters": [
                     {
-                        "description": "State data",
-                        "name": "state",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/state.CreateStateDTO"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/state.State"
-                        }
+                        "type": "integer",
+                        "description": "Book ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Photo ID",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current book representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -729,6 +979,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Book or photo not found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Book has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -738,30 +1012,52 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/states/{id}": {
-            "get": {
-                "description": "Get book state information by its ID",
+        "/api/v1/books/{id}/photos/{photoId}/main": {
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Make the photo the main one for the book",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "States"
+                    "Books"
                 ],
-                "summary": "Get state by ID",
+                "summary": "Set main book photo",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "State ID",
+                        "description": "Book ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Photo ID",
+                        "name": "photoId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current book representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Book photos in gallery order",
                         "schema": {
-                            "$ref": "#/definitions/state.State"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/book.BookPhoto"
+                            }
                         }
                     },
                     "400": {
@@ -770,21 +1066,53 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Book or photo not found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Book has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/books/{id}/state": {
+            "patch": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update existing book state information",
+                "description": "Update the state of an existing book. Only the book owner or an admin can update its state.",
                 "consumes": [
                     "application/json"
                 ],
@@ -792,83 +1120,144 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "States"
+                    "Books"
                 ],
-                "summary": "Update state",
+                "summary": "Update book state",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "State ID",
+                        "description": "Book ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated state data",
+                        "description": "New state data",
                         "name": "state",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/state.UpdateStateDTO"
+                            "$ref": "#/definitions/book.UpdateBookStateDTO"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Updated book with new state",
                         "schema": {
-                            "$ref": "#/definitions/state.State"
+                            "$ref": "#/definitions/book.Book"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request data or validation failed",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized",
+                        "description": "Unauthorized - Invalid or missing token",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden - User is not the book owner or an admin",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Book not found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Transition to the requested state is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/books/{id}/tags": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete book state by ID",
+                "description": "Add new tags to an existing book",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "States"
+                    "Books"
                 ],
-                "summary": "Delete state",
+                "summary": "Add tags to book",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "State ID",
+                        "description": "Book ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Tag IDs to add",
+                        "name": "tagIds",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/book.Book"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -882,8 +1271,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Book or tag not found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
@@ -897,24 +1304,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tags": {
+        "/api/v1/conversations": {
             "get": {
-                "description": "Get list of all tags",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of conversations of the current user, most recently active first. Each conversation contains the unread_count of the current user, unread_total is the number of unread messages in all conversations.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Messages"
+                ],
+                "summary": "Get my conversations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all tags",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Returns conversations, unread_total and pagination info",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/tag.Tag"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "500": {
@@ -931,7 +1361,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Create a new tag with the provided name and optional photo",
+                "description": "Start a conversation with the owner of a book (book_id) or with the other party of a trade (trade_id) and send the first message. If the conversation already exists, the message is added to it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -939,17 +1369,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Messages"
                 ],
-                "summary": "Create new tag",
+                "summary": "Start conversation",
                 "parameters": [
                     {
-                        "description": "Tag data",
-                        "name": "tag",
+                        "description": "Conversation subject and first message",
+                        "name": "conversation",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/tag.CreateTagDTO"
+                            "$ref": "#/definitions/conversation.CreateConversationDTO"
                         }
                     }
                 ],
@@ -957,7 +1387,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/tag.Tag"
+                            "$ref": "#/definitions/conversation.Conversation"
                         }
                     },
                     "400": {
@@ -972,6 +1402,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -981,32 +1417,59 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tags/popular": {
+        "/api/v1/conversations/{id}": {
             "get": {
-                "description": "Get list of popular tags with optional limit",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get conversation with its participants. Only participants can see it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Messages"
                 ],
-                "summary": "Get popular tags",
+                "summary": "Get conversation by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Number of tags to return (default: 10)",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Conversation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/tag.Tag"
-                            }
+                            "$ref": "#/definitions/conversation.Conversation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1018,30 +1481,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/tags/{id}": {
+        "/api/v1/conversations/{id}/messages": {
             "get": {
-                "description": "Get tag information by its ID",
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get messages of the conversation with keyset pagination ordered by sending time. Without a cursor the latest messages are returned: prev_cursor leads to older messages and next_cursor can be used to poll for new ones. Only participants can read the conversation.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Messages"
                 ],
-                "summary": "Get tag by ID",
+                "summary": "Get conversation messages",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Tag ID",
+                        "description": "Conversation ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (empty for the latest messages)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Messages per page (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Returns messages, next_cursor, prev_cursor and limit",
                         "schema": {
-                            "$ref": "#/definitions/tag.Tag"
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages"
+                            }
                         }
                     },
                     "400": {
@@ -1050,21 +1537,39 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update existing tag information including optional photo",
+                "description": "Send a message to the conversation. Unread counters of the other participants are increased.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1072,32 +1577,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Tags"
+                    "Messages"
                 ],
-                "summary": "Update tag",
+                "summary": "Send message",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Tag ID",
+                        "description": "Conversation ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Updated tag data",
-                        "name": "tag",
+                        "description": "Message",
+                        "name": "message",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/tag.UpdateTagDTO"
+                            "$ref": "#/definitions/conversation.SendMessageDTO"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/tag.Tag"
+                            "$ref": "#/definitions/conversation.Message"
                         }
                     },
                     "400": {
@@ -1112,6 +1617,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1125,22 +1636,24 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/conversations/{id}/read": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete tag by ID",
+                "description": "Reset the unread counter of the current user in the conversation",
                 "tags": [
-                    "Tags"
+                    "Messages"
                 ],
-                "summary": "Delete tag",
+                "summary": "Mark conversation as read",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Tag ID",
+                        "description": "Conversation ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1162,6 +1675,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1177,22 +1696,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users": {
+        "/api/v1/notifications": {
             "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get paginated list of all users",
+                "description": "Get paginated notification inbox of the current user, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Notifications"
                 ],
-                "summary": "Get all users",
+                "summary": "Get notifications",
                 "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Return only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "Page number (default: 1)",
@@ -1208,12 +1733,18 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Returns users and pagination info",
+                        "description": "Returns notifications, unread_count and pagination info",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -1229,42 +1760,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users/{id}": {
-            "get": {
+        "/api/v1/notifications/read": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get user information by ID",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Mark every notification of the current user as read",
                 "tags": [
-                    "Users"
-                ],
-                "summary": "Get user by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Notifications"
                 ],
+                "summary": "Mark all notifications as read",
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/user.User"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/http.ErrorResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -1272,60 +1782,49 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/notifications/stream": {
+            "get": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Update existing user information",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Server-Sent Events stream of notifications for the current user. Events: trade_offer, trade_accepted, trade_cycle, message, wishlist_match, book_state_changed; the data field contains the notification as JSON and the id field its ID. Browsers' EventSource cannot send headers, so the access token can also be passed in the jwt query parameter or the jwt cookie. On reconnect, unread notifications created after the Last-Event-ID header are sent first.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "Users"
+                    "Notifications"
                 ],
-                "summary": "Update user",
+                "summary": "Stream notifications",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "Access token, if it cannot be sent in the Authorization header",
+                        "name": "jwt",
+                        "in": "query"
                     },
                     {
-                        "description": "Updated user data",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user.UpdateUserDTO"
-                        }
+                        "type": "integer",
+                        "description": "ID of the last received notification",
+                        "name": "Last-Event-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/user.User"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
+                        "description": "Event stream",
                         "schema": {
-                            "$ref": "#/definitions/http.ErrorResponse"
+                            "type": "string"
                         }
                     },
                     "401": {
@@ -1334,12 +1833,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/http.ErrorResponse"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1347,22 +1840,24 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/notifications/{id}/read": {
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Delete user by ID",
+                "description": "Mark a single notification of the current user as read",
                 "tags": [
-                    "Users"
+                    "Notifications"
                 ],
-                "summary": "Delete user",
+                "summary": "Mark notification as read",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Notification ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1399,48 +1894,226 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/users/{id}/books": {
+        "/api/v1/states": {
             "get": {
+                "description": "Get list of all book states",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "States"
+                ],
+                "summary": "Get all states",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/state.State"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
                 "security": [
                     {
                         "Bearer": []
                     }
                 ],
-                "description": "Get paginated list of user's books",
+                "description": "Create a new book state",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "States"
                 ],
-                "summary": "Get user books",
+                "summary": "Create new state",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "State data",
+                        "name": "state",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/state.CreateStateDTO"
+                        }
                     },
                     {
-                        "type": "integer",
-                        "description": "Page number (default: 1)",
-                        "name": "page",
-                        "in": "query"
-                    },
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/state.State"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key has been used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/states/{id}": {
+            "get": {
+                "description": "Get book state information by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "States"
+                ],
+                "summary": "Get state by ID",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "Items per page (default: 10, max: 100)",
-                        "name": "pageSize",
-                        "in": "query"
+                        "description": "State ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response; 304 is returned if it still matches",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Used only without If-None-Match",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Returns books and pagination info",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/state.State"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong entity tag of the representation"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change of the representation"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update existing book state information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "States"
+                ],
+                "summary": "Update state",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "State ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated state data",
+                        "name": "state",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/state.UpdateStateDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/state.State"
                         }
                     },
                     "400": {
@@ -1455,12 +2128,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/http.ErrorResponse"
                         }
                     },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1468,57 +2159,2721 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "book.Book": {
-            "description": "Модель книги в системе обмена",
-            "type": "object",
-            "properties": {
-                "author": {
-                    "description": "@Description Автор книги\n@example Лев Толстой",
-                    "type": "string"
-                },
-                "created_at": {
-                    "description": "@Description Дата создания\n@example 2024-03-20T10:00:00Z",
-                    "type": "string"
-                },
-                "description": {
-                    "description": "@Description Описание книги\n@example Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона",
-                    "type": "string"
-                },
-                "id": {
-                    "description": "@Description ID книги\n@example 1",
-                    "type": "integer"
-                },
-                "photos": {
-                    "description": "@Description Фотографии книги",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/book.BookPhoto"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
                     }
-                },
-                "state": {
-                    "description": "@Description Информация о состоянии книги",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/state.State"
+                ],
+                "description": "Delete book state by ID",
+                "tags": [
+                    "States"
+                ],
+                "summary": "Delete state",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "State ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
                         }
-                    ]
-                },
-                "state_id": {
-                    "description": "@Description ID состояния книги\n@example 1",
-                    "type": "integer"
-                },
-                "tags": {
-                    "description": "@Description Теги книги",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/tag.Tag"
                     }
-                },
-                "title": {
+                }
+            }
+        },
+        "/api/v1/states/{id}/transitions": {
+            "get": {
+                "description": "Get list of states a book can move to from the given state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "States"
+                ],
+                "summary": "Get state transitions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "State ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/state.Transition"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Allow books to move from the given state to another state",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "States"
+                ],
+                "summary": "Add state transition",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Source state ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current source state representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Target state",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/state.CreateTransitionDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/state.Transition"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source state not found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/states/{id}/transitions/{toId}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Forbid books to move from one state to another",
+                "tags": [
+                    "States"
+                ],
+                "summary": "Delete state transition",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Source state ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Target state ID",
+                        "name": "toId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current source state representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tags": {
+            "get": {
+                "description": "Get list of all tags",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get all tags",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tag.Tag"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create a new tag with the provided name and optional photo",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Create new tag",
+                "parameters": [
+                    {
+                        "description": "Tag data",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/tag.CreateTagDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/tag.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key has been used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/popular": {
+            "get": {
+                "description": "Get list of popular tags with optional limit",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get popular tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of tags to return (default: 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tag.Tag"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tags/{id}": {
+            "get": {
+                "description": "Get tag information by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Get tag by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response; 304 is returned if it still matches",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Used only without If-None-Match",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tag.Tag"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong entity tag of the representation"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Time of the last change of the representation"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update existing tag information including optional photo",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Update tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated tag data",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/tag.UpdateTagDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/tag.Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete tag by ID",
+                "tags": [
+                    "Tags"
+                ],
+                "summary": "Delete tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tag ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of multi-party trade cycles where the current user is a participant",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Get my trade cycles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status (pending, accepted, rejected, cancelled, completed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns cycles and pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles/scan": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Run the trade cycle search immediately instead of waiting for the periodic scan. Returns newly proposed cycles.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Find trade cycles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/trade.Cycle"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get multi-party trade cycle by its ID. Only cycle participants can see it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Get trade cycle by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade cycle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Accept a pending trade cycle. The cycle is accepted only when every participant has accepted it, then all involved books move to the \"trading\" state at once. If some book is no longer available, the cycle is cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Accept trade cycle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade cycle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Cancel an accepted trade cycle. All involved books become available again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Cancel trade cycle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade cycle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles/{id}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Confirm that the current user has received the book of an accepted trade cycle. Once all participants confirm, the cycle is completed: all involved books move to the \"traded\" state and change owners.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Complete trade cycle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade cycle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trade-cycles/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reject a pending trade cycle. A single rejection cancels the cycle for all participants.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Reject trade cycle",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade cycle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Cycle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of trades where the current user is the proposer or the recipient",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Get my trades",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status (pending, accepted, rejected, cancelled, countered, completed)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns trades and pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Offer one or more of your books in exchange for one or more books of another user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Propose trade",
+                "parameters": [
+                    {
+                        "description": "Trade offer",
+                        "name": "trade",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/trade.CreateTradeDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key has been used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get trade information by its ID. Only trade participants can see it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Get trade by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Accept a pending trade offer. All involved books move to the \"trading\" state.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Accept trade",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Cancel a pending offer (proposer only) or an accepted trade (any participant). Books of an accepted trade become available again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Cancel trade",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}/complete": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Confirm that the current user has received the books of an accepted trade. Once both parties confirm, the trade is completed: all involved books move to the \"traded\" state and change owners.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Complete trade",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}/counter": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Answer a pending trade offer with a counter-offer. The original offer gets the \"countered\" status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Counter trade",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Counter-offer",
+                        "name": "trade",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/trade.CounterTradeDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Unique key that makes retries of this request safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key has been used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Reject a pending trade offer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trades"
+                ],
+                "summary": "Reject trade",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trade.Trade"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/trades/{id}/reviews": {
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Rate the other party of a completed trade from 1 to 5 with an optional comment. Each party can review a trade only once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reviews"
+                ],
+                "summary": "Review trade partner",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Trade ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/review.CreateReviewDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/review.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of all users. Passing cursor or limit switches to keyset pagination ordered by creation time: the response then contains next_cursor and prev_cursor, and the Link header points to the neighbouring pages.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Alias of pageSize",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (empty for the first page)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page in cursor mode (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns users and pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages in cursor mode"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get user information by ID together with the user's reputation: average rating and number of completed trades",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response; 304 is returned if it still matches",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong entity tag of the representation"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Update existing user information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Updated user data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Delete user by ID",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/books": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of user's books",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user books",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Alias of size",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor from next_cursor or prev_cursor (empty for the first page)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page in cursor mode (default: 10, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns books and pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages in cursor mode"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of reviews received by the user, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reviews"
+                ],
+                "summary": "Get user reviews",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns reviews and pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/role": {
+            "patch": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Change the role of a user. Admin only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserRoleDTO"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the current representation",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Resource has been modified since it was read",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/wishlist": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get all wishes of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wishlist"
+                ],
+                "summary": "Get wishlist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/wishlist.Item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Add a wish to the user's wishlist. A wish matches a book by title or author substring, exact ISBN and a set of tags; every specified criterion must match. When a book becomes available, matching wishes are recorded and can be fetched from /wishlist/matches.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wishlist"
+                ],
+                "summary": "Add wishlist item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Wishlist item",
+                        "name": "item",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/wishlist.CreateItemDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/wishlist.Item"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/wishlist/matches": {
+            "get": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Get paginated list of available books that matched the user's wishlist, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Wishlist"
+                ],
+                "summary": "Get wishlist matches",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default: 10, max: 100)",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns matches and pagination info",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/wishlist/{itemId}": {
+            "delete": {
+                "security": [
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Remove a wish from the user's wishlist together with its matches",
+                "tags": [
+                    "Wishlist"
+                ],
+                "summary": "Delete wishlist item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Wishlist item ID",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the process is running and able to serve HTTP. Does not check dependencies",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{hash}": {
+            "get": {
+                "description": "Get an uploaded image by its content hash. Files never change, so they are served with a long-lived cache header.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png"
+                ],
+                "tags": [
+                    "Media"
+                ],
+                "summary": "Get media file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SHA-256 hash of the file content",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the service can accept traffic: the database answers a ping, all migrations are applied and unmodified, and the cache backend is healthy. Returns 503 during shutdown",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/http.HealthResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "book.Book": {
+            "description": "Модель книги в системе обмена",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Автор книги\n@example Лев Толстой",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "@Description Дата создания\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "@Description Описание книги\n@example Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description ID книги\n@example 1",
+                    "type": "integer"
+                },
+                "isbn": {
+                    "description": "@Description ISBN книги без дефисов (ISBN-10 или ISBN-13)\n@example 9785170906307",
+                    "type": "string"
+                },
+                "photos": {
+                    "description": "@Description Фотографии книги",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/book.BookPhoto"
+                    }
+                },
+                "state": {
+                    "description": "@Description Информация о состоянии книги",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/state.State"
+                        }
+                    ]
+                },
+                "state_id": {
+                    "description": "@Description ID состояния книги\n@example 1",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "@Description Теги книги",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tag.Tag"
+                    }
+                },
+                "title": {
                     "description": "@Description Название книги\n@example Война и мир",
                     "type": "string"
                 },
@@ -1526,219 +4881,719 @@ const docTemplate = `{
                     "description": "@Description Дата обновления\n@example 2024-03-20T10:00:00Z",
                     "type": "string"
                 },
-                "user": {
-                    "description": "@Description Информация о владельце книги",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/user.User"
-                        }
-                    ]
+                "user": {
+                    "description": "@Description Информация о владельце книги",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "description": "@Description ID владельца книги\n@example 1",
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "@Description Версия книги, увеличивается при каждом изменении книги, ее тегов и фотографий\n@example 3",
+                    "type": "integer"
+                }
+            }
+        },
+        "book.BookPhoto": {
+            "description": "Модель фотографии книги",
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "description": "@Description ID книги, к которой относится фотография\n@example 1",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "@Description Дата создания\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description ID фотографии\n@example 1",
+                    "type": "integer"
+                },
+                "is_main": {
+                    "description": "@Description Флаг, указывающий является ли фотография главной\n@example true",
+                    "type": "boolean"
+                },
+                "medium_url": {
+                    "description": "@Description URL варианта среднего размера (до 800px по длинной стороне)\n@example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b-medium",
+                    "type": "string"
+                },
+                "photo_url": {
+                    "description": "@Description URL фотографии в хранилище изображений\n@example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b",
+                    "type": "string"
+                },
+                "position": {
+                    "description": "@Description Порядковый номер фотографии в галерее книги, начиная с 0\n@example 0",
+                    "type": "integer"
+                },
+                "thumbnail_url": {
+                    "description": "@Description URL миниатюры (до 200px по длинной стороне)\n@example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b-thumb",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "@Description Дата обновления\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
+                }
+            }
+        },
+        "book.BookPhotoData": {
+            "description": "Данные фотографии для создания книги",
+            "type": "object",
+            "properties": {
+                "is_main": {
+                    "description": "@Description Флаг, указывающий является ли фотография главной\n@example true",
+                    "type": "boolean"
+                },
+                "photo_url": {
+                    "description": "@Description URL фотографии в формате base64\n@example \"data:image/jpeg;base64,/9j/4AAQSkZJRg...\"",
+                    "type": "string"
+                }
+            }
+        },
+        "book.CreateBookDTO": {
+            "description": "Данные для создания новой книги",
+            "type": "object",
+            "required": [
+                "author",
+                "state_id",
+                "title",
+                "user_id"
+            ],
+            "properties": {
+                "author": {
+                    "description": "@Description Автор книги\n@example \"Лев Толстой\"",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "@Description Описание книги\n@example \"Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона\"",
+                    "type": "string"
+                },
+                "isbn": {
+                    "description": "@Description ISBN книги, дефисы допускаются\n@example \"978-5-17-090630-7\"",
+                    "type": "string"
+                },
+                "photos": {
+                    "description": "@Description Массив фотографий книги",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/book.BookPhotoData"
+                    }
+                },
+                "state_id": {
+                    "description": "@Description ID состояния книги (1 - доступна, 2 - недоступна)\n@example 1",
+                    "type": "integer"
+                },
+                "tag_ids": {
+                    "description": "@Description Массив ID тегов книги\n@example [1, 2, 3]",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "description": "@Description Название книги\n@example \"Война и мир\"",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "@Description ID пользователя-владельца книги\n@example 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "book.ReorderPhotosDTO": {
+            "description": "Новый порядок фотографий книги",
+            "type": "object",
+            "required": [
+                "photo_ids"
+            ],
+            "properties": {
+                "photo_ids": {
+                    "description": "@Description ID всех фотографий книги в новом порядке\n@example [3, 1, 2]",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "book.UpdateBookDTO": {
+            "description": "Данные для обновления существующей книги",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Автор книги\n@example Лев Толстой",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "@Description Описание книги\n@example Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона",
+                    "type": "string"
+                },
+                "isbn": {
+                    "description": "@Description ISBN книги, дефисы допускаются\n@example 978-5-17-090630-7",
+                    "type": "string"
+                },
+                "photos": {
+                    "description": "@Description Новый набор фотографий книги. Если поле передано, он заменяет текущие фотографии",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/book.BookPhotoData"
+                    }
+                },
+                "state_id": {
+                    "description": "@Description ID состояния книги\n@example 1",
+                    "type": "integer"
+                },
+                "tag_ids": {
+                    "description": "@Description Массив ID тегов книги\n@example [1, 2, 3]",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "description": "@Description Название книги\n@example Война и мир",
+                    "type": "string"
+                }
+            }
+        },
+        "book.UpdateBookStateDTO": {
+            "description": "Данные для обновления состояния книги",
+            "type": "object",
+            "required": [
+                "state_id"
+            ],
+            "properties": {
+                "state_id": {
+                    "description": "@Description ID нового состояния книги\n@example 1",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "conversation.Conversation": {
+            "description": "Переписка между владельцем книги и заинтересованным пользователем или участниками обмена",
+            "type": "object",
+            "properties": {
+                "book_id": {
+                    "description": "@Description ID книги, о которой идет переписка\n@example 1",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "@Description Время создания записи\n@example 2025-04-28T12:00:00Z",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Уникальный идентификатор\n@example 1",
+                    "type": "integer"
+                },
+                "last_message_at": {
+                    "description": "@Description Время последнего сообщения\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
+                },
+                "participants": {
+                    "description": "@Description Участники переписки",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/conversation.Participant"
+                    }
+                },
+                "trade_id": {
+                    "description": "@Description ID обмена, о котором идет переписка\n@example 1",
+                    "type": "integer"
+                },
+                "unread_count": {
+                    "description": "@Description Число непрочитанных сообщений текущего пользователя\n@example 2",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
+                    "type": "string"
+                }
+            }
+        },
+        "conversation.CreateConversationDTO": {
+            "description": "Данные для начала переписки. Нужно указать ровно одно из book_id или trade_id",
+            "type": "object",
+            "required": [
+                "message"
+            ],
+            "properties": {
+                "book_id": {
+                    "description": "@Description ID книги: переписка с ее владельцем\n@example 1",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "message": {
+                    "description": "@Description Первое сообщение\n@example Здравствуйте! Книга еще доступна?",
+                    "type": "string",
+                    "maxLength": 2000
+                },
+                "trade_id": {
+                    "description": "@Description ID обмена: переписка между его участниками\n@example 1",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "conversation.Message": {
+            "description": "Сообщение в переписке",
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "@Description Текст сообщения\n@example Здравствуйте! Книга еще доступна?",
+                    "type": "string"
+                },
+                "conversation_id": {
+                    "description": "@Description ID переписки\n@example 1",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "description": "@Description Время отправки\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description ID сообщения\n@example 1",
+                    "type": "integer"
+                },
+                "sender_id": {
+                    "description": "@Description ID отправителя\n@example 2",
+                    "type": "integer"
+                }
+            }
+        },
+        "conversation.Participant": {
+            "description": "Участник переписки",
+            "type": "object",
+            "properties": {
+                "conversation_id": {
+                    "description": "@Description ID переписки\n@example 1",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "@Description ID записи участника\n@example 1",
+                    "type": "integer"
+                },
+                "last_read_at": {
+                    "description": "@Description Время, когда участник последний раз прочитал переписку\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
+                },
+                "unread_count": {
+                    "description": "@Description Число непрочитанных сообщений участника\n@example 2",
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "@Description Информация о пользователе",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "description": "@Description ID пользователя\n@example 2",
+                    "type": "integer"
+                }
+            }
+        },
+        "conversation.SendMessageDTO": {
+            "description": "Данные для отправки сообщения",
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "description": "@Description Текст сообщения\n@example Могу встретиться завтра",
+                    "type": "string",
+                    "maxLength": 2000
+                }
+            }
+        },
+        "http.ErrorResponse": {
+            "description": "Структура для возврата ошибок API",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Error message"
+                }
+            }
+        },
+        "http.HealthResponse": {
+            "description": "Результат проверки здоровья сервиса",
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Результаты проверок зависимостей: ok или текст ошибки",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "description": "Общий статус: ok или unavailable",
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "response.LoginResponse": {
+            "description": "Полный ответ при успешном входе в систему",
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "@Description Время жизни токена доступа в секундах\n@example 900",
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "description": "@Description Одноразовый токен для обновления доступа\n@example 8mZ3oQ1q7bQd2vR5w0cJ4kTnY6xE9uHs-AaLpBfGiKo",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "@Description JWT токен доступа\n@example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+                    "type": "string"
                 },
                 "user_id": {
-                    "description": "@Description ID владельца книги\n@example 1",
+                    "description": "@Description ID пользователя\n@example 1",
                     "type": "integer"
                 }
             }
         },
-        "book.BookPhoto": {
-            "description": "Модель фотографии книги",
+        "response.TokenResponse": {
+            "description": "Ответ с токенами доступа",
             "type": "object",
             "properties": {
-                "book_id": {
-                    "description": "@Description ID книги, к которой относится фотография\n@example 1",
+                "expires_in": {
+                    "description": "@Description Время жизни токена доступа в секундах\n@example 900",
                     "type": "integer"
                 },
+                "refresh_token": {
+                    "description": "@Description Одноразовый токен для обновления доступа\n@example 8mZ3oQ1q7bQd2vR5w0cJ4kTnY6xE9uHs-AaLpBfGiKo",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "@Description JWT токен доступа\n@example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+                    "type": "string"
+                }
+            }
+        },
+        "review.CreateReviewDTO": {
+            "description": "Данные для отзыва о другом участнике завершенного обмена",
+            "type": "object",
+            "required": [
+                "rating"
+            ],
+            "properties": {
+                "comment": {
+                    "description": "@Description Комментарий\n@example Книга в отличном состоянии, обмен прошел быстро",
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "rating": {
+                    "description": "@Description Оценка от 1 до 5\n@example 5",
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                }
+            }
+        },
+        "review.Review": {
+            "description": "Отзыв об участнике обмена",
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "description": "@Description Комментарий\n@example Книга в отличном состоянии, обмен прошел быстро",
+                    "type": "string"
+                },
                 "created_at": {
-                    "description": "@Description Дата создания\n@example 2024-03-20T10:00:00Z",
+                    "description": "@Description Время создания записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 },
                 "id": {
-                    "description": "@Description ID фотографии\n@example 1",
+                    "description": "@Description Уникальный идентификатор\n@example 1",
                     "type": "integer"
                 },
-                "is_main": {
-                    "description": "@Description Флаг, указывающий является ли фотография главной\n@example true",
-                    "type": "boolean"
+                "rating": {
+                    "description": "@Description Оценка от 1 до 5\n@example 5",
+                    "type": "integer"
                 },
-                "photo_url": {
-                    "description": "@Description URL фотографии в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
-                    "type": "string"
+                "reviewee_id": {
+                    "description": "@Description ID пользователя, о котором оставлен отзыв\n@example 2",
+                    "type": "integer"
+                },
+                "reviewer": {
+                    "description": "@Description Информация об авторе отзыва",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    ]
+                },
+                "reviewer_id": {
+                    "description": "@Description ID автора отзыва\n@example 1",
+                    "type": "integer"
+                },
+                "trade_id": {
+                    "description": "@Description ID обмена, по итогам которого оставлен отзыв\n@example 1",
+                    "type": "integer"
                 },
                 "updated_at": {
-                    "description": "@Description Дата обновления\n@example 2024-03-20T10:00:00Z",
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 }
             }
         },
-        "book.BookPhotoData": {
-            "description": "Данные фотографии для создания книги",
+        "state.CreateStateDTO": {
+            "description": "Данные для создания нового состояния",
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "is_main": {
-                    "description": "@Description Флаг, указывающий является ли фотография главной\n@example true",
-                    "type": "boolean"
-                },
-                "photo_url": {
-                    "description": "@Description URL фотографии в формате base64\n@example \"data:image/jpeg;base64,/9j/4AAQSkZJRg...\"",
-                    "type": "string"
+                "name": {
+                    "description": "@Description Название состояния\n@example available",
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
                 }
             }
         },
-        "book.CreateBookDTO": {
-            "description": "Данные для создания новой книги",
+        "state.CreateTransitionDTO": {
+            "description": "Данные для добавления разрешенного перехода между состояниями",
             "type": "object",
             "required": [
-                "author",
-                "state_id",
-                "title",
-                "user_id"
+                "to_state_id"
             ],
             "properties": {
-                "author": {
-                    "description": "@Description Автор книги\n@example \"Лев Толстой\"",
-                    "type": "string"
-                },
-                "description": {
-                    "description": "@Description Описание книги\n@example \"Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона\"",
+                "to_state_id": {
+                    "description": "@Description ID целевого состояния\n@example 2",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "state.State": {
+            "description": "Модель состояния книги",
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "@Description Время создания записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 },
-                "photos": {
-                    "description": "@Description Массив фотографий книги",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/book.BookPhotoData"
-                    }
-                },
-                "state_id": {
-                    "description": "@Description ID состояния книги (1 - доступна, 2 - недоступна)\n@example 1",
+                "id": {
+                    "description": "@Description Уникальный идентификатор\n@example 1",
                     "type": "integer"
                 },
-                "tag_ids": {
-                    "description": "@Description Массив ID тегов книги\n@example [1, 2, 3]",
+                "name": {
+                    "description": "@Description Название состояния\n@example available",
+                    "type": "string"
+                },
+                "transitions": {
+                    "description": "@Description Разрешенные переходы из этого состояния",
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/state.Transition"
                     }
                 },
-                "title": {
-                    "description": "@Description Название книги\n@example \"Война и мир\"",
+                "updated_at": {
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 },
-                "user_id": {
-                    "description": "@Description ID пользователя-владельца книги\n@example 1",
+                "version": {
+                    "description": "@Description Версия состояния, увеличивается при каждом изменении, в том числе переходов\n@example 1",
                     "type": "integer"
                 }
             }
         },
-        "book.UpdateBookDTO": {
-            "description": "Данные для обновления существующей книги",
+        "state.Transition": {
+            "description": "Модель разрешенного перехода между состояниями книги",
             "type": "object",
             "properties": {
-                "author": {
-                    "description": "@Description Автор книги\n@example Лев Толстой",
-                    "type": "string"
-                },
-                "description": {
-                    "description": "@Description Описание книги\n@example Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона",
+                "created_at": {
+                    "description": "@Description Время создания записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 },
-                "photos": {
-                    "description": "@Description Массив URL фотографий в формате base64\n@example [\"data:image/jpeg;base64,/9j/4AAQSkZJRg...\"]",
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "from_state_id": {
+                    "description": "@Description ID исходного состояния\n@example 1",
+                    "type": "integer"
                 },
-                "state_id": {
-                    "description": "@Description ID состояния книги\n@example 1",
+                "id": {
+                    "description": "@Description Уникальный идентификатор\n@example 1",
                     "type": "integer"
                 },
-                "tag_ids": {
-                    "description": "@Description Массив ID тегов книги\n@example [1, 2, 3]",
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "to_state": {
+                    "description": "@Description Информация о целевом состоянии",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/state.State"
+                        }
+                    ]
                 },
-                "title": {
-                    "description": "@Description Название книги\n@example Война и мир",
+                "to_state_id": {
+                    "description": "@Description ID целевого состояния\n@example 2",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 }
             }
         },
-        "book.UpdateBookStateDTO": {
-            "description": "Данные для обновления состояния книги",
+        "state.UpdateStateDTO": {
+            "description": "Данные для обновления существующего состояния",
             "type": "object",
             "required": [
-                "state_id"
+                "name"
             ],
             "properties": {
-                "state_id": {
-                    "description": "@Description ID нового состояния книги\n@example 1",
-                    "type": "integer",
-                    "minimum": 1
+                "name": {
+                    "description": "@Description Название состояния\n@example available",
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3
                 }
             }
         },
-        "http.ErrorResponse": {
-            "description": "Структура для возврата ошибок API",
+        "tag.CreateTagDTO": {
+            "description": "Данные для создания нового тега",
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "error": {
+                "name": {
+                    "description": "@Description Название тега\n@example fiction",
                     "type": "string",
-                    "example": "Error message"
+                    "maxLength": 255,
+                    "minLength": 1
+                },
+                "photo": {
+                    "description": "@Description Фото тега в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
+                    "type": "string"
                 }
             }
         },
-        "response.LoginResponse": {
-            "description": "Полный ответ при успешном входе в систему",
+        "tag.Tag": {
+            "description": "Модель тега для категоризации книг",
             "type": "object",
             "properties": {
-                "refresh_token": {
-                    "description": "@Description Токен для обновления доступа\n@example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+                "created_at": {
+                    "description": "@Description Время создания записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 },
-                "token": {
-                    "description": "@Description JWT токен доступа\n@example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+                "id": {
+                    "description": "@Description Уникальный идентификатор\n@example 1",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "@Description Название тега\n@example fiction",
                     "type": "string"
                 },
-                "user_id": {
-                    "description": "@Description ID пользователя\n@example 1",
+                "photo": {
+                    "description": "@Description URL фото тега\n@example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "@Description Версия тега, увеличивается при каждом изменении\n@example 1",
                     "type": "integer"
                 }
             }
         },
-        "response.TokenResponse": {
-            "description": "Ответ с токенами доступа",
+        "tag.UpdateTagDTO": {
+            "description": "Данные для обновления существующего тега",
             "type": "object",
             "properties": {
-                "refresh_token": {
-                    "description": "@Description Токен для обновления доступа\n@example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
-                    "type": "string"
+                "name": {
+                    "description": "@Description Название тега\n@example fiction",
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
                 },
-                "token": {
-                    "description": "@Description JWT токен доступа\n@example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...",
+                "photo": {
+                    "description": "@Description Фото тега в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
                     "type": "string"
                 }
             }
         },
-        "state.CreateStateDTO": {
-            "description": "Данные для создания нового состояния",
+        "trade.CounterTradeDTO": {
+            "description": "Данные для встречного предложения обмена",
+            "type": "object",
+            "required": [
+                "offered_book_ids",
+                "requested_book_ids"
+            ],
+            "properties": {
+                "message": {
+                    "description": "@Description Сообщение к встречному предложению\n@example Могу отдать две книги за одну",
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "offered_book_ids": {
+                    "description": "@Description ID книг отвечающего, отдаваемых в обмен\n@example [5, 6]",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "requested_book_ids": {
+                    "description": "@Description ID книг инициатора исходного предложения, запрашиваемых в обмен\n@example [1]",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "trade.CreateTradeDTO": {
+            "description": "Данные для создания предложения обмена",
             "type": "object",
             "required": [
-                "name"
+                "offered_book_ids",
+                "recipient_id",
+                "requested_book_ids"
             ],
             "properties": {
-                "name": {
-                    "description": "@Description Название состояния\n@example available",
+                "message": {
+                    "description": "@Description Сообщение к предложению\n@example Меняю на вашу книгу, состояние отличное",
                     "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3
+                    "maxLength": 1000
+                },
+                "offered_book_ids": {
+                    "description": "@Description ID книг предлагающего, отдаваемых в обмен\n@example [1, 2]",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "recipient_id": {
+                    "description": "@Description ID пользователя, которому адресовано предложение\n@example 2",
+                    "type": "integer"
+                },
+                "requested_book_ids": {
+                    "description": "@Description ID книг получателя, запрашиваемых в обмен\n@example [5]",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
                 }
             }
         },
-        "state.State": {
-            "description": "Модель состояния книги",
+        "trade.Cycle": {
+            "description": "Кольцевой обмен между несколькими пользователями",
             "type": "object",
             "properties": {
                 "created_at": {
@@ -1749,9 +5604,27 @@ const docTemplate = `{
                     "description": "@Description Уникальный идентификатор\n@example 1",
                     "type": "integer"
                 },
-                "name": {
-                    "description": "@Description Название состояния\n@example available",
-                    "type": "string"
+                "items": {
+                    "description": "@Description Книги, передаваемые в рамках обмена",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/trade.CycleItem"
+                    }
+                },
+                "participants": {
+                    "description": "@Description Участники обмена и их решения",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/trade.CycleParticipant"
+                    }
+                },
+                "status": {
+                    "description": "@Description Статус кольцевого обмена\n@example pending",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/trade.Status"
+                        }
+                    ]
                 },
                 "updated_at": {
                     "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
@@ -1759,42 +5632,138 @@ const docTemplate = `{
                 }
             }
         },
-        "state.UpdateStateDTO": {
-            "description": "Данные для обновления существующего состояния",
+        "trade.CycleItem": {
+            "description": "Книга, участвующая в кольцевом обмене",
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
-                    "description": "@Description Название состояния\n@example available",
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3
+                "book": {
+                    "description": "@Description Информация о книге",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/book.Book"
+                        }
+                    ]
+                },
+                "book_id": {
+                    "description": "@Description ID книги. Пусто, если книгу удалили после завершения обмена\n@example 1",
+                    "type": "integer"
+                },
+                "cycle_id": {
+                    "description": "@Description ID кольцевого обмена\n@example 1",
+                    "type": "integer"
+                },
+                "from_user_id": {
+                    "description": "@Description ID пользователя, отдающего книгу\n@example 1",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "@Description ID позиции обмена\n@example 1",
+                    "type": "integer"
+                },
+                "to_user_id": {
+                    "description": "@Description ID пользователя, получающего книгу\n@example 2",
+                    "type": "integer"
                 }
             }
         },
-        "tag.CreateTagDTO": {
-            "description": "Данные для создания нового тега",
+        "trade.CycleParticipant": {
+            "description": "Участник кольцевого обмена",
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
-                    "description": "@Description Название тега\n@example fiction",
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 1
+                "accepted_at": {
+                    "description": "@Description Время, когда участник принял обмен\n@example 2024-03-20T10:00:00Z",
+                    "type": "string"
                 },
-                "photo": {
-                    "description": "@Description Фото тега в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
+                "completed_at": {
+                    "description": "@Description Время, когда участник подтвердил получение книги\n@example 2024-03-25T10:00:00Z",
                     "type": "string"
+                },
+                "cycle_id": {
+                    "description": "@Description ID кольцевого обмена\n@example 1",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "@Description ID записи участника\n@example 1",
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "@Description Информация о пользователе",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "description": "@Description ID пользователя\n@example 2",
+                    "type": "integer"
                 }
             }
         },
-        "tag.Tag": {
-            "description": "Модель тега для категоризации книг",
+        "trade.Item": {
+            "description": "Книга, участвующая в обмене",
+            "type": "object",
+            "properties": {
+                "book": {
+                    "description": "@Description Информация о книге",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/book.Book"
+                        }
+                    ]
+                },
+                "book_id": {
+                    "description": "@Description ID книги. Пусто, если книгу удалили после завершения обмена\n@example 1",
+                    "type": "integer"
+                },
+                "from_user_id": {
+                    "description": "@Description ID пользователя, отдающего книгу\n@example 1",
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "@Description ID позиции обмена\n@example 1",
+                    "type": "integer"
+                },
+                "to_user_id": {
+                    "description": "@Description ID пользователя, получающего книгу\n@example 2",
+                    "type": "integer"
+                },
+                "trade_id": {
+                    "description": "@Description ID обмена\n@example 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "trade.Status": {
+            "description": "Перечисление возможных статусов обмена",
+            "type": "string",
+            "enum": [
+                "pending",
+                "accepted",
+                "rejected",
+                "cancelled",
+                "countered",
+                "completed"
+            ],
+            "x-enum-comments": {
+                "StatusAccepted": "Принято, книги в процессе обмена",
+                "StatusCancelled": "Отменено одним из участников",
+                "StatusCompleted": "Обмен завершен, книги обменяны",
+                "StatusCountered": "Получатель выдвинул встречное предложение",
+                "StatusPending": "Ожидает ответа получателя",
+                "StatusRejected": "Отклонено получателем"
+            },
+            "x-enum-varnames": [
+                "StatusPending",
+                "StatusAccepted",
+                "StatusRejected",
+                "StatusCancelled",
+                "StatusCountered",
+                "StatusCompleted"
+            ]
+        },
+        "trade.Trade": {
+            "description": "Модель предложения обмена книгами",
             "type": "object",
             "properties": {
                 "created_at": {
@@ -1805,32 +5774,63 @@ const docTemplate = `{
                     "description": "@Description Уникальный идентификатор\n@example 1",
                     "type": "integer"
                 },
-                "name": {
-                    "description": "@Description Название тега\n@example fiction",
+                "items": {
+                    "description": "@Description Книги, участвующие в обмене",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/trade.Item"
+                    }
+                },
+                "message": {
+                    "description": "@Description Сообщение к предложению\n@example Меняю на вашу книгу, состояние отличное",
                     "type": "string"
                 },
-                "photo": {
-                    "description": "@Description Фото тега в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
+                "parent_id": {
+                    "description": "@Description ID предложения, на которое это предложение является встречным\n@example 3",
+                    "type": "integer"
+                },
+                "proposer": {
+                    "description": "@Description Информация о пользователе, предложившем обмен",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    ]
+                },
+                "proposer_completed_at": {
+                    "description": "@Description Время, когда предложивший обмен подтвердил получение книг\n@example 2024-03-20T10:00:00Z",
                     "type": "string"
                 },
-                "updated_at": {
-                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
+                "proposer_id": {
+                    "description": "@Description ID пользователя, предложившего обмен\n@example 1",
+                    "type": "integer"
+                },
+                "recipient": {
+                    "description": "@Description Информация о получателе предложения",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.User"
+                        }
+                    ]
+                },
+                "recipient_completed_at": {
+                    "description": "@Description Время, когда получатель подтвердил получение книг\n@example 2024-03-20T10:00:00Z",
                     "type": "string"
-                }
-            }
-        },
-        "tag.UpdateTagDTO": {
-            "description": "Данные для обновления существующего тега",
-            "type": "object",
-            "properties": {
-                "name": {
-                    "description": "@Description Название тега\n@example fiction",
-                    "type": "string",
-                    "maxLength": 255,
-                    "minLength": 1
                 },
-                "photo": {
-                    "description": "@Description Фото тега в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
+                "recipient_id": {
+                    "description": "@Description ID пользователя, которому адресовано предложение\n@example 2",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "@Description Статус обмена\n@example pending",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/trade.Status"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
                     "type": "string"
                 }
             }
@@ -1882,6 +5882,43 @@ const docTemplate = `{
                 }
             }
         },
+        "user.Reputation": {
+            "description": "Репутация пользователя",
+            "type": "object",
+            "properties": {
+                "completed_trades": {
+                    "description": "@Description Число завершенных обменов\n@example 15",
+                    "type": "integer"
+                },
+                "rating": {
+                    "description": "@Description Средняя оценка по отзывам, 0 если отзывов нет\n@example 4.5",
+                    "type": "number"
+                },
+                "review_count": {
+                    "description": "@Description Число полученных отзывов\n@example 12",
+                    "type": "integer"
+                }
+            }
+        },
+        "user.Role": {
+            "description": "Перечисление возможных ролей пользователя",
+            "type": "string",
+            "enum": [
+                "user",
+                "moderator",
+                "admin"
+            ],
+            "x-enum-comments": {
+                "RoleAdmin": "Администратор",
+                "RoleModerator": "Модератор",
+                "RoleUser": "Обычный пользователь"
+            },
+            "x-enum-varnames": [
+                "RoleUser",
+                "RoleModerator",
+                "RoleAdmin"
+            ]
+        },
         "user.UpdateUserDTO": {
             "description": "Данные для обновления существующего пользователя",
             "type": "object",
@@ -1898,12 +5935,34 @@ const docTemplate = `{
                 }
             }
         },
+        "user.UpdateUserRoleDTO": {
+            "description": "Данные для изменения роли пользователя",
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "@Description Новая роль пользователя\n@example moderator",
+                    "enum": [
+                        "user",
+                        "moderator",
+                        "admin"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.Role"
+                        }
+                    ]
+                }
+            }
+        },
         "user.User": {
             "description": "Модель пользователя системы обмена книгами",
             "type": "object",
             "properties": {
                 "avatar": {
-                    "description": "@Description Аватар пользователя в формате base64\n@example data:image/jpeg;base64,/9j/4AAQSkZJRg...",
+                    "description": "@Description URL аватара пользователя\n@example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b",
                     "type": "string"
                 },
                 "book_ids": {
@@ -1925,6 +5984,22 @@ const docTemplate = `{
                     "description": "@Description Логин пользователя\n@example john_doe",
                     "type": "string"
                 },
+                "reputation": {
+                    "description": "@Description Репутация пользователя (не сохраняется в БД, возвращается в профиле пользователя)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.Reputation"
+                        }
+                    ]
+                },
+                "role": {
+                    "description": "@Description Роль пользователя\n@example user",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/user.Role"
+                        }
+                    ]
+                },
                 "updated_at": {
                     "description": "@Description Дата обновления\n@example 2024-03-20T10:00:00Z",
                     "type": "string"
@@ -1932,6 +6007,78 @@ const docTemplate = `{
                 "username": {
                     "description": "@Description Отображаемое имя пользователя\n@example John Doe",
                     "type": "string"
+                },
+                "version": {
+                    "description": "@Description Версия пользователя, увеличивается при каждом изменении\n@example 2",
+                    "type": "integer"
+                }
+            }
+        },
+        "wishlist.CreateItemDTO": {
+            "description": "Данные для добавления книги в вишлист. Нужно указать хотя бы один критерий",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Часть имени автора\n@example Толстой",
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "isbn": {
+                    "description": "@Description ISBN книги, дефисы допускаются\n@example 978-5-17-090630-7",
+                    "type": "string"
+                },
+                "tag_ids": {
+                    "description": "@Description ID тегов, которые должны быть у книги\n@example [1, 2]",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "description": "@Description Часть названия книги\n@example Война и мир",
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "wishlist.Item": {
+            "description": "Пожелание из вишлиста пользователя",
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "@Description Часть имени автора (без учета регистра)\n@example Толстой",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "@Description Время создания записи\n@example 2025-04-28T12:00:00Z",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "@Description Уникальный идентификатор\n@example 1",
+                    "type": "integer"
+                },
+                "isbn": {
+                    "description": "@Description ISBN книги без дефисов\n@example 9785170906307",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "@Description Теги, которые должны быть у книги (все одновременно)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tag.Tag"
+                    }
+                },
+                "title": {
+                    "description": "@Description Часть названия книги (без учета регистра)\n@example Война и мир",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "@Description Время последнего обновления записи\n@example 2025-04-28T12:00:00Z",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "@Description ID пользователя, которому принадлежит пожелание\n@example 1",
+                    "type": "integer"
                 }
             }
         }
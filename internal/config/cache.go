@@ -1,11 +1,34 @@
 package config
 
-import "time"
+import (
+	"strconv"
+	"time"
+)
 
 // CacheConfig содержит конфигурацию кэша
 type CacheConfig struct {
-	TTL              time.Duration
-	CleanupInterval  time.Duration
+	// Driver - где хранить кеш: memory (в памяти процесса) или redis
+	Driver string
+	// TTL - сколько запись считается свежей
+	TTL time.Duration
+	// StaleTTL - сколько после TTL запись еще отдается, пока в фоне загружается новое значение
+	StaleTTL time.Duration
+	// NegativeTTL - сколько кешируется отсутствие записи, например книги с несуществующим ID
+	NegativeTTL time.Duration
+	// TTLJitter - доля, на которую TTL случайно отклоняется, чтобы записи, созданные
+	// одновременно, не устаревали одновременно
+	TTLJitter float64
+	// LoadTimeout ограничивает загрузку значения из базы при промахе. Загрузка общая для
+	// всех ожидающих ее запросов и не отменяется, если отключится один из них
+	LoadTimeout time.Duration
+	// CleanupInterval - период удаления устаревших записей in-memory кеша
+	CleanupInterval time.Duration
+	// RedisAddr - адрес Redis в формате host:port
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	// RedisKeyPrefix отделяет ключи кеша от других данных в том же Redis
+	RedisKeyPrefix string
 }
 
 // NewCacheConfig создает новую конфигурацию кэша
@@ -15,13 +38,47 @@ func NewCacheConfig() (*CacheConfig, error) {
 		return nil, err
 	}
 
+	staleTTL, err := time.ParseDuration(getEnv("CACHE_STALE_TTL", "1m"))
+	if err != nil {
+		return nil, err
+	}
+
+	negativeTTL, err := time.ParseDuration(getEnv("CACHE_NEGATIVE_TTL", "30s"))
+	if err != nil {
+		return nil, err
+	}
+
+	ttlJitter, err := strconv.ParseFloat(getEnv("CACHE_TTL_JITTER", "0.1"), 64)
+	if err != nil {
+		return nil, err
+	}
+
+	loadTimeout, err := time.ParseDuration(getEnv("CACHE_LOAD_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, err
+	}
+
 	cleanupInterval, err := time.ParseDuration(getEnv("CACHE_CLEANUP_INTERVAL", "10m"))
 	if err != nil {
 		return nil, err
 	}
 
+	redisDB, err := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	if err != nil {
+		return nil, err
+	}
+
 	return &CacheConfig{
+		Driver:          getEnv("CACHE_DRIVER", "memory"),
 		TTL:             ttl,
+		StaleTTL:        staleTTL,
+		NegativeTTL:     negativeTTL,
+		TTLJitter:       ttlJitter,
+		LoadTimeout:     loadTimeout,
 		CleanupInterval: cleanupInterval,
+		RedisAddr:       getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:   getEnv("REDIS_PASSWORD", ""),
+		RedisDB:         redisDB,
+		RedisKeyPrefix:  getEnv("REDIS_KEY_PREFIX", "booktrading:cache:"),
 	}, nil
-} 
\ No newline at end of file
+}
@@ -6,22 +6,36 @@ import (
 	_ "github.com/rs/zerolog"
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config содержит все конфигурации приложения
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Cache    *CacheConfig
-	Logging  *LoggingConfig
-	CORS     *CORSConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Cache       *CacheConfig
+	Logging     *LoggingConfig
+	CORS        *CORSConfig
+	JWT         JWTConfig
+	Pagination  PaginationConfig
+	Storage     StorageConfig
+	Cycles      CycleConfig
+	Tracing     TracingConfig
+	Idempotency IdempotencyConfig
 }
 
 // ServerConfig содержит конфигурацию сервера
 type ServerConfig struct {
 	Host string
 	Port int
+	// ReadTimeout - время на чтение запроса вместе с телом
+	ReadTimeout time.Duration
+	// WriteTimeout - время на запись ответа. Поток уведомлений снимает это ограничение
+	WriteTimeout time.Duration
+	// IdleTimeout - сколько keep-alive соединение может простаивать между запросами
+	IdleTimeout time.Duration
+	// ShutdownTimeout - сколько при остановке ждать завершения выполняющихся запросов
+	ShutdownTimeout time.Duration
 }
 
 // DatabaseConfig represents the database configuration
@@ -31,6 +45,9 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+	// QueryTimeout - сколько запрос к API может ждать базу данных, после чего
+	// его запросы к базе отменяются. 0 отключает ограничение
+	QueryTimeout time.Duration
 }
 
 // JWTConfig represents the JWT configuration
@@ -38,6 +55,74 @@ type JWTConfig struct {
 	SecretKey     string
 	RefreshSecret string
 	Issuer        string
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+}
+
+// PaginationConfig содержит конфигурацию курсорной пагинации
+type PaginationConfig struct {
+	// CursorSecret - ключ HMAC подписи курсоров
+	CursorSecret string
+}
+
+// CycleConfig содержит конфигурацию поиска кольцевых обменов
+type CycleConfig struct {
+	// MaxLength - максимальное число участников кольца
+	MaxLength int
+	// ScanInterval - период фонового поиска колец, 0 отключает фоновый поиск
+	ScanInterval time.Duration
+}
+
+// IdempotencyConfig содержит конфигурацию ключей идемпотентности
+type IdempotencyConfig struct {
+	// TTL - сколько хранится ответ на запрос с ключом идемпотентности
+	TTL time.Duration
+}
+
+// TracingConfig содержит конфигурацию трассировки OpenTelemetry
+type TracingConfig struct {
+	// Exporter - куда отправлять спаны: none (трассировка отключена) или otlp
+	Exporter string
+	// Endpoint - адрес OTLP/HTTP коллектора в формате host:port
+	Endpoint string
+	// Insecure - подключаться к коллектору без TLS
+	Insecure bool
+	// ServiceName - имя сервиса в трассах
+	ServiceName string
+	// SampleRatio - доля трассируемых запросов от 0 до 1
+	SampleRatio float64
+}
+
+// StorageConfig содержит конфигурацию хранилища изображений
+type StorageConfig struct {
+	// Driver - тип хранилища: local или s3
+	Driver string
+	// LocalPath - каталог для локального хранилища
+	LocalPath string
+	// MediaURL - префикс URL, по которому раздаются изображения
+	MediaURL    string
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
+	S3Bucket    string
+	S3Region    string
+	S3UseSSL    bool
+}
+
+// NewStorageConfig создает конфигурацию хранилища из переменных окружения
+func NewStorageConfig() StorageConfig {
+	useSSL, _ := strconv.ParseBool(getEnv("S3_USE_SSL", "false"))
+	return StorageConfig{
+		Driver:      getEnv("STORAGE_DRIVER", "local"),
+		LocalPath:   getEnv("STORAGE_LOCAL_PATH", "./data/media"),
+		MediaURL:    getEnv("MEDIA_BASE_URL", "/media"),
+		S3Endpoint:  getEnv("S3_ENDPOINT", "localhost:9000"),
+		S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		S3Bucket:    getEnv("S3_BUCKET", "booktrading-media"),
+		S3Region:    getEnv("S3_REGION", ""),
+		S3UseSSL:    useSSL,
+	}
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -55,6 +140,30 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	readTimeout, err := time.ParseDuration(getEnv("SERVER_READ_TIMEOUT", "15s"))
+	if err != nil {
+		logger.Error("Failed to parse SERVER_READ_TIMEOUT", err)
+		return nil, err
+	}
+
+	writeTimeout, err := time.ParseDuration(getEnv("SERVER_WRITE_TIMEOUT", "30s"))
+	if err != nil {
+		logger.Error("Failed to parse SERVER_WRITE_TIMEOUT", err)
+		return nil, err
+	}
+
+	idleTimeout, err := time.ParseDuration(getEnv("SERVER_IDLE_TIMEOUT", "60s"))
+	if err != nil {
+		logger.Error("Failed to parse SERVER_IDLE_TIMEOUT", err)
+		return nil, err
+	}
+
+	shutdownTimeout, err := time.ParseDuration(getEnv("SERVER_SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		logger.Error("Failed to parse SERVER_SHUTDOWN_TIMEOUT", err)
+		return nil, err
+	}
+
 	// Загрузка конфигурации базы данных
 	dbPort, err := strconv.Atoi(getEnv("DB_PORT", "3306"))
 	if err != nil {
@@ -62,15 +171,70 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	queryTimeout, err := time.ParseDuration(getEnv("DB_QUERY_TIMEOUT", "10s"))
+	if err != nil {
+		logger.Error("Failed to parse DB_QUERY_TIMEOUT", err)
+		return nil, err
+	}
+
 	cacheConfig, err := NewCacheConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	// Загрузка времени жизни токенов
+	accessTTL, err := time.ParseDuration(getEnv("JWT_ACCESS_TTL", "15m"))
+	if err != nil {
+		logger.Error("Failed to parse JWT_ACCESS_TTL", err)
+		return nil, err
+	}
+
+	refreshTTL, err := time.ParseDuration(getEnv("JWT_REFRESH_TTL", "168h"))
+	if err != nil {
+		logger.Error("Failed to parse JWT_REFRESH_TTL", err)
+		return nil, err
+	}
+
+	// Загрузка параметров поиска кольцевых обменов
+	cycleMaxLength, err := strconv.Atoi(getEnv("CYCLE_MAX_LENGTH", "4"))
+	if err != nil {
+		logger.Error("Failed to parse CYCLE_MAX_LENGTH", err)
+		return nil, err
+	}
+
+	cycleScanInterval, err := time.ParseDuration(getEnv("CYCLE_SCAN_INTERVAL", "15m"))
+	if err != nil {
+		logger.Error("Failed to parse CYCLE_SCAN_INTERVAL", err)
+		return nil, err
+	}
+
+	idempotencyTTL, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil {
+		logger.Error("Failed to parse IDEMPOTENCY_TTL", err)
+		return nil, err
+	}
+
+	// Загрузка конфигурации трассировки
+	tracingInsecure, err := strconv.ParseBool(getEnv("TRACING_INSECURE", "true"))
+	if err != nil {
+		logger.Error("Failed to parse TRACING_INSECURE", err)
+		return nil, err
+	}
+
+	tracingSampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		logger.Error("Failed to parse TRACING_SAMPLE_RATIO", err)
+		return nil, err
+	}
+
 	return &Config{
 		Server: ServerConfig{
-			Host: getEnv("SERVER_HOST", "localhost"),
-			Port: serverPort,
+			Host:            getEnv("SERVER_HOST", "localhost"),
+			Port:            serverPort,
+			ReadTimeout:     readTimeout,
+			WriteTimeout:    writeTimeout,
+			IdleTimeout:     idleTimeout,
+			ShutdownTimeout: shutdownTimeout,
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -78,6 +242,8 @@ func Load() (*Config, error) {
 			User:     getEnv("DB_USER", "root"),
 			Password: getEnv("DB_PASSWORD", ""),
 			DBName:   getEnv("DB_NAME", "booktrading"),
+
+			QueryTimeout: queryTimeout,
 		},
 		Cache:   cacheConfig,
 		Logging: NewLoggingConfig(),
@@ -86,6 +252,26 @@ func Load() (*Config, error) {
 			SecretKey:     getEnv("JWT_SECRET_KEY", "your-secret-key-here-book-trading"),
 			RefreshSecret: getEnv("JWT_REFRESH_SECRET", "your-refresh-secret-here-book-trading"),
 			Issuer:        getEnv("JWT_ISSUER", "booktrading"),
+			AccessTTL:     accessTTL,
+			RefreshTTL:    refreshTTL,
+		},
+		Pagination: PaginationConfig{
+			CursorSecret: getEnv("CURSOR_SECRET", "your-cursor-secret-here-book-trading"),
+		},
+		Storage: NewStorageConfig(),
+		Cycles: CycleConfig{
+			MaxLength:    cycleMaxLength,
+			ScanInterval: cycleScanInterval,
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			Endpoint:    getEnv("TRACING_ENDPOINT", "localhost:4318"),
+			Insecure:    tracingInsecure,
+			ServiceName: getEnv("TRACING_SERVICE_NAME", "booktrading"),
+			SampleRatio: tracingSampleRatio,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: idempotencyTTL,
 		},
 	}, nil
 }
@@ -6,17 +6,23 @@ import (
 
 // LoggingConfig содержит конфигурацию логирования
 type LoggingConfig struct {
-	Level  zerolog.Level
+	// Level - минимальный уровень записей: debug, info, warn, error
+	Level zerolog.Level
+	// Format - формат вывода: json или console
 	Format string
 }
 
-// NewLoggingConfig создает новую конфигурацию логирования
+// NewLoggingConfig создает новую конфигурацию логирования.
+// Неизвестный уровень заменяется на info
 func NewLoggingConfig() *LoggingConfig {
 	levelStr := getEnv("LOG_LEVEL", "debug")
-	level, _ := zerolog.ParseLevel(levelStr)
-	
+	level, err := zerolog.ParseLevel(levelStr)
+	if err != nil || level == zerolog.NoLevel {
+		level = zerolog.InfoLevel
+	}
+
 	return &LoggingConfig{
 		Level:  level,
 		Format: getEnv("LOG_FORMAT", "json"),
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/state"
+	"booktrading/internal/domain/tag"
+	"booktrading/internal/domain/user"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// etag строит сильный ETag из частей представления ресурса
+func etag(parts ...interface{}) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v|", part)
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// bookETag вычисляет ETag книги. Книга отдается вместе с владельцем, состоянием и тегами,
+// поэтому в ETag входят и их версии. Фотографии меняют версию самой книги
+func bookETag(b *book.Book) string {
+	parts := []interface{}{"book", b.ID, b.Version, b.UpdatedAt.UnixMilli()}
+	if b.User != nil {
+		parts = append(parts, "user", b.User.ID, b.User.Version)
+	}
+	if b.State != nil {
+		parts = append(parts, "state", b.State.ID, b.State.Version)
+	}
+	for _, t := range b.Tags {
+		parts = append(parts, "tag", t.ID, t.Version)
+	}
+	return etag(parts...)
+}
+
+// userETag вычисляет ETag пользователя. Список книг и репутация не меняют версию
+// пользователя, поэтому входят в ETag явно
+func userETag(u *user.User) string {
+	parts := []interface{}{"user", u.ID, u.Version, u.UpdatedAt.UnixMilli(), u.BookIDs}
+	if u.Reputation != nil {
+		parts = append(parts, u.Reputation.Rating, u.Reputation.ReviewCount, u.Reputation.CompletedTrades)
+	}
+	return etag(parts...)
+}
+
+// tagETag вычисляет ETag тега
+func tagETag(t *tag.Tag) string {
+	return etag("tag", t.ID, t.Version, t.UpdatedAt.UnixMilli())
+}
+
+// stateETag вычисляет ETag состояния. Переходы меняют версию состояния,
+// а целевые состояния переходов отдаются вместе с ним
+func stateETag(s *state.State) string {
+	parts := []interface{}{"state", s.ID, s.Version, s.UpdatedAt.UnixMilli()}
+	for _, t := range s.Transitions {
+		parts = append(parts, "transition", t.ToStateID)
+		if t.ToState != nil {
+			parts = append(parts, t.ToState.Version)
+		}
+	}
+	return etag(parts...)
+}
+
+// latest возвращает самое позднее из времен изменения
+func latest(times ...time.Time) time.Time {
+	var result time.Time
+	for _, t := range times {
+		if t.After(result) {
+			result = t
+		}
+	}
+	return result
+}
+
+// bookLastModified возвращает время последнего изменения книги с учетом владельца,
+// состояния и тегов, которые отдаются вместе с ней
+func bookLastModified(b *book.Book) time.Time {
+	times := []time.Time{b.UpdatedAt}
+	if b.User != nil {
+		times = append(times, b.User.UpdatedAt)
+	}
+	if b.State != nil {
+		times = append(times, b.State.UpdatedAt)
+	}
+	for _, t := range b.Tags {
+		times = append(times, t.UpdatedAt)
+	}
+	return latest(times...)
+}
+
+// stateLastModified возвращает время последнего изменения состояния с учетом
+// целевых состояний его переходов
+func stateLastModified(s *state.State) time.Time {
+	times := []time.Time{s.UpdatedAt}
+	for _, t := range s.Transitions {
+		if t.ToState != nil {
+			times = append(times, t.ToState.UpdatedAt)
+		}
+	}
+	return latest(times...)
+}
+
+// etagMatches проверяет, совпадает ли ETag с одним из значений заголовка If-Match
+// или If-None-Match. "*" совпадает с любым существующим представлением.
+// При сильном сравнении (If-Match) слабые ETag не совпадают ни с чем
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified устанавливает заголовки ETag и Last-Modified (если lastModified не нулевое)
+// и проверяет условия If-None-Match и If-Modified-Since. If-Modified-Since учитывается,
+// только если нет If-None-Match. Возвращает true, если уже отправлен ответ 304
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if !etagMatches(ifNoneMatch, etag, true) {
+			return false
+		}
+	} else {
+		ifModifiedSince := r.Header.Get("If-Modified-Since")
+		if ifModifiedSince == "" || lastModified.IsZero() {
+			return false
+		}
+		since, err := http.ParseTime(ifModifiedSince)
+		// Заголовок Last-Modified передается с точностью до секунды
+		if err != nil || lastModified.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch проверяет условие If-Match изменяющего запроса по ETag текущего
+// представления ресурса. Без заголовка запрос отклоняется с 428, при несовпадении -
+// с 412 и актуальным ETag. Возвращает false, если ответ с ошибкой уже отправлен
+func checkIfMatch(w http.ResponseWriter, r *http.Request, etag string) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		http.Error(w, "If-Match header is required", http.StatusPreconditionRequired)
+		return false
+	}
+	if !etagMatches(ifMatch, etag, false) {
+		w.Header().Set("ETag", etag)
+		http.Error(w, "Resource has been modified", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// versionConflict отвечает 412, если ресурс изменили между проверкой If-Match
+// и сохранением. Возвращает true, если ответ уже отправлен
+func versionConflict(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		return false
+	}
+	http.Error(w, "Resource has been modified", http.StatusPreconditionFailed)
+	return true
+}
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"booktrading/internal/domain/conversation"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/usecase"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Start conversation
+// @Description Start a conversation with the owner of a book (book_id) or with the other party of a trade (trade_id) and send the first message. If the conversation already exists, the message is added to it.
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Param conversation body conversation.CreateConversationDTO true "Conversation subject and first message"
+// @Success 201 {object} conversation.Conversation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/conversations [post]
+func (h *Handler) startConversation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	var dto conversation.CreateConversationDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	c, err := h.conversationUsecase.StartConversation(r.Context(), userID, &dto)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to start conversation", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, c)
+}
+
+// @Summary Get my conversations
+// @Description Get paginated list of conversations of the current user, most recently active first. Each conversation contains the unread_count of the current user, unread_total is the number of unread messages in all conversations.
+// @Tags Messages
+// @Produce json
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns conversations, unread_total and pagination info"
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/conversations [get]
+func (h *Handler) getConversations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	page, pageSize := pageParams(r)
+
+	conversations, total, err := h.conversationUsecase.GetUserConversations(r.Context(), userID, page, pageSize)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get conversations", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	unread, err := h.conversationUsecase.GetUnreadTotal(r.Context(), userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get unread messages count", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"conversations": conversations,
+		"unread_total":  unread,
+		"pagination":    paginationInfo(total, page, pageSize),
+	})
+}
+
+// @Summary Get conversation by ID
+// @Description Get conversation with its participants. Only participants can see it.
+// @Tags Messages
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} conversation.Conversation
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/conversations/{id} [get]
+func (h *Handler) getConversation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+	id, ok := h.conversationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	c, err := h.conversationUsecase.GetConversation(r.Context(), userID, id)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get conversation", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, c)
+}
+
+// @Summary Get conversation messages
+// @Description Get messages of the conversation with keyset pagination ordered by sending time. Without a cursor the latest messages are returned: prev_cursor leads to older messages and next_cursor can be used to poll for new ones. Only participants can read the conversation.
+// @Tags Messages
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (empty for the latest messages)"
+// @Param limit query int false "Messages per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns messages, next_cursor, prev_cursor and limit"
+// @Header 200 {string} Link "Links to the next and previous pages"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/conversations/{id}/messages [get]
+func (h *Handler) getConversationMessages(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+	id, ok := h.conversationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	// Курсор действителен только для сообщений этой переписки
+	scope := fmt.Sprintf("conversations/%d/messages", id)
+	c, limit, err := h.parseCursor(r, scope)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid cursor: "+err.Error())
+		return
+	}
+
+	messages, hasMore, err := h.conversationUsecase.GetMessages(r.Context(), userID, id, c, limit)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get conversation messages", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	h.respondCursorPage(w, r, "messages", messages, messagePage(c, scope, messages, hasMore), limit)
+}
+
+// @Summary Send message
+// @Description Send a message to the conversation. Unread counters of the other participants are increased.
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param message body conversation.SendMessageDTO true "Message"
+// @Success 201 {object} conversation.Message
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/conversations/{id}/messages [post]
+func (h *Handler) sendMessage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+	id, ok := h.conversationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var dto conversation.SendMessageDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	message, err := h.conversationUsecase.SendMessage(r.Context(), userID, id, &dto)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to send message", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, message)
+}
+
+// @Summary Mark conversation as read
+// @Description Reset the unread counter of the current user in the conversation
+// @Tags Messages
+// @Param id path int true "Conversation ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/conversations/{id}/read [post]
+func (h *Handler) markConversationRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+	id, ok := h.conversationIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.conversationUsecase.MarkRead(r.Context(), userID, id); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to mark conversation as read", err)
+		h.conversationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// conversationIDParam извлекает ID переписки из URL
+func (h *Handler) conversationIDParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid conversation ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// messagePage вычисляет курсоры соседних страниц для списка сообщений.
+// Первая страница без курсора содержит последние сообщения, поэтому считается
+// полученной при движении назад от конца переписки
+func messagePage(c *cursor.Cursor, scope string, messages []*conversation.Message, hasMore bool) *cursor.Page {
+	req := c
+	if req == nil {
+		req = &cursor.Cursor{Backward: true, Scope: scope}
+	}
+	if len(messages) == 0 {
+		page := cursor.NewPage(req, scope, hasMore, nil, nil)
+		// Новых сообщений пока нет - тот же курсор пригоден для следующего опроса
+		if c != nil && !c.Backward {
+			page.Next = c
+		}
+		return page
+	}
+	first, last := messages[0], messages[len(messages)-1]
+	return cursor.NewPage(req, scope, hasMore,
+		&cursor.Position{CreatedAt: first.CreatedAt, ID: first.ID},
+		&cursor.Position{CreatedAt: last.CreatedAt, ID: last.ID})
+}
+
+// conversationError преобразует ошибки переписки в HTTP ответ
+func (h *Handler) conversationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrConversationNotFound):
+		h.error(w, http.StatusNotFound, "Conversation not found")
+	case errors.Is(err, usecase.ErrConversationForbidden):
+		h.error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, usecase.ErrInvalidConversation):
+		h.error(w, http.StatusBadRequest, err.Error())
+	default:
+		h.error(w, http.StatusInternalServerError, "Failed to process conversation")
+	}
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Get my trade cycles
+// @Description Get paginated list of multi-party trade cycles where the current user is a participant
+// @Tags Trades
+// @Produce json
+// @Param status query string false "Filter by status (pending, accepted, rejected, cancelled, completed)"
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns cycles and pagination info"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles [get]
+func (h *Handler) getUserCycles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	page, pageSize := pageParams(r)
+	status := trade.Status(r.URL.Query().Get("status"))
+
+	cycles, total, err := h.cycleUsecase.GetUserCycles(r.Context(), userID, status, page, pageSize)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get user trade cycles", err)
+		h.tradeError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"cycles":     cycles,
+		"pagination": paginationInfo(total, page, pageSize),
+	})
+}
+
+// @Summary Get trade cycle by ID
+// @Description Get multi-party trade cycle by its ID. Only cycle participants can see it.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade cycle ID"
+// @Success 200 {object} trade.Cycle
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles/{id} [get]
+func (h *Handler) getCycleByID(w http.ResponseWriter, r *http.Request) {
+	h.handleCycleAction(w, r, h.cycleUsecase.GetCycleByID)
+}
+
+// @Summary Accept trade cycle
+// @Description Accept a pending trade cycle. The cycle is accepted only when every participant has accepted it, then all involved books move to the "trading" state at once. If some book is no longer available, the cycle is cancelled.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade cycle ID"
+// @Success 200 {object} trade.Cycle
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles/{id}/accept [post]
+func (h *Handler) acceptCycle(w http.ResponseWriter, r *http.Request) {
+	h.handleCycleAction(w, r, h.cycleUsecase.AcceptCycle)
+}
+
+// @Summary Reject trade cycle
+// @Description Reject a pending trade cycle. A single rejection cancels the cycle for all participants.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade cycle ID"
+// @Success 200 {object} trade.Cycle
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles/{id}/reject [post]
+func (h *Handler) rejectCycle(w http.ResponseWriter, r *http.Request) {
+	h.handleCycleAction(w, r, h.cycleUsecase.RejectCycle)
+}
+
+// @Summary Cancel trade cycle
+// @Description Cancel an accepted trade cycle. All involved books become available again.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade cycle ID"
+// @Success 200 {object} trade.Cycle
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles/{id}/cancel [post]
+func (h *Handler) cancelCycle(w http.ResponseWriter, r *http.Request) {
+	h.handleCycleAction(w, r, h.cycleUsecase.CancelCycle)
+}
+
+// @Summary Complete trade cycle
+// @Description Confirm that the current user has received the book of an accepted trade cycle. Once all participants confirm, the cycle is completed: all involved books move to the "traded" state and change owners.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade cycle ID"
+// @Success 200 {object} trade.Cycle
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles/{id}/complete [post]
+func (h *Handler) completeCycle(w http.ResponseWriter, r *http.Request) {
+	h.handleCycleAction(w, r, h.cycleUsecase.CompleteCycle)
+}
+
+// @Summary Find trade cycles
+// @Description Run the trade cycle search immediately instead of waiting for the periodic scan. Returns newly proposed cycles.
+// @Tags Trades
+// @Produce json
+// @Success 200 {array} trade.Cycle
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trade-cycles/scan [post]
+func (h *Handler) scanCycles(w http.ResponseWriter, r *http.Request) {
+	cycles, err := h.cycleUsecase.FindCycles(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to find trade cycles", err)
+		h.error(w, http.StatusInternalServerError, "Failed to find trade cycles")
+		return
+	}
+	if cycles == nil {
+		cycles = []*trade.Cycle{}
+	}
+
+	h.respond(w, http.StatusOK, cycles)
+}
+
+// handleCycleAction разбирает ID кольцевого обмена и пользователя и выполняет действие над обменом
+func (h *Handler) handleCycleAction(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, userID, id uint) (*trade.Cycle, error)) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid trade cycle ID", err)
+		h.error(w, http.StatusBadRequest, "Invalid trade cycle ID")
+		return
+	}
+
+	c, err := action(r.Context(), userID, uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Trade cycle action failed", err)
+		h.tradeError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, c)
+}
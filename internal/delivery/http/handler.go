@@ -2,17 +2,25 @@ package http
 
 import (
 	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/response"
 	"booktrading/internal/domain/state"
 	"booktrading/internal/domain/tag"
 	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/health"
+	"booktrading/internal/pkg/jwt"
 	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/storage"
 	"booktrading/internal/pkg/validator"
 	"booktrading/internal/usecase"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth/v5"
@@ -45,6 +53,21 @@ import (
 // @tag.name States
 // @tag.description Book state management operations
 
+// @tag.name Trades
+// @tag.description Book trade offers and swaps
+
+// @tag.name Messages
+// @tag.description Conversations between book owners and trade partners
+
+// @tag.name Notifications
+// @tag.description Real-time events and the notification inbox
+
+// @tag.name Reviews
+// @tag.description Ratings and reviews left after completed trades
+
+// @tag.name Health
+// @tag.description Liveness and readiness probes
+
 // ErrorResponse представляет собой структуру для ответов с ошибками
 // @Description Структура для возврата ошибок API
 type ErrorResponse struct {
@@ -53,11 +76,21 @@ type ErrorResponse struct {
 
 // Handler представляет HTTP обработчик
 type Handler struct {
-	bookUsecase  usecase.BookUseCase
-	tagUsecase   usecase.TagUseCase
-	stateUsecase usecase.StateUseCase
-	userUsecase  usecase.UserUseCase
-	validate     *validator.Validate
+	bookUsecase         usecase.BookUseCase
+	tagUsecase          usecase.TagUseCase
+	stateUsecase        usecase.StateUseCase
+	userUsecase         usecase.UserUseCase
+	tradeUsecase        usecase.TradeUseCase
+	wishlistUsecase     usecase.WishlistUseCase
+	cycleUsecase        usecase.CycleUseCase
+	conversationUsecase usecase.ConversationUseCase
+	notificationUsecase usecase.NotificationUseCase
+	reviewUsecase       usecase.ReviewUseCase
+	idempotencyUsecase  usecase.IdempotencyUseCase
+	cursorSigner        *cursor.Signer
+	media               *storage.Media
+	health              *health.Checker
+	validate            *validator.Validate
 }
 
 // error отправляет ответ с ошибкой
@@ -88,13 +121,33 @@ func NewHandler(
 	tagUsecase usecase.TagUseCase,
 	stateUsecase usecase.StateUseCase,
 	userUsecase usecase.UserUseCase,
+	tradeUsecase usecase.TradeUseCase,
+	wishlistUsecase usecase.WishlistUseCase,
+	cycleUsecase usecase.CycleUseCase,
+	conversationUsecase usecase.ConversationUseCase,
+	notificationUsecase usecase.NotificationUseCase,
+	reviewUsecase usecase.ReviewUseCase,
+	idempotencyUsecase usecase.IdempotencyUseCase,
+	cursorSigner *cursor.Signer,
+	media *storage.Media,
+	health *health.Checker,
 ) *Handler {
 	return &Handler{
-		bookUsecase:  bookUsecase,
-		tagUsecase:   tagUsecase,
-		stateUsecase: stateUsecase,
-		userUsecase:  userUsecase,
-		validate:     validator.New(),
+		bookUsecase:         bookUsecase,
+		tagUsecase:          tagUsecase,
+		stateUsecase:        stateUsecase,
+		userUsecase:         userUsecase,
+		tradeUsecase:        tradeUsecase,
+		wishlistUsecase:     wishlistUsecase,
+		cycleUsecase:        cycleUsecase,
+		conversationUsecase: conversationUsecase,
+		notificationUsecase: notificationUsecase,
+		reviewUsecase:       reviewUsecase,
+		idempotencyUsecase:  idempotencyUsecase,
+		cursorSigner:        cursorSigner,
+		media:               media,
+		health:              health,
+		validate:            validator.New(),
 	}
 }
 
@@ -104,23 +157,27 @@ func NewHandler(
 // @Accept json
 // @Produce json
 // @Param tag body tag.CreateTagDTO true "Tag data"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} tag.Tag
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} ErrorResponse "Idempotency-Key has been used for a different request"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/tags [post]
 func (h *Handler) createTag(w http.ResponseWriter, r *http.Request) {
 	var dto tag.CreateTagDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate the DTO
 	if err := h.validate.Struct(dto); err != nil {
-		logger.Error("Validation failed", err)
+		logger.FromContext(r.Context()).Error("Validation failed", err)
 		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -132,8 +189,12 @@ func (h *Handler) createTag(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save tag
-	if err := h.tagUsecase.CreateTag(newTag); err != nil {
-		logger.Error("Failed to create tag", err)
+	if err := h.tagUsecase.CreateTag(r.Context(), newTag); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create tag", err)
+		if isMediaError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to create tag: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -148,25 +209,34 @@ func (h *Handler) createTag(w http.ResponseWriter, r *http.Request) {
 // @Tags Tags
 // @Produce json
 // @Param id path int true "Tag ID"
+// @Param If-None-Match header string false "ETag from a previous response; 304 is returned if it still matches"
+// @Param If-Modified-Since header string false "Used only without If-None-Match"
 // @Success 200 {object} tag.Tag
+// @Header 200 {string} ETag "Strong entity tag of the representation"
+// @Header 200 {string} Last-Modified "Time of the last change of the representation"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/v1/tags/{id} [get]
 func (h *Handler) getTagByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid tag ID", err)
+		logger.FromContext(r.Context()).Error("Invalid tag ID", err)
 		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
 		return
 	}
 
-	tag, err := h.tagUsecase.GetTagByID(uint(id))
+	tag, err := h.tagUsecase.GetTagByID(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to get tag", err)
+		logger.FromContext(r.Context()).Error("Failed to get tag", err)
 		http.Error(w, "Tag not found", http.StatusNotFound)
 		return
 	}
 
+	if notModified(w, r, tagETag(tag), tag.UpdatedAt) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(tag)
 }
@@ -187,9 +257,9 @@ func (h *Handler) getPopularTags(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	tags, err := h.tagUsecase.GetPopularTags(limit)
+	tags, err := h.tagUsecase.GetPopularTags(r.Context(), limit)
 	if err != nil {
-		logger.Error("Failed to get popular tags", err)
+		logger.FromContext(r.Context()).Error("Failed to get popular tags", err)
 		http.Error(w, "Failed to get popular tags", http.StatusInternalServerError)
 		return
 	}
@@ -204,9 +274,12 @@ func (h *Handler) getPopularTags(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param book body book.CreateBookDTO true "Book data"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} book.Book "Created book"
 // @Failure 400 {object} ErrorResponse "Invalid request data"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 409 {object} ErrorResponse "Request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} ErrorResponse "Idempotency-Key has been used for a different request"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security Bearer
 // @Router /api/v1/books [post]
@@ -238,7 +311,7 @@ func (h *Handler) getPopularTags(w http.ResponseWriter, r *http.Request) {
 //	    {
 //	      "id": 1,
 //	      "book_id": 1,
-//	      "photo_url": "data:image/jpeg;base64,/9j/4AAQSkZJRg...",
+//	      "photo_url": "/media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b",
 //	      "is_main": true,
 //	      "created_at": "2024-03-20T12:00:00Z",
 //	      "updated_at": "2024-03-20T12:00:00Z"
@@ -253,7 +326,7 @@ func (h *Handler) createBook(w http.ResponseWriter, r *http.Request) {
 	// Get claims from context
 	_, claims, err := jwtauth.FromContext(r.Context())
 	if err != nil {
-		logger.Error("Failed to get claims from context", err)
+		logger.FromContext(r.Context()).Error("Failed to get claims from context", err)
 		http.Error(w, "Authentication failed: Token not found", http.StatusUnauthorized)
 		return
 	}
@@ -261,21 +334,21 @@ func (h *Handler) createBook(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from claims
 	userID, ok := claims["user_id"].(float64)
 	if !ok {
-		logger.Error("User ID not found in claims", nil)
+		logger.FromContext(r.Context()).Error("User ID not found in claims", nil)
 		http.Error(w, "Authentication failed: User ID not found in token claims", http.StatusUnauthorized)
 		return
 	}
 
 	var dto book.CreateBookDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate the DTO
 	if err := h.validate.Struct(dto); err != nil {
-		logger.Error("Validation failed", err)
+		logger.FromContext(r.Context()).Error("Validation failed", err)
 		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -284,29 +357,17 @@ func (h *Handler) createBook(w http.ResponseWriter, r *http.Request) {
 	newBook := dto.ToBook()
 	newBook.UserID = uint(userID)
 
-	// Save book and associate tags
-	if err := h.bookUsecase.CreateBook(newBook, dto.TagIDs); err != nil {
-		logger.Error("Failed to create book", err)
+	// Save book with its tags and photos
+	if err := h.bookUsecase.CreateBook(r.Context(), newBook, dto.TagIDs, dto.Photos); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create book", err)
+		if isPhotoInputError(err) {
+			http.Error(w, "Invalid photos: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to create book: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Create photos if they exist
-	if len(dto.Photos) > 0 {
-		for _, photoData := range dto.Photos {
-			photo := &book.BookPhoto{
-				BookID:   newBook.ID,
-				PhotoURL: photoData.PhotoURL,
-				IsMain:   photoData.IsMain,
-			}
-			if err := h.bookUsecase.CreatePhoto(photo); err != nil {
-				logger.Error("Failed to create photo", err)
-				http.Error(w, "Failed to create photo: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(newBook)
@@ -317,41 +378,54 @@ func (h *Handler) createBook(w http.ResponseWriter, r *http.Request) {
 // @Tags Books
 // @Produce json
 // @Param id path int true "Book ID"
+// @Param If-None-Match header string false "ETag from a previous response; 304 is returned if it still matches"
+// @Param If-Modified-Since header string false "Used only without If-None-Match"
 // @Success 200 {object} book.Book
+// @Header 200 {string} ETag "Strong entity tag of the representation"
+// @Header 200 {string} Last-Modified "Time of the last change of the representation"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/v1/books/{id} [get]
 func (h *Handler) getBookByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid book ID", err)
+		logger.FromContext(r.Context()).Error("Invalid book ID", err)
 		http.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
-	book, err := h.bookUsecase.GetBookByID(uint(id))
+	book, err := h.bookUsecase.GetBookByID(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to get book", err)
+		logger.FromContext(r.Context()).Error("Failed to get book", err)
 		http.Error(w, "Book not found", http.StatusNotFound)
 		return
 	}
 
+	if notModified(w, r, bookETag(book), bookLastModified(book)) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(book)
 }
 
 // @Summary Update book
-// @Description Update existing book information. Only the book owner can update it.
+// @Description Update existing book information. Only the book owner or an admin can update it.
 // @Tags Books
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
 // @Param book body book.UpdateBookDTO true "Updated book data"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 200 {object} book.Book "Updated book information"
 // @Failure 400 {object} ErrorResponse "Invalid request data or validation failed"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing token"
-// @Failure 403 {object} ErrorResponse "Forbidden - User is not the book owner"
+// @Failure 403 {object} ErrorResponse "Forbidden - User is not the book owner or an admin"
 // @Failure 404 {object} ErrorResponse "Book not found"
+// @Failure 409 {object} ErrorResponse "Transition to the requested state is not allowed"
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security Bearer
 // @Router /api/v1/books/{id} [put]
@@ -373,52 +447,32 @@ func (h *Handler) updateBook(w http.ResponseWriter, r *http.Request) {
 	// Get book ID from URL
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid book ID", err)
+		logger.FromContext(r.Context()).Error("Invalid book ID", err)
 		http.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
-	// Get claims from context
-	_, claims, err := jwtauth.FromContext(r.Context())
-	if err != nil {
-		logger.Error("Failed to get claims from context", err)
-		http.Error(w, "Authentication failed: Token not found", http.StatusUnauthorized)
-		return
-	}
-
-	// Get user ID from claims
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		logger.Error("User ID not found in claims", nil)
-		http.Error(w, "Authentication failed: User ID not found in token claims", http.StatusUnauthorized)
-		return
-	}
-
-	// Get existing book
-	existingBook, err := h.bookUsecase.GetBookByID(uint(id))
+	// Get existing book. Права на изменение проверяет RequireBookOwnerOrRole
+	existingBook, err := h.bookUsecase.GetBookForUpdate(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to get book", err)
+		logger.FromContext(r.Context()).Error("Failed to get book", err)
 		http.Error(w, "Book not found", http.StatusNotFound)
 		return
 	}
-
-	// Check if user owns the book
-	if existingBook.UserID != uint(userID) {
-		logger.Error("User does not own the book", nil)
-		http.Error(w, "You don't have permission to update this book", http.StatusForbidden)
+	if !checkIfMatch(w, r, bookETag(existingBook)) {
 		return
 	}
 
 	var dto book.UpdateBookDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate the DTO
 	if err := h.validate.Struct(dto); err != nil {
-		logger.Error("Validation failed", err)
+		logger.FromContext(r.Context()).Error("Validation failed", err)
 		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -426,35 +480,31 @@ func (h *Handler) updateBook(w http.ResponseWriter, r *http.Request) {
 	// Update book fields
 	existingBook.UpdateFromDTO(&dto)
 
-	// Update book
-	if err := h.bookUsecase.UpdateBook(existingBook, dto.TagIDs); err != nil {
-		logger.Error("Failed to update book", err)
+	// Update book. Фотографии заменяются, только если они переданы. Отдельные
+	// фотографии можно изменить через /api/v1/books/{id}/photos
+	if err := h.bookUsecase.UpdateBook(r.Context(), existingBook, dto.TagIDs, dto.Photos); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to update book", err)
+		if versionConflict(w, err) {
+			return
+		}
+		if isPhotoInputError(err) {
+			http.Error(w, "Invalid photos: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var transitionErr *state.TransitionError
+		if errors.As(err, &transitionErr) {
+			http.Error(w, transitionErr.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to update book: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Update photos if they exist
-	if len(dto.Photos) > 0 {
-		// Delete existing photos
-		if err := h.bookUsecase.DeletePhotos(existingBook.ID); err != nil {
-			logger.Error("Failed to delete existing photos", err)
-			http.Error(w, "Failed to delete existing photos: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		// Create new photos
-		for i, photoURL := range dto.Photos {
-			photo := &book.BookPhoto{
-				BookID:   existingBook.ID,
-				PhotoURL: photoURL,
-				IsMain:   i == 0, // Первая фотография - главная
-			}
-			if err := h.bookUsecase.CreatePhoto(photo); err != nil {
-				logger.Error("Failed to create photo", err)
-				http.Error(w, "Failed to create photo: "+err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
+	// Отвечаем книгой в том виде, в каком ее отдает чтение, чтобы ETag ответа
+	// подходил для следующего изменения
+	if current, err := h.bookUsecase.GetBookByID(r.Context(), existingBook.ID); err == nil {
+		existingBook = current
+		w.Header().Set("ETag", bookETag(current))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -462,13 +512,18 @@ func (h *Handler) updateBook(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Delete book
-// @Description Delete book by ID
+// @Description Delete book by ID. Books that are part of a pending or accepted trade cannot be deleted
 // @Tags Books
 // @Param id path int true "Book ID"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Book is part of an active trade"
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/books/{id} [delete]
@@ -479,7 +534,27 @@ func (h *Handler) deleteBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.bookUsecase.DeleteBook(uint(id)); err != nil {
+	existingBook, err := h.bookUsecase.GetBookForUpdate(r.Context(), uint(id))
+	if err != nil {
+		http.Error(w, "Book not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, bookETag(existingBook)) {
+		return
+	}
+
+	if err := h.bookUsecase.DeleteBook(r.Context(), uint(id), existingBook.Version); err != nil {
+		if versionConflict(w, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Book not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, book.ErrBookInTrade) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to delete book", http.StatusInternalServerError)
 		return
 	}
@@ -487,42 +562,136 @@ func (h *Handler) deleteBook(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// @Summary Search books by tags
-// @Description Search books by provided tag IDs
+// @Summary Search books
+// @Description Full-text search over title, author and description with filters, sorting and facet counts by tags and states
 // @Tags Books
 // @Produce json
-// @Param tagIds query []int true "Tag IDs"
-// @Success 200 {array} book.Book
+// @Param q query string false "Full-text query over title, author and description"
+// @Param author query string false "Author name (substring match)"
+// @Param state_id query int false "State ID"
+// @Param user_id query int false "Owner ID"
+// @Param created_from query string false "Created at or after (RFC3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Created at or before (RFC3339 or YYYY-MM-DD)"
+// @Param tag_ids query []int false "Tag IDs" collectionFormat(multi)
+// @Param tagIds query []int false "Tag IDs (legacy name of tag_ids)" collectionFormat(multi)
+// @Param tag_mode query string false "How tags are combined: or (any tag, default) or and (all tags)" Enums(or, and)
+// @Param sort query string false "Sort order: relevance (default, requires q) or recent" Enums(relevance, recent)
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns books, facets and pagination info"
 // @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/books/search [get]
-func (h *Handler) searchBooksByTags(w http.ResponseWriter, r *http.Request) {
-	tagIDsStr := r.URL.Query()["tagIds"]
-	if len(tagIDsStr) == 0 {
-		http.Error(w, "No tag IDs provided", http.StatusBadRequest)
+func (h *Handler) searchBooks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := &book.SearchParams{
+		Query:    strings.TrimSpace(query.Get("q")),
+		Author:   strings.TrimSpace(query.Get("author")),
+		TagMode:  book.TagMode(query.Get("tag_mode")),
+		Sort:     book.SearchSort(query.Get("sort")),
+		Page:     1,
+		PageSize: 10,
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			params.Page = p
+		}
+	}
+
+	if pageSizeStr := query.Get("pageSize"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			params.PageSize = ps
+		}
+	}
+
+	var err error
+	if params.StateID, err = parseOptionalID(query.Get("state_id")); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid state ID")
+		return
+	}
+	if params.UserID, err = parseOptionalID(query.Get("user_id")); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if params.CreatedFrom, err = parseSearchDate(query.Get("created_from"), false); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid created_from date")
+		return
+	}
+	if params.CreatedTo, err = parseSearchDate(query.Get("created_to"), true); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid created_to date")
 		return
 	}
 
-	tagIDs := make([]uint, len(tagIDsStr))
-	for i, idStr := range tagIDsStr {
-		id, err := strconv.ParseUint(idStr, 10, 32)
-		if err != nil {
-			logger.Error("Invalid tag ID", err)
-			http.Error(w, "Invalid tag ID", http.StatusBadRequest)
-			return
+	// Теги принимаются как повторяющимся параметром, так и списком через запятую
+	for _, value := range append(query["tag_ids"], query["tagIds"]...) {
+		for _, idStr := range strings.Split(value, ",") {
+			if idStr == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(idStr, 10, 32)
+			if err != nil {
+				logger.FromContext(r.Context()).Error("Invalid tag ID", err)
+				h.error(w, http.StatusBadRequest, "Invalid tag ID")
+				return
+			}
+			params.TagIDs = append(params.TagIDs, uint(id))
 		}
-		tagIDs[i] = uint(id)
 	}
 
-	books, err := h.bookUsecase.GetBooksByTags(tagIDs)
+	result, err := h.bookUsecase.SearchBooks(r.Context(), params)
 	if err != nil {
-		logger.Error("Failed to search books", err)
-		http.Error(w, "Failed to search books", http.StatusInternalServerError)
+		if errors.Is(err, usecase.ErrInvalidSearchParams) {
+			h.error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.FromContext(r.Context()).Error("Failed to search books", err)
+		h.error(w, http.StatusInternalServerError, "Failed to search books")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(books)
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"books":  result.Books,
+		"facets": result.Facets,
+		"pagination": map[string]interface{}{
+			"total":      result.Total,
+			"page":       params.Page,
+			"pageSize":   params.PageSize,
+			"totalPages": (result.Total + int64(params.PageSize) - 1) / int64(params.PageSize),
+		},
+	})
+}
+
+// parseOptionalID разбирает необязательный числовой идентификатор из параметра запроса
+func parseOptionalID(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id), nil
+}
+
+// parseSearchDate разбирает дату в формате RFC3339 или YYYY-MM-DD.
+// Для верхней границы дата без времени означает конец дня
+func parseSearchDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return &t, nil
 }
 
 // @Summary Add tags to book
@@ -531,25 +700,29 @@ func (h *Handler) searchBooksByTags(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
+// @Param If-Match header string true "ETag of the current representation"
 // @Param tagIds body []int true "Tag IDs to add"
 // @Success 200 {object} book.Book
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
-// @Failure 404 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Book or tag not found"
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/books/{id}/tags [post]
 func (h *Handler) addTagsToBook(w http.ResponseWriter, r *http.Request) {
 	bookID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid book ID", err)
+		logger.FromContext(r.Context()).Error("Invalid book ID", err)
 		http.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
 	var tagIDsInt []int64
 	if err := json.NewDecoder(r.Body).Decode(&tagIDsInt); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -559,19 +732,37 @@ func (h *Handler) addTagsToBook(w http.ResponseWriter, r *http.Request) {
 		tagIDs[i] = uint(id)
 	}
 
-	if err := h.bookUsecase.AddTagsToBook(uint(bookID), tagIDs); err != nil {
-		logger.Error("Failed to add tags to book", err)
+	existingBook, err := h.bookUsecase.GetBookForUpdate(r.Context(), uint(bookID))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get book", err)
+		http.Error(w, "Book not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, bookETag(existingBook)) {
+		return
+	}
+
+	if err := h.bookUsecase.AddTagsToBook(r.Context(), uint(bookID), tagIDs, existingBook.Version); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to add tags to book", err)
+		if versionConflict(w, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Book or tag not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Failed to add tags to book", http.StatusInternalServerError)
 		return
 	}
 
-	book, err := h.bookUsecase.GetBookByID(uint(bookID))
+	book, err := h.bookUsecase.GetBookByID(r.Context(), uint(bookID))
 	if err != nil {
-		logger.Error("Failed to get updated book", err)
+		logger.FromContext(r.Context()).Error("Failed to get updated book", err)
 		http.Error(w, "Failed to get updated book", http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("ETag", bookETag(book))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(book)
 }
@@ -582,22 +773,26 @@ func (h *Handler) addTagsToBook(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param state body state.CreateStateDTO true "State data"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} state.State
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} ErrorResponse "Idempotency-Key has been used for a different request"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/states [post]
 func (h *Handler) createState(w http.ResponseWriter, r *http.Request) {
 	var s state.State
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.stateUsecase.Create(&s); err != nil {
-		logger.Error("Failed to create state", err)
+	if err := h.stateUsecase.Create(r.Context(), &s); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create state", err)
 		http.Error(w, "Failed to create state", http.StatusInternalServerError)
 		return
 	}
@@ -614,9 +809,9 @@ func (h *Handler) createState(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/states [get]
 func (h *Handler) getAllStates(w http.ResponseWriter, r *http.Request) {
-	states, err := h.stateUsecase.GetAll()
+	states, err := h.stateUsecase.GetAll(r.Context())
 	if err != nil {
-		logger.Error("Failed to get states", err)
+		logger.FromContext(r.Context()).Error("Failed to get states", err)
 		http.Error(w, "Failed to get states", http.StatusInternalServerError)
 		return
 	}
@@ -630,25 +825,34 @@ func (h *Handler) getAllStates(w http.ResponseWriter, r *http.Request) {
 // @Tags States
 // @Produce json
 // @Param id path int true "State ID"
+// @Param If-None-Match header string false "ETag from a previous response; 304 is returned if it still matches"
+// @Param If-Modified-Since header string false "Used only without If-None-Match"
 // @Success 200 {object} state.State
+// @Header 200 {string} ETag "Strong entity tag of the representation"
+// @Header 200 {string} Last-Modified "Time of the last change of the representation"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
 // @Router /api/v1/states/{id} [get]
 func (h *Handler) getStateByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid state ID", err)
+		logger.FromContext(r.Context()).Error("Invalid state ID", err)
 		http.Error(w, "Invalid state ID", http.StatusBadRequest)
 		return
 	}
 
-	state, err := h.stateUsecase.GetByID(uint(id))
+	state, err := h.stateUsecase.GetByID(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to get state", err)
+		logger.FromContext(r.Context()).Error("Failed to get state", err)
 		http.Error(w, "State not found", http.StatusNotFound)
 		return
 	}
 
+	if notModified(w, r, stateETag(state), stateLastModified(state)) {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(state)
 }
@@ -660,60 +864,96 @@ func (h *Handler) getStateByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "State ID"
 // @Param state body state.UpdateStateDTO true "Updated state data"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 200 {object} state.State
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/states/{id} [put]
 func (h *Handler) updateState(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid state ID", err)
+		logger.FromContext(r.Context()).Error("Invalid state ID", err)
 		http.Error(w, "Invalid state ID", http.StatusBadRequest)
 		return
 	}
 
 	var s state.State
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	s.ID = uint(id)
-	if err := h.stateUsecase.Update(&s); err != nil {
-		logger.Error("Failed to update state", err)
+	existingState, err := h.stateUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get state", err)
+		http.Error(w, "State not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, stateETag(existingState)) {
+		return
+	}
+
+	// Изменяется только название, переходы и версия остаются от текущего состояния
+	existingState.Name = s.Name
+	if err := h.stateUsecase.Update(r.Context(), existingState); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to update state", err)
+		if versionConflict(w, err) {
+			return
+		}
 		http.Error(w, "Failed to update state", http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("ETag", stateETag(existingState))
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(s)
+	json.NewEncoder(w).Encode(existingState)
 }
 
 // @Summary Delete state
 // @Description Delete book state by ID
 // @Tags States
 // @Param id path int true "State ID"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/states/{id} [delete]
 func (h *Handler) deleteState(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid state ID", err)
+		logger.FromContext(r.Context()).Error("Invalid state ID", err)
 		http.Error(w, "Invalid state ID", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.stateUsecase.Delete(uint(id)); err != nil {
-		logger.Error("Failed to delete state", err)
+	existingState, err := h.stateUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get state", err)
+		http.Error(w, "State not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, stateETag(existingState)) {
+		return
+	}
+
+	if err := h.stateUsecase.Delete(r.Context(), uint(id), existingState.Version); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to delete state", err)
+		if versionConflict(w, err) {
+			return
+		}
 		http.Error(w, "Failed to delete state", http.StatusInternalServerError)
 		return
 	}
@@ -721,16 +961,188 @@ func (h *Handler) deleteState(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// @Summary Get state transitions
+// @Description Get list of states a book can move to from the given state
+// @Tags States
+// @Produce json
+// @Param id path int true "State ID"
+// @Success 200 {array} state.Transition
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/states/{id}/transitions [get]
+func (h *Handler) getStateTransitions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid state ID", err)
+		http.Error(w, "Invalid state ID", http.StatusBadRequest)
+		return
+	}
+
+	transitions, err := h.stateUsecase.GetTransitions(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get state transitions", err)
+		http.Error(w, "State not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transitions)
+}
+
+// @Summary Add state transition
+// @Description Allow books to move from the given state to another state
+// @Tags States
+// @Accept json
+// @Produce json
+// @Param id path int true "Source state ID"
+// @Param If-Match header string true "ETag of the current source state representation"
+// @Param transition body state.CreateTransitionDTO true "Target state"
+// @Success 201 {object} state.Transition
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Source state not found"
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/states/{id}/transitions [post]
+func (h *Handler) addStateTransition(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid state ID", err)
+		http.Error(w, "Invalid state ID", http.StatusBadRequest)
+		return
+	}
+
+	var dto state.CreateTransitionDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existingState, err := h.stateUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get state", err)
+		http.Error(w, "State not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, stateETag(existingState)) {
+		return
+	}
+
+	transition, err := h.stateUsecase.AddTransition(r.Context(), uint(id), &dto, existingState.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to add state transition", err)
+		if versionConflict(w, err) {
+			return
+		}
+		http.Error(w, "Failed to add state transition: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transition)
+}
+
+// @Summary Delete state transition
+// @Description Forbid books to move from one state to another
+// @Tags States
+// @Param id path int true "Source state ID"
+// @Param toId path int true "Target state ID"
+// @Param If-Match header string true "ETag of the current source state representation"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/states/{id}/transitions/{toId} [delete]
+func (h *Handler) deleteStateTransition(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid state ID", err)
+		http.Error(w, "Invalid state ID", http.StatusBadRequest)
+		return
+	}
+
+	toID, err := strconv.ParseUint(chi.URLParam(r, "toId"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid target state ID", err)
+		http.Error(w, "Invalid target state ID", http.StatusBadRequest)
+		return
+	}
+
+	existingState, err := h.stateUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get state", err)
+		http.Error(w, "State not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, stateETag(existingState)) {
+		return
+	}
+
+	if err := h.stateUsecase.DeleteTransition(r.Context(), uint(id), uint(toID), existingState.Version); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to delete state transition", err)
+		if versionConflict(w, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			http.Error(w, "Transition not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to delete state transition", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // @Summary Get all books
-// @Description Get paginated list of all books
+// @Description Get paginated list of all books. Passing cursor or limit switches to keyset pagination ordered by creation time: the response then contains next_cursor and prev_cursor, and the Link header points to the neighbouring pages.
 // @Tags Books
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Param page_size query int false "Alias of pageSize"
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (empty for the first page)"
+// @Param limit query int false "Items per page in cursor mode (default: 10, max: 100)"
 // @Success 200 {object} map[string]interface{} "Returns books and pagination info"
+// @Header 200 {string} Link "Links to the next and previous pages in cursor mode"
+// @Failure 400 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/books [get]
 func (h *Handler) getAllBooks(w http.ResponseWriter, r *http.Request) {
+	if cursorMode(r) {
+		c, limit, err := h.parseCursor(r, "books")
+		if err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid cursor: "+err.Error())
+			return
+		}
+
+		books, hasMore, err := h.bookUsecase.GetAllBooksByCursor(r.Context(), c, limit)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to get books", err)
+			h.error(w, http.StatusInternalServerError, "Failed to get books")
+			return
+		}
+
+		h.respondCursorPage(w, r, "books", books, bookPage(c, "books", books, hasMore), limit)
+		return
+	}
+
 	// Получаем параметры пагинации
 	page := 1
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
@@ -740,16 +1152,16 @@ func (h *Handler) getAllBooks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pageSize := 10
-	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
+	if pageSizeStr := queryParam(r, "pageSize", "page_size"); pageSizeStr != "" {
 		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
 			pageSize = ps
 		}
 	}
 
 	// Получаем книги с пагинацией
-	books, total, err := h.bookUsecase.GetAllBooks(page, pageSize)
+	books, total, err := h.bookUsecase.GetAllBooks(r.Context(), page, pageSize)
 	if err != nil {
-		logger.Error("Failed to get books", err)
+		logger.FromContext(r.Context()).Error("Failed to get books", err)
 		http.Error(w, "Failed to get books", http.StatusInternalServerError)
 		return
 	}
@@ -775,8 +1187,12 @@ func (h *Handler) getAllBooks(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Param page query int false "Page number (default: 1)"
-// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Param size query int false "Items per page (default: 10, max: 100)"
+// @Param page_size query int false "Alias of size"
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (empty for the first page)"
+// @Param limit query int false "Items per page in cursor mode (default: 10, max: 100)"
 // @Success 200 {object} map[string]interface{} "Returns books and pagination info"
+// @Header 200 {string} Link "Links to the next and previous pages in cursor mode"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
@@ -786,11 +1202,31 @@ func (h *Handler) getAllBooks(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) getUserBooks(w http.ResponseWriter, r *http.Request) {
 	userID, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid user ID", err)
+		logger.FromContext(r.Context()).Error("Invalid user ID", err)
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
+	if cursorMode(r) {
+		// Курсор действителен только для книг этого пользователя
+		scope := fmt.Sprintf("users/%d/books", userID)
+		c, limit, err := h.parseCursor(r, scope)
+		if err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid cursor: "+err.Error())
+			return
+		}
+
+		books, hasMore, err := h.bookUsecase.GetUserBooksByCursor(r.Context(), uint(userID), c, limit)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to get user books", err)
+			h.error(w, http.StatusInternalServerError, "Failed to get user books")
+			return
+		}
+
+		h.respondCursorPage(w, r, "books", books, bookPage(c, scope, books, hasMore), limit)
+		return
+	}
+
 	page := 1
 	pageSize := 10
 
@@ -800,15 +1236,15 @@ func (h *Handler) getUserBooks(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+	if sizeStr := queryParam(r, "size", "page_size"); sizeStr != "" {
 		if s, err := strconv.Atoi(sizeStr); err == nil && s > 0 && s <= 100 {
 			pageSize = s
 		}
 	}
 
-	books, total, err := h.bookUsecase.GetUserBooks(uint(userID), page, pageSize)
+	books, total, err := h.bookUsecase.GetUserBooks(r.Context(), uint(userID), page, pageSize)
 	if err != nil {
-		logger.Error("Failed to get user books", err)
+		logger.FromContext(r.Context()).Error("Failed to get user books", err)
 		http.Error(w, "Failed to get user books", http.StatusInternalServerError)
 		return
 	}
@@ -827,46 +1263,69 @@ func (h *Handler) getUserBooks(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Tag ID"
 // @Param tag body tag.UpdateTagDTO true "Updated tag data"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 200 {object} tag.Tag
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/tags/{id} [put]
 func (h *Handler) updateTag(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid tag ID", err)
+		logger.FromContext(r.Context()).Error("Invalid tag ID", err)
 		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
 		return
 	}
 
 	var dto tag.UpdateTagDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if err := h.validate.Struct(dto); err != nil {
-		logger.Error("Validation failed", err)
+		logger.FromContext(r.Context()).Error("Validation failed", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	updatedTag, err := h.tagUsecase.UpdateTag(uint(id), &dto)
+	existingTag, err := h.tagUsecase.GetTagByID(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to update tag", err)
+		logger.FromContext(r.Context()).Error("Failed to get tag", err)
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, tagETag(existingTag)) {
+		return
+	}
+
+	updatedTag, err := h.tagUsecase.UpdateTag(r.Context(), uint(id), &dto, existingTag.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to update tag", err)
+		if versionConflict(w, err) {
+			return
+		}
+		if isMediaError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to update tag", http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("ETag", tagETag(updatedTag))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedTag)
 }
 
 // @Summary Refresh access token
+// @Description Exchange a refresh token for a new token pair. Each refresh token can be used only once; presenting an already used token revokes the whole session.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -882,19 +1341,21 @@ func (h *Handler) refreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем пользователя по refresh token
-	user, err := h.userUsecase.ValidateRefreshToken(refreshToken)
+	// Обмениваем refresh token на новую пару токенов
+	tokenPair, err := h.userUsecase.Refresh(r.Context(), refreshToken)
 	if err != nil {
-		logger.Error("Failed to validate refresh token", err)
-		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
-		return
-	}
-
-	// Генерируем новую пару токенов
-	tokenPair, err := h.userUsecase.GenerateTokenPair(user)
-	if err != nil {
-		logger.Error("Failed to generate token pair", err)
-		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, jwt.ErrRefreshReused):
+			logger.FromContext(r.Context()).Error("Refresh token reuse detected", err)
+			http.Error(w, "Refresh token has already been used, please log in again", http.StatusUnauthorized)
+		case errors.Is(err, jwt.ErrExpiredRefresh):
+			http.Error(w, "Refresh token has expired", http.StatusUnauthorized)
+		case errors.Is(err, jwt.ErrInvalidRefresh):
+			http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		default:
+			logger.FromContext(r.Context()).Error("Failed to refresh token pair", err)
+			http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -903,17 +1364,40 @@ func (h *Handler) refreshToken(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get all users
-// @Description Get paginated list of all users
+// @Description Get paginated list of all users. Passing cursor or limit switches to keyset pagination ordered by creation time: the response then contains next_cursor and prev_cursor, and the Link header points to the neighbouring pages.
 // @Tags Users
 // @Produce json
 // @Param page query int false "Page number (default: 1)"
 // @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Param page_size query int false "Alias of pageSize"
+// @Param cursor query string false "Opaque cursor from next_cursor or prev_cursor (empty for the first page)"
+// @Param limit query int false "Items per page in cursor mode (default: 10, max: 100)"
 // @Success 200 {object} map[string]interface{} "Returns users and pagination info"
+// @Header 200 {string} Link "Links to the next and previous pages in cursor mode"
+// @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/users [get]
 func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
+	if cursorMode(r) {
+		c, limit, err := h.parseCursor(r, "users")
+		if err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid cursor: "+err.Error())
+			return
+		}
+
+		users, hasMore, err := h.userUsecase.GetAllByCursor(r.Context(), c, limit)
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to get all users", err)
+			h.error(w, http.StatusInternalServerError, "Failed to get users")
+			return
+		}
+
+		h.respondCursorPage(w, r, "users", users, userPage(c, users, hasMore), limit)
+		return
+	}
+
 	// Получаем параметры пагинации из запроса
 	page := 1
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
@@ -923,16 +1407,16 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pageSize := 10
-	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
+	if pageSizeStr := queryParam(r, "pageSize", "page_size"); pageSizeStr != "" {
 		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
 			pageSize = ps
 		}
 	}
 
 	// Call the usecase to get all users
-	users, total, err := h.userUsecase.GetAll(page, pageSize)
+	users, total, err := h.userUsecase.GetAll(r.Context(), page, pageSize)
 	if err != nil {
-		logger.Error("Failed to get all users", err)
+		logger.FromContext(r.Context()).Error("Failed to get all users", err)
 		http.Error(w, "Failed to get users", http.StatusInternalServerError)
 		return
 	}
@@ -953,14 +1437,18 @@ func (h *Handler) getAllUsers(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get user by ID
-// @Description Get user information by ID
+// @Description Get user information by ID together with the user's reputation: average rating and number of completed trades
 // @Tags Users
 // @Produce json
 // @Param id path int true "User ID"
+// @Param If-None-Match header string false "ETag from a previous response; 304 is returned if it still matches"
 // @Success 200 {object} user.User
+// @Header 200 {string} ETag "Strong entity tag of the representation"
+// @Success 304 "Not Modified"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/users/{id} [get]
 func (h *Handler) getUserByID(w http.ResponseWriter, r *http.Request) {
@@ -976,10 +1464,19 @@ func (h *Handler) getUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u, err := h.userUsecase.GetByID(uint(id))
+	u, err := h.userUsecase.GetByID(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to get user by ID", err)
-		http.Error(w, "User not found", http.StatusNotFound)
+		logger.FromContext(r.Context()).Error("Failed to get user by ID", err)
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+
+	// Репутация не меняет время изменения пользователя, поэтому Last-Modified не отдается
+	if notModified(w, r, userETag(u), time.Time{}) {
 		return
 	}
 
@@ -994,10 +1491,14 @@ func (h *Handler) getUserByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Param user body user.UpdateUserDTO true "Updated user data"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 200 {object} user.User
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/users/{id} [put]
@@ -1021,13 +1522,115 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updatedUser, err := h.userUsecase.Update(uint(id), &req)
+	existingUser, err := h.userUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get user by ID", err)
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if !checkIfMatch(w, r, userETag(existingUser)) {
+		return
+	}
+
+	updatedUser, err := h.userUsecase.Update(r.Context(), uint(id), &req, existingUser.Version)
 	if err != nil {
-		logger.Error("Failed to update user", err)
+		logger.FromContext(r.Context()).Error("Failed to update user", err)
+		if versionConflict(w, err) {
+			return
+		}
+		if isMediaError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	// Отвечаем пользователем вместе с репутацией, как при чтении, чтобы ETag ответа
+	// подходил для следующего изменения
+	if current, err := h.userUsecase.GetByID(r.Context(), updatedUser.ID); err == nil {
+		updatedUser = current
+		w.Header().Set("ETag", userETag(current))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedUser)
+}
+
+// @Summary Update user role
+// @Description Change the role of a user. Admin only.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body user.UpdateUserRoleDTO true "New role"
+// @Param If-Match header string true "ETag of the current representation"
+// @Success 200 {object} user.User
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/users/{id}/role [patch]
+func (h *Handler) updateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var dto user.UpdateUserRoleDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existingUser, err := h.userUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get user by ID", err)
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if !checkIfMatch(w, r, userETag(existingUser)) {
+		return
+	}
+
+	updatedUser, err := h.userUsecase.UpdateRole(r.Context(), uint(id), dto.Role, existingUser.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to update user role", err)
+		if versionConflict(w, err) {
+			return
+		}
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to update user role", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if current, err := h.userUsecase.GetByID(r.Context(), updatedUser.ID); err == nil {
+		updatedUser = current
+		w.Header().Set("ETag", userETag(current))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(updatedUser)
 }
@@ -1036,10 +1639,14 @@ func (h *Handler) updateUser(w http.ResponseWriter, r *http.Request) {
 // @Description Delete user by ID
 // @Tags Users
 // @Param id path int true "User ID"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/users/{id} [delete]
@@ -1053,14 +1660,31 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		logger.Error("Invalid user ID for deletion", err)
+		logger.FromContext(r.Context()).Error("Invalid user ID for deletion", err)
 		http.Error(w, "Invalid user ID", http.StatusBadRequest)
 		return
 	}
 
+	existingUser, err := h.userUsecase.GetByID(r.Context(), uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get user by ID", err)
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if !checkIfMatch(w, r, userETag(existingUser)) {
+		return
+	}
+
 	// Call the usecase to delete the user
-	if err := h.userUsecase.Delete(uint(id)); err != nil {
-		logger.Error("Failed to delete user", err)
+	if err := h.userUsecase.Delete(r.Context(), uint(id), existingUser.Version); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to delete user", err)
+		if versionConflict(w, err) {
+			return
+		}
 		if errors.Is(err, usecase.ErrUserNotFound) {
 			http.Error(w, "User not found", http.StatusNotFound)
 		} else {
@@ -1073,17 +1697,21 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Update book state
-// @Description Update the state of an existing book. Only the book owner can update its state.
+// @Description Update the state of an existing book. Only the book owner or an admin can update its state.
 // @Tags Books
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
 // @Param state body book.UpdateBookStateDTO true "New state data"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 200 {object} book.Book "Updated book with new state"
 // @Failure 400 {object} ErrorResponse "Invalid request data or validation failed"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing token"
-// @Failure 403 {object} ErrorResponse "Forbidden - User is not the book owner"
+// @Failure 403 {object} ErrorResponse "Forbidden - User is not the book owner or an admin"
 // @Failure 404 {object} ErrorResponse "Book not found"
+// @Failure 409 {object} ErrorResponse "Transition to the requested state is not allowed"
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security Bearer
 // @Router /api/v1/books/{id}/state [patch]
@@ -1095,31 +1723,54 @@ func (h *Handler) deleteUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) updateBookState(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
 	if err != nil {
-		logger.Error("Invalid book ID", err)
+		logger.FromContext(r.Context()).Error("Invalid book ID", err)
 		http.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
 	var dto book.UpdateBookStateDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	if err := h.validate.Struct(dto); err != nil {
-		logger.Error("Validation failed", err)
+		logger.FromContext(r.Context()).Error("Validation failed", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	book, err := h.bookUsecase.UpdateBookState(uint(id), uint(dto.StateID))
+	existingBook, err := h.bookUsecase.GetBookForUpdate(r.Context(), uint(id))
 	if err != nil {
-		logger.Error("Failed to update book state", err)
+		logger.FromContext(r.Context()).Error("Failed to get book", err)
+		http.Error(w, "Book not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, bookETag(existingBook)) {
+		return
+	}
+
+	book, err := h.bookUsecase.UpdateBookState(r.Context(), uint(id), uint(dto.StateID), existingBook.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to update book state", err)
+		if versionConflict(w, err) {
+			return
+		}
+		var transitionErr *state.TransitionError
+		if errors.As(err, &transitionErr) {
+			http.Error(w, transitionErr.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to update book state", http.StatusInternalServerError)
 		return
 	}
 
+	if current, err := h.bookUsecase.GetBookByID(r.Context(), book.ID); err == nil {
+		book = current
+		w.Header().Set("ETag", bookETag(current))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(book)
 }
@@ -1130,30 +1781,32 @@ func (h *Handler) updateBookState(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param user body user.CreateUserDTO true "User registration data"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
 // @Success 201 {object} user.User
 // @Failure 400 {object} ErrorResponse
 // @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Idempotency-Key has been used for a different request"
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/auth/register [post]
 func (h *Handler) register(w http.ResponseWriter, r *http.Request) {
 	var dto user.CreateUserDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-		logger.Error("Failed to decode request body", err)
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
 	// Validate the DTO
 	if err := h.validate.Struct(dto); err != nil {
-		logger.Error("Validation failed", err)
+		logger.FromContext(r.Context()).Error("Validation failed", err)
 		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Register new user
-	newUser, err := h.userUsecase.Register(&dto)
+	newUser, err := h.userUsecase.Register(r.Context(), &dto)
 	if err != nil {
-		logger.Error("Failed to register user", err)
+		logger.FromContext(r.Context()).Error("Failed to register user", err)
 		if errors.Is(err, usecase.ErrUserAlreadyExists) {
 			http.Error(w, "User already exists", http.StatusConflict)
 		} else {
@@ -1185,7 +1838,7 @@ func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenResponse, userID, err := h.userUsecase.Login(&dto)
+	tokenResponse, userID, err := h.userUsecase.Login(r.Context(), &dto)
 	if err != nil {
 		if err == usecase.ErrInvalidCredentials {
 			h.error(w, http.StatusUnauthorized, "invalid credentials")
@@ -1198,6 +1851,7 @@ func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
 	response := response.LoginResponse{
 		Token:        tokenResponse.Token,
 		RefreshToken: tokenResponse.RefreshToken,
+		ExpiresIn:    tokenResponse.ExpiresIn,
 		UserID:       userID,
 	}
 
@@ -1212,9 +1866,9 @@ func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} ErrorResponse
 // @Router /api/v1/tags [get]
 func (h *Handler) getAllTags(w http.ResponseWriter, r *http.Request) {
-	tags, err := h.tagUsecase.GetAllTags()
+	tags, err := h.tagUsecase.GetAllTags(r.Context())
 	if err != nil {
-		logger.Error("Failed to get tags", err)
+		logger.FromContext(r.Context()).Error("Failed to get tags", err)
 		http.Error(w, "Failed to get tags", http.StatusInternalServerError)
 		return
 	}
@@ -1227,10 +1881,14 @@ func (h *Handler) getAllTags(w http.ResponseWriter, r *http.Request) {
 // @Description Delete tag by ID
 // @Tags Tags
 // @Param id path int true "Tag ID"
+// @Param If-Match header string true "ETag of the current representation"
 // @Success 204 "No Content"
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
 // @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "Resource has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
 // @Failure 500 {object} ErrorResponse
 // @Security Bearer
 // @Router /api/v1/tags/{id} [delete]
@@ -1241,7 +1899,19 @@ func (h *Handler) deleteTag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.tagUsecase.DeleteTag(uint(id)); err != nil {
+	existingTag, err := h.tagUsecase.GetTagByID(r.Context(), uint(id))
+	if err != nil {
+		http.Error(w, "Tag not found", http.StatusNotFound)
+		return
+	}
+	if !checkIfMatch(w, r, tagETag(existingTag)) {
+		return
+	}
+
+	if err := h.tagUsecase.DeleteTag(r.Context(), uint(id), existingTag.Version); err != nil {
+		if versionConflict(w, err) {
+			return
+		}
 		http.Error(w, "Failed to delete tag", http.StatusInternalServerError)
 		return
 	}
@@ -1250,7 +1920,7 @@ func (h *Handler) deleteTag(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Logout user
-// @Description Logout user and invalidate refresh token. Requires a valid refresh token in the X-Refresh-Token header.
+// @Description Logout user and revoke the refresh token together with all tokens issued from it. Requires a valid refresh token in the X-Refresh-Token header.
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -1262,7 +1932,7 @@ func (h *Handler) deleteTag(w http.ResponseWriter, r *http.Request) {
 // @Router /api/v1/auth/logout [post]
 // @Example {json} Request Header:
 //
-//	X-Refresh-Token: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+//	X-Refresh-Token: "8mZ3oQ1q7bQd2vR5w0cJ4kTnY6xE9uHs-AaLpBfGiKo"
 func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
 	refreshToken := r.Header.Get("X-Refresh-Token")
 	if refreshToken == "" {
@@ -1270,8 +1940,12 @@ func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userUsecase.Logout(refreshToken); err != nil {
-		logger.Error("Failed to logout user", err)
+	if err := h.userUsecase.Logout(r.Context(), refreshToken); err != nil {
+		if errors.Is(err, jwt.ErrInvalidRefresh) {
+			h.error(w, http.StatusUnauthorized, "Invalid refresh token")
+			return
+		}
+		logger.FromContext(r.Context()).Error("Failed to logout user", err)
 		h.error(w, http.StatusInternalServerError, "Failed to logout")
 		return
 	}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"booktrading/internal/pkg/logger"
+	"net/http"
+)
+
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+// HealthResponse представляет результат проверки здоровья сервиса
+// @Description Результат проверки здоровья сервиса
+type HealthResponse struct {
+	// Общий статус: ok или unavailable
+	Status string `json:"status" example:"ok"`
+	// Результаты проверок зависимостей: ok или текст ошибки
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// @Summary Liveness probe
+// @Description Reports that the process is running and able to serve HTTP. Does not check dependencies
+// @Tags Health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /healthz [get]
+func (h *Handler) healthz(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, http.StatusOK, HealthResponse{Status: healthStatusOK})
+}
+
+// @Summary Readiness probe
+// @Description Reports whether the service can accept traffic: the database answers a ping, all migrations are applied and unmodified, and the cache backend is healthy. Returns 503 during shutdown
+// @Tags Health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (h *Handler) readyz(w http.ResponseWriter, r *http.Request) {
+	results, ok := h.health.Check(r.Context())
+
+	response := HealthResponse{Status: healthStatusOK, Checks: make(map[string]string, len(results))}
+	for name, err := range results {
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Readiness check failed: "+name, err)
+			response.Checks[name] = err.Error()
+			continue
+		}
+		response.Checks[name] = healthStatusOK
+	}
+
+	if !ok {
+		response.Status = healthStatusUnavailable
+		h.respond(w, http.StatusServiceUnavailable, response)
+		return
+	}
+	h.respond(w, http.StatusOK, response)
+}
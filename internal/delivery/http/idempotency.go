@@ -0,0 +1,143 @@
+package http
+
+import (
+	"booktrading/internal/domain/idempotency"
+	"booktrading/internal/pkg/logger"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxIdempotencyKeyLength - максимальная длина заголовка Idempotency-Key
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders - заголовки ответа, которые сохраняются и повторяются вместе с телом
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotent позволяет безопасно повторять POST запрос с заголовком Idempotency-Key.
+// Первый запрос с ключом выполняется, а его ответ сохраняется на IDEMPOTENCY_TTL.
+// Повтор с тем же ключом и телом получает сохраненный ответ с заголовком
+// Idempotent-Replayed, повтор с другим телом отклоняется с 422, а повтор, пока первый
+// запрос еще выполняется, - с 409. Ответы 5xx не сохраняются: ключ освобождается,
+// и запрос можно повторить. Запросы без заголовка выполняются как обычно.
+// Ключи разных пользователей не пересекаются, поэтому на защищенных маршрутах
+// подключается после проверки токена. У анонимных запросов пользователя нет,
+// поэтому их ключ объединяется с IP адресом клиента
+func (h *Handler) Idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key must not be longer than 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		// Тело нужно и для хеша, и обработчику, поэтому читается целиком и подменяется
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Для анонимных запросов, например регистрации, userID равен 0
+		hash := requestHash(r, body)
+		userID, _ := GetUserIDFromContext(r.Context())
+		if userID == 0 {
+			key = anonymousKey(r, key)
+		}
+		rec, err := h.idempotencyUsecase.Begin(r.Context(), userID, key, hash)
+		switch {
+		case errors.Is(err, idempotency.ErrKeyReused):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		case errors.Is(err, idempotency.ErrInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			logger.FromContext(r.Context()).Error("Failed to check idempotency key", err)
+			http.Error(w, "Failed to check idempotency key", http.StatusInternalServerError)
+			return
+		}
+
+		if rec.Completed() {
+			for name, value := range rec.Headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(rec.StatusCode)
+			w.Write(rec.Body)
+			return
+		}
+
+		// Ответ сохраняется, даже если клиент уже отключился: именно тогда он
+		// и повторит запрос
+		ctx := context.WithoutCancel(r.Context())
+		completed := false
+		defer func() {
+			// Обработчик упал или ответил ошибкой сервера: освобождаем ключ
+			if !completed {
+				if err := h.idempotencyUsecase.Release(ctx, rec); err != nil {
+					logger.FromContext(ctx).Error("Failed to release idempotency key", err)
+				}
+			}
+		}()
+
+		var response bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&response)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if status >= http.StatusInternalServerError {
+			return
+		}
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := ww.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err := h.idempotencyUsecase.Complete(ctx, rec, status, headers, response.Bytes()); err != nil {
+			logger.FromContext(ctx).Error("Failed to save idempotent response", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// requestHash вычисляет SHA-256 метода, пути и тела запроса. Путь входит в хеш,
+// чтобы один ключ нельзя было использовать для запросов к разным ресурсам
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// anonymousKey объединяет ключ анонимного запроса с IP адресом клиента, чтобы ключи
+// разных клиентов не пересекались. Тело в ключ не входит: повтор того же ключа
+// с другим телом должен найти запись и получить 422. RemoteAddr уже заменен
+// middleware.RealIP, а порт отбрасывается, потому что меняется между соединениями
+func anonymousKey(r *http.Request, key string) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	h := sha256.Sum256([]byte(ip + "\n" + key))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"booktrading/internal/domain/idempotency"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/usecase"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIdempotencyRepo хранит ключи идемпотентности в памяти
+type fakeIdempotencyRepo struct {
+	records map[string]*idempotency.Record
+	nextID  uint
+}
+
+func recordKey(userID uint, key string) string {
+	return fmt.Sprintf("%d\n%s", userID, key)
+}
+
+func (r *fakeIdempotencyRepo) Create(ctx context.Context, rec *idempotency.Record) (bool, error) {
+	if _, ok := r.records[recordKey(rec.UserID, rec.Key)]; ok {
+		return false, nil
+	}
+	r.nextID++
+	rec.ID = r.nextID
+	stored := *rec
+	r.records[recordKey(rec.UserID, rec.Key)] = &stored
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepo) Get(ctx context.Context, userID uint, key string) (*idempotency.Record, error) {
+	rec, ok := r.records[recordKey(userID, key)]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *rec
+	return &copied, nil
+}
+
+func (r *fakeIdempotencyRepo) Complete(ctx context.Context, rec *idempotency.Record) error {
+	stored := *rec
+	r.records[recordKey(rec.UserID, rec.Key)] = &stored
+	return nil
+}
+
+func (r *fakeIdempotencyRepo) Delete(ctx context.Context, id uint) error {
+	for k, rec := range r.records {
+		if rec.ID == id {
+			delete(r.records, k)
+		}
+	}
+	return nil
+}
+
+func (r *fakeIdempotencyRepo) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+// idempotentRequest - запрос на регистрацию без авторизации
+type idempotentRequest struct {
+	remoteAddr string
+	key        string
+	body       string
+}
+
+func TestIdempotentAnonymous(t *testing.T) {
+	const first = `{"username":"reader","password":"secret"}`
+
+	tests := []struct {
+		name         string
+		requests     []idempotentRequest
+		wantStatus   int
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{
+			name: "replays same request",
+			requests: []idempotentRequest{
+				{remoteAddr: "192.0.2.1:40000", key: "k1", body: first},
+				{remoteAddr: "192.0.2.1:40001", key: "k1", body: first},
+			},
+			wantStatus:   http.StatusCreated,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name: "rejects key reused with different body",
+			requests: []idempotentRequest{
+				{remoteAddr: "192.0.2.1:40000", key: "k1", body: first},
+				{remoteAddr: "192.0.2.1:40001", key: "k1", body: `{"username":"other","password":"secret"}`},
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+		{
+			name: "keys of different clients do not collide",
+			requests: []idempotentRequest{
+				{remoteAddr: "192.0.2.1:40000", key: "k1", body: first},
+				{remoteAddr: "198.51.100.7:40000", key: "k1", body: `{"username":"other","password":"secret"}`},
+			},
+			wantStatus: http.StatusCreated,
+			wantCalls:  2,
+		},
+		{
+			name: "without key",
+			requests: []idempotentRequest{
+				{remoteAddr: "192.0.2.1:40000", body: first},
+				{remoteAddr: "192.0.2.1:40000", body: first},
+			},
+			wantStatus: http.StatusCreated,
+			wantCalls:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeIdempotencyRepo{records: make(map[string]*idempotency.Record)}
+			h := &Handler{idempotencyUsecase: usecase.NewIdempotencyUseCase(repo, time.Hour)}
+
+			calls := 0
+			register := h.Idempotent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				io.ReadAll(r.Body)
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			var rec *httptest.ResponseRecorder
+			for _, sent := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(sent.body))
+				req.RemoteAddr = sent.remoteAddr
+				if sent.key != "" {
+					req.Header.Set("Idempotency-Key", sent.key)
+				}
+				rec = httptest.NewRecorder()
+				register.ServeHTTP(rec, req)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("last response status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if replayed := rec.Header().Get("Idempotent-Replayed") == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
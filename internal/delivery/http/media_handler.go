@@ -0,0 +1,63 @@
+package http
+
+import (
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/storage"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Get media file
+// @Description Get an uploaded image by its content hash. Files never change, so they are served with a long-lived cache header.
+// @Tags Media
+// @Produce image/jpeg
+// @Produce image/png
+// @Param hash path string true "SHA-256 hash of the file content"
+// @Success 200 {file} binary
+// @Success 304 "Not Modified"
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /media/{hash} [get]
+func (h *Handler) getMedia(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+
+	// Содержимое адресуется хешем, поэтому хеш служит и ETag
+	etag := `"` + hash + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, info, err := h.media.Open(r.Context(), hash)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			h.error(w, http.StatusNotFound, "Media not found")
+			return
+		}
+		logger.FromContext(r.Context()).Error("Failed to open media", err)
+		h.error(w, http.StatusInternalServerError, "Failed to get media")
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to write media", err)
+	}
+}
+
+// isMediaError проверяет, вызвана ли ошибка недопустимым изображением
+func isMediaError(err error) bool {
+	return errors.Is(err, storage.ErrInvalidImage) ||
+		errors.Is(err, storage.ErrImageTooLarge) ||
+		errors.Is(err, storage.ErrUnsupportedValue)
+}
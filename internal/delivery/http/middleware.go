@@ -1,21 +1,38 @@
 package http
 
 import (
+	"booktrading/internal/domain/user"
 	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/metrics"
+	"booktrading/internal/pkg/tracing"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// requestIDKey - атрибут спана с ID запроса из middleware.RequestID
+const requestIDKey = attribute.Key("http.request_id")
+
 type contextKey string
 
 const (
 	UserIDKey contextKey = "user_id"
 	LoginKey  contextKey = "login"
+	RoleKey   contextKey = "role"
 )
 
 // PhotoValidationMiddleware проверяет фотографии в запросе
@@ -36,7 +53,7 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 		// Читаем тело запроса
 		var body map[string]interface{}
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-			logger.Error("Failed to decode request body", err)
+			logger.FromContext(r.Context()).Error("Failed to decode request body", err)
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
@@ -50,7 +67,7 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 
 		// Проверяем количество фотографий
 		if len(photos) > 5 {
-			logger.Error("Too many photos", fmt.Errorf("maximum 5 photos allowed, got %d", len(photos)))
+			logger.FromContext(r.Context()).Error("Too many photos", fmt.Errorf("maximum 5 photos allowed, got %d", len(photos)))
 			http.Error(w, "Maximum 5 photos allowed", http.StatusBadRequest)
 			return
 		}
@@ -59,14 +76,14 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 		for i, photo := range photos {
 			photoStr, ok := photo.(string)
 			if !ok {
-				logger.Error("Invalid photo format", fmt.Errorf("photo at index %d is not a string", i))
+				logger.FromContext(r.Context()).Error("Invalid photo format", fmt.Errorf("photo at index %d is not a string", i))
 				http.Error(w, fmt.Sprintf("Invalid photo format at index %d", i), http.StatusBadRequest)
 				return
 			}
 
 			// Проверяем формат base64
 			if !strings.HasPrefix(photoStr, "data:image/") {
-				logger.Error("Invalid photo format", fmt.Errorf("photo at index %d is not a base64 image", i))
+				logger.FromContext(r.Context()).Error("Invalid photo format", fmt.Errorf("photo at index %d is not a base64 image", i))
 				http.Error(w, fmt.Sprintf("Invalid photo format at index %d: must be base64 encoded image", i), http.StatusBadRequest)
 				return
 			}
@@ -74,7 +91,7 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 			// Проверяем формат изображения
 			contentType := strings.TrimPrefix(photoStr, "data:")
 			if !strings.HasPrefix(contentType, "image/jpeg;base64,") && !strings.HasPrefix(contentType, "image/png;base64,") {
-				logger.Error("Unsupported image format", fmt.Errorf("photo at index %d has unsupported format", i))
+				logger.FromContext(r.Context()).Error("Unsupported image format", fmt.Errorf("photo at index %d has unsupported format", i))
 				http.Error(w, fmt.Sprintf("Unsupported image format at index %d: only JPEG and PNG are allowed", i), http.StatusBadRequest)
 				return
 			}
@@ -83,7 +100,7 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 		// Восстанавливаем тело запроса
 		newBody, err := json.Marshal(body)
 		if err != nil {
-			logger.Error("Failed to marshal request body", err)
+			logger.FromContext(r.Context()).Error("Failed to marshal request body", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
@@ -91,7 +108,7 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 		// Создаем новый запрос с восстановленным телом
 		newReq, err := http.NewRequest(r.Method, r.URL.String(), strings.NewReader(string(newBody)))
 		if err != nil {
-			logger.Error("Failed to create new request", err)
+			logger.FromContext(r.Context()).Error("Failed to create new request", err)
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
@@ -107,6 +124,124 @@ func (h *Handler) PhotoValidationMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// QueryTimeout ограничивает время, которое запрос может провести в базе данных.
+// Контекст запроса передается в репозитории, поэтому по истечении таймаута
+// незавершенные запросы к базе отменяются. При timeout <= 0 ограничения нет
+func QueryTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Metrics учитывает число и длительность HTTP запросов. Запросы группируются по шаблону
+// маршрута chi, а не по пути, чтобы ID в пути не порождали новые серии метрик
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := metrics.RequestStarted(r.Method)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		done(route, status)
+	})
+}
+
+// RequestLogger создает логгер запроса с его ID, методом и путем, доступный обработчикам
+// через logger.FromContext, и по завершении запроса записывает его статус и длительность.
+// Записи также содержат шаблон маршрута chi, а после LogUser - ID пользователя.
+// Должен подключаться после middleware.RequestID
+func RequestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Шаблон маршрута известен только после маршрутизации, поэтому добавляется при записи
+		rctx := chi.RouteContext(r.Context())
+		l := logger.Global().With(
+			"request_id", middleware.GetReqID(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+			"remote_addr", r.RemoteAddr,
+		).Hook(zerolog.HookFunc(func(e *zerolog.Event, _ zerolog.Level, _ string) {
+			if rctx != nil && rctx.RoutePattern() != "" {
+				e.Str("route", rctx.RoutePattern())
+			}
+		}))
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(logger.WithContext(r.Context(), l)))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		l.Info("HTTP request",
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start),
+		)
+	})
+}
+
+// LogUser добавляет ID пользователя из токена в логгер запроса.
+// Подключается после проверки токена
+func LogUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, ok := GetUserIDFromContext(r.Context()); ok {
+			logger.FromContext(r.Context()).Append("user_id", userID)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Tracing создает серверный спан на каждый HTTP запрос, продолжая трассу из заголовка
+// traceparent, если он передан. Спан называется по шаблону маршрута chi и содержит
+// ID запроса, поэтому должен подключаться после middleware.RequestID
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				requestIDKey.String(middleware.GetReqID(r.Context())),
+			),
+		)
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
 // GetUserIDFromContext извлекает ID пользователя из контекста запроса
 func GetUserIDFromContext(ctx context.Context) (uint, bool) {
 	_, claims, err := jwtauth.FromContext(ctx)
@@ -136,3 +271,19 @@ func GetLoginFromContext(ctx context.Context) (string, bool) {
 
 	return login, true
 }
+
+// GetRoleFromContext извлекает роль пользователя из контекста запроса.
+// Токены, выпущенные до появления ролей, считаются токенами обычного пользователя.
+func GetRoleFromContext(ctx context.Context) (user.Role, bool) {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil || claims == nil {
+		return "", false
+	}
+
+	role, roleOK := claims["role"].(string)
+	if !roleOK || role == "" {
+		return user.RoleUser, true
+	}
+
+	return user.Role(role), true
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// newSpanRecorder настраивает глобальный TracerProvider, сохраняющий спаны в памяти.
+// После теста спаны снова ничего не делают
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracing.NewProvider("booktrading-test", 1, recorder)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTracing(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(Tracing)
+	r.Get("/api/v1/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// Спаны обработчика должны быть дочерними для спана запроса
+		_, span := tracing.Start(r.Context(), "BookUseCase.GetBookByID")
+		span.End()
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Post("/api/v1/books", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Failed to create book", http.StatusInternalServerError)
+	})
+
+	const parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		traceparent string
+		wantName    string
+		wantStatus  int
+		wantError   bool
+	}{
+		{
+			name:       "route pattern",
+			method:     http.MethodGet,
+			path:       "/api/v1/books/42",
+			wantName:   "GET /api/v1/books/{id}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "continues incoming trace",
+			method:      http.MethodGet,
+			path:        "/api/v1/books/7",
+			traceparent: "00-" + parentTraceID + "-00f067aa0ba902b7-01",
+			wantName:    "GET /api/v1/books/{id}",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:       "server error",
+			method:     http.MethodPost,
+			path:       "/api/v1/books",
+			wantName:   "POST /api/v1/books",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  true,
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			path:       "/api/v1/unknown",
+			wantName:   "GET",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newSpanRecorder(t)
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(middleware.RequestIDHeader, "req-"+tt.name)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			server := spans[len(spans)-1]
+			if server.Name() != tt.wantName {
+				t.Errorf("span name = %q, want %q", server.Name(), tt.wantName)
+			}
+
+			attrs := make(map[attribute.Key]attribute.Value)
+			for _, kv := range server.Attributes() {
+				attrs[kv.Key] = kv.Value
+			}
+			if got := attrs[requestIDKey].AsString(); got != "req-"+tt.name {
+				t.Errorf("request ID attribute = %q, want %q", got, "req-"+tt.name)
+			}
+			if got := attrs["http.response.status_code"].AsInt64(); got != int64(tt.wantStatus) {
+				t.Errorf("status code attribute = %d, want %d", got, tt.wantStatus)
+			}
+			if (server.Status().Code == codes.Error) != tt.wantError {
+				t.Errorf("span status = %v, want error %v", server.Status(), tt.wantError)
+			}
+
+			if tt.traceparent != "" && server.SpanContext().TraceID().String() != parentTraceID {
+				t.Errorf("trace ID = %s, want incoming %s", server.SpanContext().TraceID(), parentTraceID)
+			}
+			for _, span := range spans[:len(spans)-1] {
+				if span.Parent().SpanID() != server.SpanContext().SpanID() {
+					t.Errorf("span %s is not a child of the request span", span.Name())
+				}
+			}
+		})
+	}
+}
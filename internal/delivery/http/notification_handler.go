@@ -0,0 +1,224 @@
+package http
+
+import (
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/notify"
+	"booktrading/internal/usecase"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sseHeartbeat - период комментариев-пингов в потоке событий, чтобы прокси
+// не закрывали простаивающее соединение
+const sseHeartbeat = 30 * time.Second
+
+// @Summary Stream notifications
+// @Description Server-Sent Events stream of notifications for the current user. Events: trade_offer, trade_accepted, trade_cycle, message, wishlist_match, book_state_changed; the data field contains the notification as JSON and the id field its ID. Browsers' EventSource cannot send headers, so the access token can also be passed in the jwt query parameter or the jwt cookie. On reconnect, unread notifications created after the Last-Event-ID header are sent first.
+// @Tags Notifications
+// @Produce text/event-stream
+// @Param jwt query string false "Access token, if it cannot be sent in the Authorization header"
+// @Param Last-Event-ID header int false "ID of the last received notification"
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/notifications/stream [get]
+func (h *Handler) streamNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.error(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	// Поток живет дольше таймаута записи сервера, поэтому снимаем его для этого соединения
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to disable write deadline for notification stream", err)
+	}
+
+	// Подписываемся до чтения пропущенных уведомлений, чтобы не потерять созданные между ними
+	sub := h.notificationUsecase.Subscribe(userID)
+	defer h.notificationUsecase.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var lastSent uint
+	if lastID, err := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 32); err == nil {
+		missed, err := h.notificationUsecase.GetMissed(r.Context(), userID, uint(lastID))
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to get missed notifications", err)
+		}
+		for _, n := range missed {
+			event, err := usecase.NotificationEvent(n)
+			if err != nil {
+				logger.FromContext(r.Context()).Error("Failed to encode notification", err)
+				continue
+			}
+			writeEvent(w, event)
+			lastSent = n.ID
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			// Уведомление уже отправлено среди пропущенных
+			if event.ID <= lastSent {
+				continue
+			}
+			writeEvent(w, event)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent записывает событие в формате Server-Sent Events
+func writeEvent(w http.ResponseWriter, e notify.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
+
+// @Summary Get notifications
+// @Description Get paginated notification inbox of the current user, newest first
+// @Tags Notifications
+// @Produce json
+// @Param unread query bool false "Return only unread notifications"
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns notifications, unread_count and pagination info"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/notifications [get]
+func (h *Handler) getNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	unreadOnly := false
+	if value := r.URL.Query().Get("unread"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid unread value")
+			return
+		}
+		unreadOnly = parsed
+	}
+
+	page, pageSize := pageParams(r)
+
+	notifications, total, err := h.notificationUsecase.GetUserNotifications(r.Context(), userID, unreadOnly, page, pageSize)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get notifications", err)
+		h.notificationError(w, err)
+		return
+	}
+
+	unread, err := h.notificationUsecase.CountUnread(r.Context(), userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to count unread notifications", err)
+		h.notificationError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"notifications": notifications,
+		"unread_count":  unread,
+		"pagination":    paginationInfo(total, page, pageSize),
+	})
+}
+
+// @Summary Mark notification as read
+// @Description Mark a single notification of the current user as read
+// @Tags Notifications
+// @Param id path int true "Notification ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/notifications/{id}/read [post]
+func (h *Handler) markNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	if err := h.notificationUsecase.MarkRead(r.Context(), userID, uint(id)); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to mark notification as read", err)
+		h.notificationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark every notification of the current user as read
+// @Tags Notifications
+// @Success 204 "No Content"
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/notifications/read [post]
+func (h *Handler) markAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	if err := h.notificationUsecase.MarkAllRead(r.Context(), userID); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to mark notifications as read", err)
+		h.notificationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// notificationError преобразует ошибки уведомлений в HTTP ответ
+func (h *Handler) notificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrNotificationNotFound):
+		h.error(w, http.StatusNotFound, "Notification not found")
+	default:
+		h.error(w, http.StatusInternalServerError, "Failed to process notifications")
+	}
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/cursor"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// queryParam возвращает значение первого непустого параметра запроса из перечисленных
+func queryParam(r *http.Request, names ...string) string {
+	for _, name := range names {
+		if value := r.URL.Query().Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// pageParams разбирает параметры постраничной пагинации page и pageSize (или page_size).
+// Недопустимые значения заменяются значениями по умолчанию: 1 и 10
+func pageParams(r *http.Request) (int, int) {
+	page := 1
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 {
+		page = p
+	}
+
+	pageSize := 10
+	if ps, err := strconv.Atoi(queryParam(r, "pageSize", "page_size")); err == nil && ps > 0 && ps <= 100 {
+		pageSize = ps
+	}
+
+	return page, pageSize
+}
+
+// paginationInfo формирует блок pagination ответа постраничного списка
+func paginationInfo(total int64, page, pageSize int) map[string]interface{} {
+	return map[string]interface{}{
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	}
+}
+
+// cursorMode проверяет, запрошена ли курсорная пагинация.
+// Она включается параметром cursor (пустое значение - первая страница) или limit
+func cursorMode(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("cursor") || query.Has("limit")
+}
+
+// parseCursor разбирает параметры курсорной пагинации для списка scope
+func (h *Handler) parseCursor(r *http.Request, scope string) (*cursor.Cursor, int, error) {
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > 100 {
+			return nil, 0, fmt.Errorf("limit must be between 1 and 100")
+		}
+		limit = l
+	}
+
+	token := r.URL.Query().Get("cursor")
+	if token == "" {
+		return nil, limit, nil
+	}
+
+	c, err := h.cursorSigner.Decode(token, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c, limit, nil
+}
+
+// respondCursorPage отправляет страницу списка с курсорами соседних страниц
+// в теле ответа и в заголовке Link (RFC 8288)
+func (h *Handler) respondCursorPage(w http.ResponseWriter, r *http.Request, key string, items interface{}, page *cursor.Page, limit int) {
+	var nextCursor, prevCursor string
+	var links []string
+
+	if page.Next != nil {
+		nextCursor = h.cursorSigner.Encode(page.Next)
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, cursorURL(r, nextCursor, limit)))
+	}
+	if page.Prev != nil {
+		prevCursor = h.cursorSigner.Encode(page.Prev)
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, cursorURL(r, prevCursor, limit)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		key:           items,
+		"next_cursor": nextCursor,
+		"prev_cursor": prevCursor,
+		"limit":       limit,
+	})
+}
+
+// cursorURL строит ссылку на страницу списка с указанным курсором
+func cursorURL(r *http.Request, token string, limit int) string {
+	query := r.URL.Query()
+	query.Del("page")
+	query.Del("pageSize")
+	query.Del("page_size")
+	query.Set("cursor", token)
+	query.Set("limit", strconv.Itoa(limit))
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// bookPage вычисляет курсоры соседних страниц для списка книг
+func bookPage(c *cursor.Cursor, scope string, books []*book.Book, hasMore bool) *cursor.Page {
+	if len(books) == 0 {
+		return cursor.NewPage(c, scope, hasMore, nil, nil)
+	}
+	first, last := books[0], books[len(books)-1]
+	return cursor.NewPage(c, scope, hasMore,
+		&cursor.Position{CreatedAt: first.CreatedAt, ID: first.ID},
+		&cursor.Position{CreatedAt: last.CreatedAt, ID: last.ID})
+}
+
+// userPage вычисляет курсоры соседних страниц для списка пользователей
+func userPage(c *cursor.Cursor, users []*user.User, hasMore bool) *cursor.Page {
+	if len(users) == 0 {
+		return cursor.NewPage(c, "users", hasMore, nil, nil)
+	}
+	first, last := users[0], users[len(users)-1]
+	return cursor.NewPage(c, "users", hasMore,
+		&cursor.Position{CreatedAt: first.CreatedAt, ID: first.ID},
+		&cursor.Position{CreatedAt: last.CreatedAt, ID: last.ID})
+}
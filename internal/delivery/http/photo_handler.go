@@ -0,0 +1,311 @@
+package http
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// photoFormField - имя поля multipart формы с файлами фотографий
+const photoFormField = "photo"
+
+// maxUploadBody ограничивает размер multipart запроса: все фотографии книги и служебные поля
+const maxUploadBody = book.MaxPhotos*storage.MaxImageSize + 1<<20
+
+// @Summary Upload book photos
+// @Description Upload one or more JPEG/PNG photos as multipart/form-data (field "photo", can be repeated). Each file must not exceed 5MB and a book can have at most 5 photos including the existing ones. Photos are appended to the end of the gallery; if any file is rejected, none of them is added.
+// @Tags Books
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param If-Match header string true "ETag of the current book representation"
+// @Param photo formData file true "Photo file (JPEG or PNG, up to 5MB)"
+// @Param is_main formData bool false "Make the first uploaded photo the main one"
+// @Success 201 {array} book.BookPhoto "Uploaded photos"
+// @Failure 400 {object} ErrorResponse "Invalid image, too many photos or malformed form"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Book not found"
+// @Failure 412 {object} ErrorResponse "Book has been modified since it was read"
+// @Failure 413 {object} ErrorResponse "Request body is too large"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/books/{id}/photos [post]
+func (h *Handler) uploadBookPhotos(w http.ResponseWriter, r *http.Request) {
+	bookID, ok := h.bookIDParam(w, r)
+	if !ok {
+		return
+	}
+	b, ok := h.bookForUpdate(w, r, bookID)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBody)
+	if err := r.ParseMultipartForm(storage.MaxImageSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.error(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+			return
+		}
+		h.error(w, http.StatusBadRequest, "Invalid multipart form: "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File[photoFormField]
+	if len(files) == 0 {
+		h.error(w, http.StatusBadRequest, fmt.Sprintf("At least one file is required in the %q field", photoFormField))
+		return
+	}
+	if len(files) > book.MaxPhotos {
+		h.error(w, http.StatusBadRequest, book.ErrTooManyPhotos.Error())
+		return
+	}
+
+	isMain := false
+	if value := r.FormValue("is_main"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid is_main value")
+			return
+		}
+		isMain = parsed
+	}
+
+	data := make([][]byte, 0, len(files))
+	for _, fh := range files {
+		if fh.Size > storage.MaxImageSize {
+			h.error(w, http.StatusBadRequest, fmt.Sprintf("File %q: %v", fh.Filename, storage.ErrImageTooLarge))
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to open uploaded file", err)
+			h.error(w, http.StatusBadRequest, "Failed to read uploaded file")
+			return
+		}
+		content, err := io.ReadAll(io.LimitReader(f, storage.MaxImageSize+1))
+		f.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Error("Failed to read uploaded file", err)
+			h.error(w, http.StatusBadRequest, "Failed to read uploaded file")
+			return
+		}
+		data = append(data, content)
+	}
+
+	// Все фотографии сохраняются одной транзакцией: при ошибке не добавляется ни одна
+	photos, err := h.bookUsecase.UploadPhotos(r.Context(), bookID, data, isMain, b.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to upload photos", err)
+		h.photoError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, photos)
+}
+
+// @Summary Delete book photo
+// @Description Delete a single photo of the book. If the main photo is deleted, the first remaining photo becomes the main one.
+// @Tags Books
+// @Param id path int true "Book ID"
+// @Param photoId path int true "Photo ID"
+// @Param If-Match header string true "ETag of the current book representation"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Book or photo not found"
+// @Failure 412 {object} ErrorResponse "Book has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/books/{id}/photos/{photoId} [delete]
+func (h *Handler) deleteBookPhoto(w http.ResponseWriter, r *http.Request) {
+	bookID, ok := h.bookIDParam(w, r)
+	if !ok {
+		return
+	}
+	photoID, ok := h.photoIDParam(w, r)
+	if !ok {
+		return
+	}
+	b, ok := h.bookForUpdate(w, r, bookID)
+	if !ok {
+		return
+	}
+
+	if err := h.bookUsecase.DeletePhoto(r.Context(), bookID, photoID, b.Version); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to delete photo", err)
+		h.photoError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Set main book photo
+// @Description Make the photo the main one for the book
+// @Tags Books
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param photoId path int true "Photo ID"
+// @Param If-Match header string true "ETag of the current book representation"
+// @Success 200 {array} book.BookPhoto "Book photos in gallery order"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Book or photo not found"
+// @Failure 412 {object} ErrorResponse "Book has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/books/{id}/photos/{photoId}/main [put]
+func (h *Handler) setMainBookPhoto(w http.ResponseWriter, r *http.Request) {
+	bookID, ok := h.bookIDParam(w, r)
+	if !ok {
+		return
+	}
+	photoID, ok := h.photoIDParam(w, r)
+	if !ok {
+		return
+	}
+	b, ok := h.bookForUpdate(w, r, bookID)
+	if !ok {
+		return
+	}
+
+	photos, err := h.bookUsecase.SetMainPhoto(r.Context(), bookID, photoID, b.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to set main photo", err)
+		h.photoError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, photos)
+}
+
+// @Summary Reorder book photos
+// @Description Set the gallery order of the book photos. The list must contain every photo of the book exactly once.
+// @Tags Books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param If-Match header string true "ETag of the current book representation"
+// @Param order body book.ReorderPhotosDTO true "Photo IDs in the new order"
+// @Success 200 {array} book.BookPhoto "Book photos in gallery order"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Book not found"
+// @Failure 412 {object} ErrorResponse "Book has been modified since it was read"
+// @Failure 428 {object} ErrorResponse "If-Match header is missing"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/books/{id}/photos/order [put]
+func (h *Handler) reorderBookPhotos(w http.ResponseWriter, r *http.Request) {
+	bookID, ok := h.bookIDParam(w, r)
+	if !ok {
+		return
+	}
+	b, ok := h.bookForUpdate(w, r, bookID)
+	if !ok {
+		return
+	}
+
+	var dto book.ReorderPhotosDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	photos, err := h.bookUsecase.ReorderPhotos(r.Context(), bookID, dto.PhotoIDs, b.Version)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to reorder photos", err)
+		h.photoError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, photos)
+}
+
+// bookIDParam извлекает ID книги из URL
+func (h *Handler) bookIDParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid book ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// photoIDParam извлекает ID фотографии из URL
+func (h *Handler) photoIDParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "photoId"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid photo ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// bookForUpdate получает текущую книгу мимо кеша и проверяет по ее ETag условие
+// If-Match запроса, изменяющего фотографии. Возвращает false, если ответ с ошибкой уже отправлен
+func (h *Handler) bookForUpdate(w http.ResponseWriter, r *http.Request, bookID uint) (*book.Book, bool) {
+	b, err := h.bookUsecase.GetBookForUpdate(r.Context(), bookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			h.error(w, http.StatusNotFound, "Book not found")
+			return nil, false
+		}
+		logger.FromContext(r.Context()).Error("Failed to get book", err)
+		h.error(w, http.StatusInternalServerError, "Failed to get book")
+		return nil, false
+	}
+	if !checkIfMatch(w, r, bookETag(b)) {
+		return nil, false
+	}
+	return b, true
+}
+
+// photoError преобразует ошибки работы с фотографиями в HTTP ответ
+func (h *Handler) photoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		h.error(w, http.StatusPreconditionFailed, "Resource has been modified")
+	case errors.Is(err, repository.ErrNotFound):
+		h.error(w, http.StatusNotFound, "Book not found")
+	case errors.Is(err, book.ErrPhotoNotFound):
+		h.error(w, http.StatusNotFound, "Photo not found")
+	case isPhotoInputError(err):
+		h.error(w, http.StatusBadRequest, err.Error())
+	default:
+		h.error(w, http.StatusInternalServerError, "Failed to process photo")
+	}
+}
+
+// isPhotoInputError проверяет, вызвана ли ошибка недопустимыми фотографиями в запросе
+func isPhotoInputError(err error) bool {
+	return isMediaError(err) ||
+		errors.Is(err, book.ErrTooManyPhotos) ||
+		errors.Is(err, book.ErrMultipleMainPhotos) ||
+		errors.Is(err, book.ErrInvalidPhotoOrder)
+}
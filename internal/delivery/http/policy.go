@@ -0,0 +1,132 @@
+package http
+
+import (
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/logger"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// hasRole проверяет, входит ли роль в список разрешенных
+func hasRole(role user.Role, allowed []user.Role) bool {
+	for _, r := range allowed {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole пропускает запрос только пользователям с одной из указанных ролей
+func (h *Handler) RequireRole(roles ...user.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetRoleFromContext(r.Context())
+			if !ok {
+				h.error(w, http.StatusUnauthorized, "Authentication failed: Token not found")
+				return
+			}
+
+			if !hasRole(role, roles) {
+				logger.FromContext(r.Context()).Error("Access denied", fmt.Errorf("role %s is not allowed to %s %s", role, r.Method, r.URL.Path))
+				h.error(w, http.StatusForbidden, "You don't have permission to perform this action")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireBookOwnerOrRole пропускает запрос владельцу книги из параметра {id}
+// или пользователю с одной из указанных ролей
+func (h *Handler) RequireBookOwnerOrRole(roles ...user.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, role, ok := h.principal(w, r)
+			if !ok {
+				return
+			}
+
+			if hasRole(role, roles) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+			if err != nil {
+				logger.FromContext(r.Context()).Error("Invalid book ID", err)
+				h.error(w, http.StatusBadRequest, "Invalid book ID")
+				return
+			}
+
+			existingBook, err := h.bookUsecase.GetBookByID(r.Context(), uint(id))
+			if err != nil {
+				logger.FromContext(r.Context()).Error("Failed to get book", err)
+				h.error(w, http.StatusNotFound, "Book not found")
+				return
+			}
+
+			if existingBook.UserID != userID {
+				logger.FromContext(r.Context()).Error("User does not own the book", fmt.Errorf("user %d is not the owner of book %d", userID, id))
+				h.error(w, http.StatusForbidden, "You don't have permission to modify this book")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSelfOrRole пропускает запрос, если параметр {id} совпадает с ID текущего
+// пользователя, или пользователю с одной из указанных ролей
+func (h *Handler) RequireSelfOrRole(roles ...user.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, role, ok := h.principal(w, r)
+			if !ok {
+				return
+			}
+
+			if hasRole(role, roles) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+			if err != nil {
+				logger.FromContext(r.Context()).Error("Invalid user ID", err)
+				h.error(w, http.StatusBadRequest, "Invalid user ID")
+				return
+			}
+
+			if uint(id) != userID {
+				logger.FromContext(r.Context()).Error("Access denied", fmt.Errorf("user %d is not allowed to modify user %d", userID, id))
+				h.error(w, http.StatusForbidden, "You don't have permission to modify this user")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// principal извлекает ID и роль текущего пользователя, отвечая 401 при их отсутствии
+func (h *Handler) principal(w http.ResponseWriter, r *http.Request) (uint, user.Role, bool) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return 0, "", false
+	}
+
+	role, ok := GetRoleFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: Token not found")
+		return 0, "", false
+	}
+
+	return userID, role, true
+}
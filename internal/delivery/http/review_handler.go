@@ -0,0 +1,118 @@
+package http
+
+import (
+	"booktrading/internal/domain/review"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/usecase"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Review trade partner
+// @Description Rate the other party of a completed trade from 1 to 5 with an optional comment. Each party can review a trade only once
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Param review body review.CreateReviewDTO true "Review"
+// @Success 201 {object} review.Review
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades/{id}/reviews [post]
+func (h *Handler) createReview(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid trade ID")
+		return
+	}
+
+	var dto review.CreateReviewDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	rv, err := h.reviewUsecase.CreateReview(r.Context(), userID, uint(id), &dto)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create review", err)
+		h.reviewError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, rv)
+}
+
+// @Summary Get user reviews
+// @Description Get paginated list of reviews received by the user, newest first
+// @Tags Reviews
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns reviews and pagination info"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/users/{id}/reviews [get]
+func (h *Handler) getUserReviews(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	page, pageSize := pageParams(r)
+
+	reviews, total, err := h.reviewUsecase.GetUserReviews(r.Context(), uint(id), page, pageSize)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get user reviews", err)
+		h.reviewError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"reviews":    reviews,
+		"pagination": paginationInfo(total, page, pageSize),
+	})
+}
+
+// reviewError преобразует ошибки отзывов в HTTP ответ
+func (h *Handler) reviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		h.error(w, http.StatusNotFound, "User not found")
+	case errors.Is(err, review.ErrAlreadyReviewed):
+		h.error(w, http.StatusConflict, err.Error())
+	case errors.Is(err, usecase.ErrInvalidTradeStatus):
+		h.error(w, http.StatusConflict, "Only completed trades can be reviewed")
+	case errors.Is(err, usecase.ErrTradeNotFound),
+		errors.Is(err, usecase.ErrTradeForbidden):
+		h.tradeError(w, err)
+	default:
+		h.error(w, http.StatusInternalServerError, "Failed to process reviews")
+	}
+}
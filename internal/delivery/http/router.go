@@ -1,7 +1,10 @@
 package http
 
 import (
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/metrics"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,15 +12,18 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-// NewRouter создает новый роутер с настроенными маршрутами
-func NewRouter(h *Handler, jwtAuth *jwtauth.JWTAuth) *chi.Mux {
+// NewRouter создает новый роутер с настроенными маршрутами.
+// queryTimeout ограничивает обращения к базе данных в рамках одного запроса
+func NewRouter(h *Handler, jwtAuth *jwtauth.JWTAuth, queryTimeout time.Duration) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(Metrics)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.RequestID)
+	r.Use(RequestLogger)
+	r.Use(middleware.Recoverer)
+	r.Use(Tracing)
 	r.Use(middleware.URLFormat)
 	r.Use(middleware.SetHeader("Content-Type", "application/json"))
 
@@ -37,8 +43,9 @@ func NewRouter(h *Handler, jwtAuth *jwtauth.JWTAuth) *chi.Mux {
 			}
 
 			// Устанавливаем остальные CORS заголовки
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, If-Match, If-None-Match, If-Modified-Since, Idempotency-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "Link, ETag, Last-Modified, Idempotent-Replayed")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Max-Age", "300")
 
@@ -52,19 +59,30 @@ func NewRouter(h *Handler, jwtAuth *jwtauth.JWTAuth) *chi.Mux {
 		})
 	})
 
+	// Проверки здоровья для оркестратора
+	r.Get("/healthz", h.healthz)
+	r.Get("/readyz", h.readyz)
+
+	// Метрики Prometheus
+	r.Handle("/metrics", metrics.Handler())
+
 	// Public routes
 	r.Group(func(r chi.Router) {
+		r.Use(QueryTimeout(queryTimeout))
+
 		r.Get("/api/v1/books", h.getAllBooks)
 		r.Get("/api/v1/books/{id}", h.getBookByID)
-		r.Get("/api/v1/books/search", h.searchBooksByTags)
+		r.Get("/api/v1/books/search", h.searchBooks)
 		r.Get("/api/v1/tags", h.getAllTags)
 		r.Get("/api/v1/tags/{id}", h.getTagByID)
 		r.Get("/api/v1/tags/popular", h.getPopularTags)
 		r.Get("/api/v1/states", h.getAllStates)
 		r.Get("/api/v1/states/{id}", h.getStateByID)
+		r.Get("/api/v1/states/{id}/transitions", h.getStateTransitions)
+		r.Get("/media/{hash}", h.getMedia)
 
 		// Auth routes
-		r.Post("/api/v1/auth/register", h.register)
+		r.With(h.Idempotent).Post("/api/v1/auth/register", h.register)
 		r.Post("/api/v1/auth/login", h.login)
 		r.Post("/api/v1/auth/refresh", h.refreshToken)
 		r.Post("/api/v1/auth/logout", h.logout)
@@ -75,30 +93,107 @@ func NewRouter(h *Handler, jwtAuth *jwtauth.JWTAuth) *chi.Mux {
 		// JWT middleware
 		r.Use(jwtauth.Verifier(jwtAuth))
 		r.Use(jwtauth.Authenticator(jwtAuth))
+		r.Use(LogUser)
+		r.Use(QueryTimeout(queryTimeout))
 
 		// Book routes
-		r.Post("/api/v1/books", h.createBook)
-		r.Put("/api/v1/books/{id}", h.updateBook)
-		r.Delete("/api/v1/books/{id}", h.deleteBook)
-		r.Patch("/api/v1/books/{id}/state", h.updateBookState)
-		r.Post("/api/v1/books/{id}/tags", h.addTagsToBook)
-
-		// Tag routes
-		r.Post("/api/v1/tags", h.createTag)
-		r.Put("/api/v1/tags/{id}", h.updateTag)
-		r.Delete("/api/v1/tags/{id}", h.deleteTag)
-
-		// State routes
-		r.Post("/api/v1/states", h.createState)
-		r.Put("/api/v1/states/{id}", h.updateState)
-		r.Delete("/api/v1/states/{id}", h.deleteState)
+		r.With(h.Idempotent).Post("/api/v1/books", h.createBook)
+
+		// Изменять книгу может только ее владелец или администратор
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireBookOwnerOrRole(user.RoleAdmin))
+			r.Put("/api/v1/books/{id}", h.updateBook)
+			r.Delete("/api/v1/books/{id}", h.deleteBook)
+			r.Patch("/api/v1/books/{id}/state", h.updateBookState)
+			r.Post("/api/v1/books/{id}/tags", h.addTagsToBook)
+			r.Post("/api/v1/books/{id}/photos", h.uploadBookPhotos)
+			r.Put("/api/v1/books/{id}/photos/order", h.reorderBookPhotos)
+			r.Delete("/api/v1/books/{id}/photos/{photoId}", h.deleteBookPhoto)
+			r.Put("/api/v1/books/{id}/photos/{photoId}/main", h.setMainBookPhoto)
+		})
+
+		// Управление тегами и состояниями доступно только администраторам
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireRole(user.RoleAdmin))
+
+			// Tag routes
+			r.With(h.Idempotent).Post("/api/v1/tags", h.createTag)
+			r.Put("/api/v1/tags/{id}", h.updateTag)
+			r.Delete("/api/v1/tags/{id}", h.deleteTag)
+
+			// State routes
+			r.With(h.Idempotent).Post("/api/v1/states", h.createState)
+			r.Put("/api/v1/states/{id}", h.updateState)
+			r.Delete("/api/v1/states/{id}", h.deleteState)
+			r.Post("/api/v1/states/{id}/transitions", h.addStateTransition)
+			r.Delete("/api/v1/states/{id}/transitions/{toId}", h.deleteStateTransition)
+
+			r.Patch("/api/v1/users/{id}/role", h.updateUserRole)
+
+			r.Post("/api/v1/trade-cycles/scan", h.scanCycles)
+		})
 
 		// User routes
 		r.Get("/api/v1/users", h.getAllUsers)
 		r.Get("/api/v1/users/{id}", h.getUserByID)
-		r.Put("/api/v1/users/{id}", h.updateUser)
-		r.Delete("/api/v1/users/{id}", h.deleteUser)
 		r.Get("/api/v1/users/{id}/books", h.getUserBooks)
+		r.Get("/api/v1/users/{id}/reviews", h.getUserReviews)
+
+		// Изменять пользователя может только он сам или администратор
+		r.Group(func(r chi.Router) {
+			r.Use(h.RequireSelfOrRole(user.RoleAdmin))
+			r.Put("/api/v1/users/{id}", h.updateUser)
+			r.Delete("/api/v1/users/{id}", h.deleteUser)
+
+			// Вишлист виден только владельцу и администраторам
+			r.Get("/api/v1/users/{id}/wishlist", h.getWishlist)
+			r.Post("/api/v1/users/{id}/wishlist", h.createWishlistItem)
+			r.Delete("/api/v1/users/{id}/wishlist/{itemId}", h.deleteWishlistItem)
+			r.Get("/api/v1/users/{id}/wishlist/matches", h.getWishlistMatches)
+		})
+
+		// Trade routes
+		r.With(h.Idempotent).Post("/api/v1/trades", h.proposeTrade)
+		r.Get("/api/v1/trades", h.getUserTrades)
+		r.Get("/api/v1/trades/{id}", h.getTradeByID)
+		r.Post("/api/v1/trades/{id}/accept", h.acceptTrade)
+		r.Post("/api/v1/trades/{id}/reject", h.rejectTrade)
+		r.Post("/api/v1/trades/{id}/cancel", h.cancelTrade)
+		r.With(h.Idempotent).Post("/api/v1/trades/{id}/counter", h.counterTrade)
+		r.Post("/api/v1/trades/{id}/complete", h.completeTrade)
+		r.Post("/api/v1/trades/{id}/reviews", h.createReview)
+
+		// Trade cycle routes
+		r.Get("/api/v1/trade-cycles", h.getUserCycles)
+		r.Get("/api/v1/trade-cycles/{id}", h.getCycleByID)
+		r.Post("/api/v1/trade-cycles/{id}/accept", h.acceptCycle)
+		r.Post("/api/v1/trade-cycles/{id}/reject", h.rejectCycle)
+		r.Post("/api/v1/trade-cycles/{id}/cancel", h.cancelCycle)
+		r.Post("/api/v1/trade-cycles/{id}/complete", h.completeCycle)
+
+		// Conversation routes
+		r.Get("/api/v1/conversations", h.getConversations)
+		r.Post("/api/v1/conversations", h.startConversation)
+		r.Get("/api/v1/conversations/{id}", h.getConversation)
+		r.Get("/api/v1/conversations/{id}/messages", h.getConversationMessages)
+		r.Post("/api/v1/conversations/{id}/messages", h.sendMessage)
+		r.Post("/api/v1/conversations/{id}/read", h.markConversationRead)
+
+		// Notification routes
+		r.Get("/api/v1/notifications", h.getNotifications)
+		r.Post("/api/v1/notifications/read", h.markAllNotificationsRead)
+		r.Post("/api/v1/notifications/{id}/read", h.markNotificationRead)
+	})
+
+	// Поток уведомлений. EventSource в браузере не умеет передавать заголовки,
+	// поэтому токен также принимается из параметра запроса jwt.
+	// Соединение долгоживущее, поэтому таймаут запросов к базе здесь не применяется
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verify(jwtAuth, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie, jwtauth.TokenFromQuery))
+		r.Use(jwtauth.Authenticator(jwtAuth))
+		r.Use(LogUser)
+
+		r.Get("/api/v1/notifications/stream", h.streamNotifications)
 	})
 
 	// Swagger
@@ -0,0 +1,299 @@
+package http
+
+import (
+	"booktrading/internal/domain/state"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/usecase"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Propose trade
+// @Description Offer one or more of your books in exchange for one or more books of another user
+// @Tags Trades
+// @Accept json
+// @Produce json
+// @Param trade body trade.CreateTradeDTO true "Trade offer"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 201 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} ErrorResponse "Idempotency-Key has been used for a different request"
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades [post]
+func (h *Handler) proposeTrade(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	var dto trade.CreateTradeDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	t, err := h.tradeUsecase.ProposeTrade(r.Context(), userID, &dto)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to propose trade", err)
+		h.tradeError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, t)
+}
+
+// @Summary Get my trades
+// @Description Get paginated list of trades where the current user is the proposer or the recipient
+// @Tags Trades
+// @Produce json
+// @Param status query string false "Filter by status (pending, accepted, rejected, cancelled, countered, completed)"
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns trades and pagination info"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades [get]
+func (h *Handler) getUserTrades(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	page := 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	pageSize := 10
+	if pageSizeStr := r.URL.Query().Get("pageSize"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	status := trade.Status(r.URL.Query().Get("status"))
+
+	trades, total, err := h.tradeUsecase.GetUserTrades(r.Context(), userID, status, page, pageSize)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get user trades", err)
+		h.tradeError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"trades": trades,
+		"pagination": map[string]interface{}{
+			"total":      total,
+			"page":       page,
+			"pageSize":   pageSize,
+			"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+		},
+	})
+}
+
+// @Summary Get trade by ID
+// @Description Get trade information by its ID. Only trade participants can see it.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Success 200 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades/{id} [get]
+func (h *Handler) getTradeByID(w http.ResponseWriter, r *http.Request) {
+	h.handleTradeAction(w, r, h.tradeUsecase.GetTradeByID)
+}
+
+// @Summary Accept trade
+// @Description Accept a pending trade offer. All involved books move to the "trading" state.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Success 200 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades/{id}/accept [post]
+func (h *Handler) acceptTrade(w http.ResponseWriter, r *http.Request) {
+	h.handleTradeAction(w, r, h.tradeUsecase.AcceptTrade)
+}
+
+// @Summary Reject trade
+// @Description Reject a pending trade offer
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Success 200 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades/{id}/reject [post]
+func (h *Handler) rejectTrade(w http.ResponseWriter, r *http.Request) {
+	h.handleTradeAction(w, r, h.tradeUsecase.RejectTrade)
+}
+
+// @Summary Cancel trade
+// @Description Cancel a pending offer (proposer only) or an accepted trade (any participant). Books of an accepted trade become available again.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Success 200 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades/{id}/cancel [post]
+func (h *Handler) cancelTrade(w http.ResponseWriter, r *http.Request) {
+	h.handleTradeAction(w, r, h.tradeUsecase.CancelTrade)
+}
+
+// @Summary Complete trade
+// @Description Confirm that the current user has received the books of an accepted trade. Once both parties confirm, the trade is completed: all involved books move to the "traded" state and change owners.
+// @Tags Trades
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Success 200 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/trades/{id}/complete [post]
+func (h *Handler) completeTrade(w http.ResponseWriter, r *http.Request) {
+	h.handleTradeAction(w, r, h.tradeUsecase.CompleteTrade)
+}
+
+// @Summary Counter trade
+// @Description Answer a pending trade offer with a counter-offer. The original offer gets the "countered" status.
+// @Tags Trades
+// @Accept json
+// @Produce json
+// @Param id path int true "Trade ID"
+// @Param trade body trade.CounterTradeDTO true "Counter-offer"
+// @Param Idempotency-Key header string false "Unique key that makes retries of this request safe"
+// @Success 201 {object} trade.Trade
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse "Idempotency-Key has been used for a different request"
+// @Security Bearer
+// @Router /api/v1/trades/{id}/counter [post]
+func (h *Handler) counterTrade(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid trade ID", err)
+		h.error(w, http.StatusBadRequest, "Invalid trade ID")
+		return
+	}
+
+	var dto trade.CounterTradeDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	counter, err := h.tradeUsecase.CounterTrade(r.Context(), userID, uint(id), &dto)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to counter trade", err)
+		h.tradeError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, counter)
+}
+
+// handleTradeAction разбирает ID обмена и пользователя и выполняет действие над обменом
+func (h *Handler) handleTradeAction(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, userID, id uint) (*trade.Trade, error)) {
+	userID, ok := GetUserIDFromContext(r.Context())
+	if !ok {
+		h.error(w, http.StatusUnauthorized, "Authentication failed: User ID not found in token claims")
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Invalid trade ID", err)
+		h.error(w, http.StatusBadRequest, "Invalid trade ID")
+		return
+	}
+
+	t, err := action(r.Context(), userID, uint(id))
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Trade action failed", err)
+		h.tradeError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, t)
+}
+
+// tradeError преобразует ошибки обмена в HTTP ответ
+func (h *Handler) tradeError(w http.ResponseWriter, err error) {
+	var transitionErr *state.TransitionError
+	switch {
+	case errors.Is(err, usecase.ErrTradeNotFound):
+		h.error(w, http.StatusNotFound, "Trade not found")
+	case errors.Is(err, usecase.ErrCycleNotFound):
+		h.error(w, http.StatusNotFound, "Trade cycle not found")
+	case errors.Is(err, usecase.ErrTradeForbidden):
+		h.error(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, usecase.ErrInvalidTradeOffer):
+		h.error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrInvalidTradeStatus),
+		errors.Is(err, trade.ErrBooksUnavailable),
+		errors.Is(err, trade.ErrStatusChanged),
+		errors.As(err, &transitionErr):
+		h.error(w, http.StatusConflict, err.Error())
+	default:
+		h.error(w, http.StatusInternalServerError, "Failed to process trade")
+	}
+}
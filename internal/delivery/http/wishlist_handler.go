@@ -0,0 +1,175 @@
+package http
+
+import (
+	"booktrading/internal/domain/wishlist"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/usecase"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// @Summary Add wishlist item
+// @Description Add a wish to the user's wishlist. A wish matches a book by title or author substring, exact ISBN and a set of tags; every specified criterion must match. When a book becomes available, matching wishes are recorded and can be fetched from /wishlist/matches.
+// @Tags Wishlist
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param item body wishlist.CreateItemDTO true "Wishlist item"
+// @Success 201 {object} wishlist.Item
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/users/{id}/wishlist [post]
+func (h *Handler) createWishlistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var dto wishlist.CreateItemDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to decode request body", err)
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.validate.Struct(dto); err != nil {
+		logger.FromContext(r.Context()).Error("Validation failed", err)
+		h.error(w, http.StatusBadRequest, "Validation failed: "+err.Error())
+		return
+	}
+
+	item, err := h.wishlistUsecase.CreateItem(r.Context(), userID, &dto)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to create wishlist item", err)
+		h.wishlistError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusCreated, item)
+}
+
+// @Summary Get wishlist
+// @Description Get all wishes of the user
+// @Tags Wishlist
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} wishlist.Item
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/users/{id}/wishlist [get]
+func (h *Handler) getWishlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := h.wishlistUsecase.GetUserItems(r.Context(), userID)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get wishlist", err)
+		h.wishlistError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, items)
+}
+
+// @Summary Delete wishlist item
+// @Description Remove a wish from the user's wishlist together with its matches
+// @Tags Wishlist
+// @Param id path int true "User ID"
+// @Param itemId path int true "Wishlist item ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/users/{id}/wishlist/{itemId} [delete]
+func (h *Handler) deleteWishlistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, err := strconv.ParseUint(chi.URLParam(r, "itemId"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid wishlist item ID")
+		return
+	}
+
+	if err := h.wishlistUsecase.DeleteItem(r.Context(), userID, uint(itemID)); err != nil {
+		logger.FromContext(r.Context()).Error("Failed to delete wishlist item", err)
+		h.wishlistError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get wishlist matches
+// @Description Get paginated list of available books that matched the user's wishlist, newest first
+// @Tags Wishlist
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param pageSize query int false "Items per page (default: 10, max: 100)"
+// @Success 200 {object} map[string]interface{} "Returns matches and pagination info"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Security Bearer
+// @Router /api/v1/users/{id}/wishlist/matches [get]
+func (h *Handler) getWishlistMatches(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	page, pageSize := pageParams(r)
+
+	matches, total, err := h.wishlistUsecase.GetUserMatches(r.Context(), userID, page, pageSize)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("Failed to get wishlist matches", err)
+		h.wishlistError(w, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"matches":    matches,
+		"pagination": paginationInfo(total, page, pageSize),
+	})
+}
+
+// userIDParam извлекает ID пользователя из URL
+func (h *Handler) userIDParam(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// wishlistError преобразует ошибки вишлиста в HTTP ответ
+func (h *Handler) wishlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrWishlistItemNotFound):
+		h.error(w, http.StatusNotFound, "Wishlist item not found")
+	case errors.Is(err, usecase.ErrInvalidWishlistItem):
+		h.error(w, http.StatusBadRequest, err.Error())
+	default:
+		h.error(w, http.StatusInternalServerError, "Failed to process wishlist")
+	}
+}
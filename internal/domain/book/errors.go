@@ -0,0 +1,23 @@
+package book
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxPhotos - максимальное количество фотографий у одной книги
+const MaxPhotos = 5
+
+var (
+	// ErrTooManyPhotos возвращается при попытке добавить книге больше MaxPhotos фотографий
+	ErrTooManyPhotos = fmt.Errorf("maximum %d photos allowed", MaxPhotos)
+	// ErrPhotoNotFound возвращается, если у книги нет фотографии с указанным ID
+	ErrPhotoNotFound = errors.New("photo not found")
+	// ErrInvalidPhotoOrder возвращается, если новый порядок не перечисляет все фотографии книги ровно один раз
+	ErrInvalidPhotoOrder = errors.New("photo order must list every photo of the book exactly once")
+	// ErrMultipleMainPhotos возвращается, если главными отмечены несколько фотографий
+	ErrMultipleMainPhotos = errors.New("only one photo can be the main one")
+	// ErrBookInTrade возвращается при попытке удалить книгу, участвующую в ожидающем
+	// или принятом обмене
+	ErrBookInTrade = errors.New("book is part of an active trade")
+)
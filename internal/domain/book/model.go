@@ -26,12 +26,21 @@ type BookPhoto struct {
 	// @Description ID книги, к которой относится фотография
 	// @example 1
 	BookID uint `json:"book_id" gorm:"not null;index;type:int unsigned"`
-	// @Description URL фотографии в формате base64
-	// @example data:image/jpeg;base64,/9j/4AAQSkZJRg...
+	// @Description URL фотографии в хранилище изображений
+	// @example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b
 	PhotoURL string `json:"photo_url" gorm:"type:mediumtext;not null"`
+	// @Description URL варианта среднего размера (до 800px по длинной стороне)
+	// @example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b-medium
+	MediumURL string `json:"medium_url,omitempty" gorm:"type:varchar(255);not null;default:''"`
+	// @Description URL миниатюры (до 200px по длинной стороне)
+	// @example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b-thumb
+	ThumbnailURL string `json:"thumbnail_url,omitempty" gorm:"type:varchar(255);not null;default:''"`
 	// @Description Флаг, указывающий является ли фотография главной
 	// @example true
 	IsMain bool `json:"is_main" gorm:"default:false"`
+	// @Description Порядковый номер фотографии в галерее книги, начиная с 0
+	// @example 0
+	Position int `json:"position" gorm:"not null;default:0"`
 	// @Description Дата создания
 	// @example 2024-03-20T10:00:00Z
 	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
@@ -45,6 +54,14 @@ func (BookPhoto) TableName() string {
 	return "book_photos"
 }
 
+// UseThumbnail подменяет URL фотографии на URL миниатюры, если она есть.
+// Используется в списках книг, где полноразмерные изображения не нужны
+func (p *BookPhoto) UseThumbnail() {
+	if p.ThumbnailURL != "" {
+		p.PhotoURL = p.ThumbnailURL
+	}
+}
+
 // Book представляет собой книгу в системе
 // @Description Модель книги в системе обмена
 type Book struct {
@@ -53,13 +70,16 @@ type Book struct {
 	ID uint `json:"id" gorm:"primaryKey;autoIncrement;type:int unsigned"`
 	// @Description Название книги
 	// @example Война и мир
-	Title string `json:"title" gorm:"type:varchar(255);not null;index"`
+	Title string `json:"title" gorm:"type:varchar(255);not null;index;index:idx_books_fulltext,class:FULLTEXT,priority:1"`
 	// @Description Автор книги
 	// @example Лев Толстой
-	Author string `json:"author" gorm:"type:varchar(255);not null;index"`
+	Author string `json:"author" gorm:"type:varchar(255);not null;index;index:idx_books_fulltext,class:FULLTEXT,priority:2"`
 	// @Description Описание книги
 	// @example Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона
-	Description string `json:"description" gorm:"type:mediumtext"`
+	Description string `json:"description" gorm:"type:mediumtext;index:idx_books_fulltext,class:FULLTEXT,priority:3"`
+	// @Description ISBN книги без дефисов (ISBN-10 или ISBN-13)
+	// @example 9785170906307
+	ISBN string `json:"isbn,omitempty" gorm:"type:varchar(13);not null;default:'';index"`
 	// @Description ID владельца книги
 	// @example 1
 	UserID uint `json:"user_id" gorm:"not null;type:int unsigned;index"`
@@ -76,10 +96,13 @@ type Book struct {
 	Photos []*BookPhoto `json:"photos" gorm:"foreignKey:BookID"`
 	// @Description Дата создания
 	// @example 2024-03-20T10:00:00Z
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime;index:idx_books_created_at"`
 	// @Description Дата обновления
 	// @example 2024-03-20T10:00:00Z
 	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	// @Description Версия книги, увеличивается при каждом изменении книги, ее тегов и фотографий
+	// @example 3
+	Version uint `json:"version" gorm:"not null;default:1"`
 }
 
 // TableName указывает имя таблицы для модели Book
@@ -99,6 +122,14 @@ type BookPhotoData struct {
 	IsMain bool `json:"is_main"`
 }
 
+// ReorderPhotosDTO представляет новый порядок фотографий книги
+// @Description Новый порядок фотографий книги
+type ReorderPhotosDTO struct {
+	// @Description ID всех фотографий книги в новом порядке
+	// @example [3, 1, 2]
+	PhotoIDs []uint `json:"photo_ids" validate:"required,min=1"`
+}
+
 // CreateBookDTO представляет данные для создания книги
 // @Description Данные для создания новой книги
 type CreateBookDTO struct {
@@ -114,6 +145,10 @@ type CreateBookDTO struct {
 	// @example "Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона"
 	Description string `json:"description"`
 
+	// @Description ISBN книги, дефисы допускаются
+	// @example "978-5-17-090630-7"
+	ISBN string `json:"isbn" validate:"omitempty,isbn_code"`
+
 	// @Description Массив фотографий книги
 	Photos []BookPhotoData `json:"photos"`
 
@@ -142,9 +177,11 @@ type UpdateBookDTO struct {
 	// @Description Описание книги
 	// @example Роман-эпопея, описывающий русское общество в эпоху войн против Наполеона
 	Description string `json:"description"`
-	// @Description Массив URL фотографий в формате base64
-	// @example ["data:image/jpeg;base64,/9j/4AAQSkZJRg..."]
-	Photos []string `json:"photos"`
+	// @Description ISBN книги, дефисы допускаются
+	// @example 978-5-17-090630-7
+	ISBN string `json:"isbn" validate:"omitempty,isbn_code"`
+	// @Description Новый набор фотографий книги. Если поле передано, он заменяет текущие фотографии
+	Photos []BookPhotoData `json:"photos"`
 	// @Description ID состояния книги
 	// @example 1
 	StateID uint `json:"state_id"`
@@ -167,6 +204,7 @@ func (dto *CreateBookDTO) ToBook() *Book {
 		Title:       dto.Title,
 		Author:      dto.Author,
 		Description: dto.Description,
+		ISBN:        NormalizeISBN(dto.ISBN),
 		UserID:      dto.UserID,
 		StateID:     dto.StateID,
 	}
@@ -183,6 +221,9 @@ func (b *Book) UpdateFromDTO(dto *UpdateBookDTO) {
 	if dto.Description != "" {
 		b.Description = dto.Description
 	}
+	if dto.ISBN != "" {
+		b.ISBN = NormalizeISBN(dto.ISBN)
+	}
 	if dto.StateID != 0 {
 		b.StateID = dto.StateID
 	}
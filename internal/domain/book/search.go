@@ -0,0 +1,95 @@
+package book
+
+import "time"
+
+// TagMode определяет, как сочетаются теги в фильтре поиска
+type TagMode string
+
+const (
+	TagModeOr  TagMode = "or"  // Книга содержит хотя бы один из тегов
+	TagModeAnd TagMode = "and" // Книга содержит все теги
+)
+
+// IsValid проверяет, является ли режим тегов допустимым
+func (m TagMode) IsValid() bool {
+	return m == TagModeOr || m == TagModeAnd
+}
+
+// SearchSort определяет порядок сортировки результатов поиска
+type SearchSort string
+
+const (
+	SortRelevance SearchSort = "relevance" // По релевантности полнотекстового запроса
+	SortRecent    SearchSort = "recent"    // Сначала новые книги
+)
+
+// IsValid проверяет, является ли сортировка допустимой
+func (s SearchSort) IsValid() bool {
+	return s == SortRelevance || s == SortRecent
+}
+
+// SearchParams представляет параметры поиска книг
+type SearchParams struct {
+	// Query - полнотекстовый запрос по названию, автору и описанию
+	Query string `json:"q,omitempty"`
+	// Author - фильтр по автору (вхождение подстроки)
+	Author string `json:"author,omitempty"`
+	// StateID - фильтр по состоянию книги
+	StateID uint `json:"state_id,omitempty"`
+	// UserID - фильтр по владельцу книги
+	UserID uint `json:"user_id,omitempty"`
+	// CreatedFrom и CreatedTo ограничивают дату создания книги
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+	// TagIDs - фильтр по тегам, TagMode - способ их сочетания
+	TagIDs  []uint  `json:"tag_ids,omitempty"`
+	TagMode TagMode `json:"tag_mode,omitempty"`
+	// Sort - порядок сортировки результатов
+	Sort     SearchSort `json:"sort,omitempty"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"page_size"`
+}
+
+// TagFacet представляет количество найденных книг с тегом
+// @Description Количество найденных книг с тегом
+type TagFacet struct {
+	// @Description ID тега
+	// @example 1
+	TagID uint `json:"tag_id"`
+	// @Description Название тега
+	// @example fiction
+	Name string `json:"name"`
+	// @Description Количество найденных книг с тегом
+	// @example 12
+	Count int64 `json:"count"`
+}
+
+// StateFacet представляет количество найденных книг в состоянии
+// @Description Количество найденных книг в состоянии
+type StateFacet struct {
+	// @Description ID состояния
+	// @example 1
+	StateID uint `json:"state_id"`
+	// @Description Название состояния
+	// @example available
+	Name string `json:"name"`
+	// @Description Количество найденных книг в состоянии
+	// @example 7
+	Count int64 `json:"count"`
+}
+
+// SearchFacets содержит счетчики для построения фильтров
+// @Description Счетчики найденных книг по тегам и состояниям
+type SearchFacets struct {
+	// @Description Количество книг по тегам
+	Tags []*TagFacet `json:"tags"`
+	// @Description Количество книг по состояниям
+	States []*StateFacet `json:"states"`
+}
+
+// SearchResult представляет результат поиска книг
+type SearchResult struct {
+	Books  []*Book
+	Total  int64
+	Facets *SearchFacets
+}
@@ -0,0 +1,138 @@
+package conversation
+
+import (
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/gorm"
+	"strings"
+	"time"
+)
+
+// MaxMessageLength - максимальная длина сообщения в символах
+const MaxMessageLength = 2000
+
+// Conversation представляет переписку пользователей о книге или об обмене
+// @Description Переписка между владельцем книги и заинтересованным пользователем или участниками обмена
+type Conversation struct {
+	gorm.Base
+	// @Description ID книги, о которой идет переписка
+	// @example 1
+	BookID *uint `json:"book_id,omitempty" gorm:"index"`
+	// @Description ID обмена, о котором идет переписка
+	// @example 1
+	TradeID *uint `json:"trade_id,omitempty" gorm:"index"`
+	// @Description Время последнего сообщения
+	// @example 2024-03-20T10:00:00Z
+	LastMessageAt time.Time `json:"last_message_at" gorm:"index"`
+	// @Description Участники переписки
+	Participants []*Participant `json:"participants" gorm:"foreignKey:ConversationID"`
+	// @Description Число непрочитанных сообщений текущего пользователя
+	// @example 2
+	UnreadCount int `json:"unread_count" gorm:"-"`
+}
+
+// TableName указывает имя таблицы для модели Conversation
+func (Conversation) TableName() string {
+	return "conversations"
+}
+
+// Participant представляет участника переписки и его счетчик непрочитанных сообщений
+// @Description Участник переписки
+type Participant struct {
+	// @Description ID записи участника
+	// @example 1
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// @Description ID переписки
+	// @example 1
+	ConversationID uint `json:"conversation_id" gorm:"not null;uniqueIndex:idx_conversation_participant"`
+	// @Description ID пользователя
+	// @example 2
+	UserID uint `json:"user_id" gorm:"not null;uniqueIndex:idx_conversation_participant;index"`
+	// @Description Информация о пользователе
+	User *user.User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	// @Description Число непрочитанных сообщений участника
+	// @example 2
+	UnreadCount int `json:"unread_count" gorm:"not null;default:0"`
+	// @Description Время, когда участник последний раз прочитал переписку
+	// @example 2024-03-20T10:00:00Z
+	LastReadAt *time.Time `json:"last_read_at,omitempty"`
+}
+
+// TableName указывает имя таблицы для модели Participant
+func (Participant) TableName() string {
+	return "conversation_participants"
+}
+
+// Message представляет сообщение в переписке
+// @Description Сообщение в переписке
+type Message struct {
+	// @Description ID сообщения
+	// @example 1
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// @Description ID переписки
+	// @example 1
+	ConversationID uint `json:"conversation_id" gorm:"not null;index:idx_messages_conversation_created,priority:1"`
+	// @Description ID отправителя
+	// @example 2
+	SenderID uint `json:"sender_id" gorm:"not null"`
+	// @Description Текст сообщения
+	// @example Здравствуйте! Книга еще доступна?
+	Body string `json:"body" gorm:"type:text;not null"`
+	// @Description Время отправки
+	// @example 2024-03-20T10:00:00Z
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_messages_conversation_created,priority:2"`
+}
+
+// TableName указывает имя таблицы для модели Message
+func (Message) TableName() string {
+	return "messages"
+}
+
+// Participant возвращает участника переписки по ID пользователя
+func (c *Conversation) Participant(userID uint) *Participant {
+	for _, p := range c.Participants {
+		if p.UserID == userID {
+			return p
+		}
+	}
+	return nil
+}
+
+// IsParticipant проверяет, является ли пользователь участником переписки
+func (c *Conversation) IsParticipant(userID uint) bool {
+	return c.Participant(userID) != nil
+}
+
+// ForUser заполняет счетчик непрочитанных сообщений для пользователя, который просматривает переписку
+func (c *Conversation) ForUser(userID uint) *Conversation {
+	if p := c.Participant(userID); p != nil {
+		c.UnreadCount = p.UnreadCount
+	}
+	return c
+}
+
+// CreateConversationDTO представляет данные для начала переписки
+// @Description Данные для начала переписки. Нужно указать ровно одно из book_id или trade_id
+type CreateConversationDTO struct {
+	// @Description ID книги: переписка с ее владельцем
+	// @example 1
+	BookID *uint `json:"book_id" validate:"required_without=TradeID,excluded_with=TradeID,omitempty,min=1"`
+	// @Description ID обмена: переписка между его участниками
+	// @example 1
+	TradeID *uint `json:"trade_id" validate:"required_without=BookID,excluded_with=BookID,omitempty,min=1"`
+	// @Description Первое сообщение
+	// @example Здравствуйте! Книга еще доступна?
+	Message string `json:"message" validate:"required,max=2000"`
+}
+
+// SendMessageDTO представляет данные для отправки сообщения
+// @Description Данные для отправки сообщения
+type SendMessageDTO struct {
+	// @Description Текст сообщения
+	// @example Могу встретиться завтра
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+// NormalizeBody обрезает пробелы по краям сообщения
+func NormalizeBody(body string) string {
+	return strings.TrimSpace(body)
+}
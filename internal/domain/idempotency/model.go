@@ -0,0 +1,47 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrKeyReused возвращается, если ключ уже использован для запроса с другим телом
+	// или к другому адресу
+	ErrKeyReused = errors.New("idempotency key has already been used for a different request")
+	// ErrInProgress возвращается, если запрос с тем же ключом еще выполняется
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// Record хранит ключ идемпотентности запроса и ответ на этот запрос.
+// Пока запрос выполняется, StatusCode равен 0
+type Record struct {
+	ID uint `gorm:"primaryKey"`
+	// UserID - автор запроса, 0 для запросов без авторизации. Ключи разных
+	// пользователей не пересекаются
+	UserID uint   `gorm:"not null;default:0;uniqueIndex:idx_idempotency_user_key"`
+	Key    string `gorm:"column:idempotency_key;type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key"`
+	// RequestHash - SHA-256 метода, пути и тела запроса
+	RequestHash string `gorm:"type:char(64);not null"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	// Headers - заголовки ответа, которые повторяются вместе с телом
+	Headers   map[string]string `gorm:"serializer:json;type:text"`
+	Body      []byte            `gorm:"type:mediumblob"`
+	CreatedAt time.Time
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// TableName указывает имя таблицы для модели Record
+func (Record) TableName() string {
+	return "idempotency_keys"
+}
+
+// Completed проверяет, сохранен ли уже ответ на запрос
+func (r *Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// IsExpired проверяет, истек ли срок хранения ключа
+func (r *Record) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
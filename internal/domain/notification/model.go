@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"booktrading/internal/pkg/gorm"
+	"encoding/json"
+	"time"
+)
+
+// Type представляет тип уведомления
+// @Description Перечисление типов уведомлений
+type Type string
+
+const (
+	TypeTradeOffer       Type = "trade_offer"        // Новое или встречное предложение обмена
+	TypeTradeAccepted    Type = "trade_accepted"     // Предложение обмена принято
+	TypeTradeCycle       Type = "trade_cycle"        // Найден кольцевой обмен с участием пользователя
+	TypeMessage          Type = "message"            // Новое сообщение в переписке
+	TypeWishlistMatch    Type = "wishlist_match"     // Найдена книга по вишлисту
+	TypeBookStateChanged Type = "book_state_changed" // Изменилось состояние интересующей книги
+)
+
+// Notification представляет уведомление пользователя. Уведомления сохраняются,
+// чтобы пользователь увидел их, даже если не был подключен в момент события
+// @Description Уведомление пользователя
+type Notification struct {
+	gorm.Base
+	// @Description ID получателя
+	// @example 1
+	UserID uint `json:"user_id" gorm:"not null;index:idx_notifications_user_read,priority:1"`
+	// @Description Тип уведомления
+	// @example trade_offer
+	Type Type `json:"type" gorm:"type:varchar(32);not null"`
+	// @Description Данные события, зависят от типа уведомления
+	Data json.RawMessage `json:"data" gorm:"type:json" swaggertype:"object"`
+	// @Description Время прочтения, пусто для непрочитанных уведомлений
+	// @example 2024-03-20T10:00:00Z
+	ReadAt *time.Time `json:"read_at,omitempty" gorm:"index:idx_notifications_user_read,priority:2"`
+}
+
+// TableName указывает имя таблицы для модели Notification
+func (Notification) TableName() string {
+	return "notifications"
+}
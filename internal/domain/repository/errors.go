@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrNotFound = errors.New("record not found")
+	// ErrVersionConflict возвращается, если запись изменили после того, как ее прочитал
+	// тот, кто ее обновляет или удаляет
+	ErrVersionConflict = errors.New("version conflict")
 )
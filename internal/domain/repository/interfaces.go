@@ -2,61 +2,167 @@ package repository
 
 import (
 	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/conversation"
+	"booktrading/internal/domain/idempotency"
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/review"
 	"booktrading/internal/domain/state"
 	"booktrading/internal/domain/tag"
 	"booktrading/internal/domain/token"
+	"booktrading/internal/domain/trade"
 	"booktrading/internal/domain/user"
+	"booktrading/internal/domain/wishlist"
+	"booktrading/internal/pkg/cursor"
+	"context"
 )
 
-// BookRepository определяет интерфейс для работы с книгами
+// BookRepository определяет интерфейс для работы с книгами.
+// Update, Delete и изменения фотографий выполняются, только если версия книги не изменилась
+// с момента чтения, иначе возвращается ErrVersionConflict
 type BookRepository interface {
-	Create(book *book.Book) error
-	GetByID(id uint) (*book.Book, error)
-	GetByTags(tagIDs []uint) ([]*book.Book, error)
-	AddTags(bookID uint, tagIDs []uint) error
-	Update(book *book.Book) error
-	Delete(id uint) error
-	GetAll(page, pageSize int) ([]*book.Book, int64, error)
-	GetUserBooks(userID uint, page, pageSize int) ([]*book.Book, int64, error)
-	CreatePhoto(photo *book.BookPhoto) error
-	DeletePhotos(bookID uint) error
-}
-
-// TagRepository определяет интерфейс для работы с тегами
+	Create(ctx context.Context, book *book.Book) error
+	GetByID(ctx context.Context, id uint) (*book.Book, error)
+	GetByTags(ctx context.Context, tagIDs []uint) ([]*book.Book, error)
+	Search(ctx context.Context, params *book.SearchParams) (*book.SearchResult, error)
+	AddTags(ctx context.Context, bookID uint, tagIDs []uint) error
+	Update(ctx context.Context, book *book.Book, photos []*book.BookPhoto) error
+	Delete(ctx context.Context, id, version uint) error
+	GetAll(ctx context.Context, page, pageSize int) ([]*book.Book, int64, error)
+	GetUserBooks(ctx context.Context, userID uint, page, pageSize int) ([]*book.Book, int64, error)
+	GetAllByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*book.Book, bool, error)
+	GetUserBooksByCursor(ctx context.Context, userID uint, c *cursor.Cursor, limit int) ([]*book.Book, bool, error)
+	GetByState(ctx context.Context, stateID uint) ([]*book.Book, error)
+	CountByState(ctx context.Context) (map[string]int64, error)
+	GetPhotos(ctx context.Context, bookID uint) ([]*book.BookPhoto, error)
+	CreatePhotos(ctx context.Context, bookID uint, photos []*book.BookPhoto, version uint) error
+	DeletePhoto(ctx context.Context, bookID, photoID, version uint) error
+	SetMainPhoto(ctx context.Context, bookID, photoID, version uint) error
+	ReorderPhotos(ctx context.Context, bookID uint, photoIDs []uint, version uint) error
+	DeletePhotos(ctx context.Context, bookID, version uint) error
+}
+
+// TagRepository определяет интерфейс для работы с тегами.
+// Update и Delete проверяют версию тега так же, как BookRepository
 type TagRepository interface {
-	Create(tag *tag.Tag) error
-	GetByID(id uint) (*tag.Tag, error)
-	GetByName(name string) (*tag.Tag, error)
-	GetAll() ([]*tag.Tag, error)
-	GetPopular(limit int) ([]*tag.TagWithCount, error)
-	Update(tag *tag.Tag) error
-	Delete(id uint) error
+	Create(ctx context.Context, tag *tag.Tag) error
+	GetByID(ctx context.Context, id uint) (*tag.Tag, error)
+	GetByName(ctx context.Context, name string) (*tag.Tag, error)
+	GetAll(ctx context.Context) ([]*tag.Tag, error)
+	GetPopular(ctx context.Context, limit int) ([]*tag.TagWithCount, error)
+	Update(ctx context.Context, tag *tag.Tag) error
+	Delete(ctx context.Context, id, version uint) error
 }
 
-// StateRepository определяет интерфейс для работы с состояниями
+// StateRepository определяет интерфейс для работы с состояниями.
+// Update, Delete и изменения переходов проверяют версию состояния так же, как BookRepository
 type StateRepository interface {
-	Create(s *state.State) error
-	GetByID(id uint) (*state.State, error)
-	GetAll() ([]*state.State, error)
-	Update(s *state.State) error
-	Delete(id uint) error
+	Create(ctx context.Context, s *state.State) error
+	GetByID(ctx context.Context, id uint) (*state.State, error)
+	GetByName(ctx context.Context, name string) (*state.State, error)
+	GetAll(ctx context.Context) ([]*state.State, error)
+	Update(ctx context.Context, s *state.State) error
+	Delete(ctx context.Context, id, version uint) error
+	GetTransitions(ctx context.Context, fromStateID uint) ([]*state.Transition, error)
+	AddTransition(ctx context.Context, t *state.Transition, version uint) error
+	DeleteTransition(ctx context.Context, fromStateID, toStateID, version uint) error
+	CheckTransition(ctx context.Context, fromStateID, toStateID uint) error
 }
 
-// UserRepository определяет интерфейс для работы с пользователями
+// UserRepository определяет интерфейс для работы с пользователями.
+// Update и Delete проверяют версию пользователя так же, как BookRepository
 type UserRepository interface {
-	Create(user *user.User) error
-	GetByID(id uint) (*user.User, error)
-	GetByLogin(login string) (*user.User, error)
-	GetAll(page, pageSize int) ([]*user.User, int64, error)
-	Update(user *user.User) error
-	Delete(id uint) error
+	Create(ctx context.Context, user *user.User) error
+	GetByID(ctx context.Context, id uint) (*user.User, error)
+	GetByLogin(ctx context.Context, login string) (*user.User, error)
+	GetAll(ctx context.Context, page, pageSize int) ([]*user.User, int64, error)
+	GetAllByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*user.User, bool, error)
+	Update(ctx context.Context, user *user.User) error
+	Delete(ctx context.Context, id, version uint) error
+}
+
+// TradeRepository определяет интерфейс для работы с обменами
+type TradeRepository interface {
+	Create(ctx context.Context, t *trade.Trade) error
+	CreateCounter(ctx context.Context, original *trade.Trade, counter *trade.Trade) error
+	GetByID(ctx context.Context, id uint) (*trade.Trade, error)
+	GetUserTrades(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Trade, int64, error)
+	CountCompleted(ctx context.Context, userID uint) (int64, error)
+	CountByStatus(ctx context.Context) (map[trade.Status]int64, error)
+	UpdateStatus(ctx context.Context, t *trade.Trade, from trade.Status, change *trade.BookStateChange) error
+	CompleteTrade(ctx context.Context, t *trade.Trade, userID uint, change *trade.BookStateChange) error
+	CreateCycle(ctx context.Context, c *trade.Cycle) error
+	GetCycleByID(ctx context.Context, id uint) (*trade.Cycle, error)
+	GetUserCycles(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Cycle, int64, error)
+	GetPendingCycleBookIDs(ctx context.Context) ([]uint, error)
+	GetCycleKeys(ctx context.Context) ([]string, error)
+	AcceptCycle(ctx context.Context, c *trade.Cycle, userID uint, change *trade.BookStateChange) error
+	CompleteCycle(ctx context.Context, c *trade.Cycle, userID uint, change *trade.BookStateChange) error
+	UpdateCycleStatus(ctx context.Context, c *trade.Cycle, from trade.Status, change *trade.BookStateChange) error
+}
+
+// WishlistRepository определяет интерфейс для работы с вишлистами
+type WishlistRepository interface {
+	CreateItem(ctx context.Context, item *wishlist.Item) error
+	GetItemByID(ctx context.Context, id uint) (*wishlist.Item, error)
+	GetUserItems(ctx context.Context, userID uint) ([]*wishlist.Item, error)
+	GetAllItems(ctx context.Context) ([]*wishlist.Item, error)
+	DeleteItem(ctx context.Context, id uint) error
+	FindCandidates(ctx context.Context, b *book.Book) ([]*wishlist.Item, error)
+	CreateMatches(ctx context.Context, matches []*wishlist.Match) ([]*wishlist.Match, error)
+	GetUserMatches(ctx context.Context, userID uint, page, pageSize int) ([]*wishlist.Match, int64, error)
+	GetMatchUserIDs(ctx context.Context, bookID uint) ([]uint, error)
+}
+
+// ConversationRepository определяет интерфейс для работы с перепиской
+type ConversationRepository interface {
+	Create(ctx context.Context, c *conversation.Conversation, first *conversation.Message) error
+	GetByID(ctx context.Context, id uint) (*conversation.Conversation, error)
+	FindByBook(ctx context.Context, bookID, userID uint) (*conversation.Conversation, error)
+	FindByTrade(ctx context.Context, tradeID uint) (*conversation.Conversation, error)
+	GetUserConversations(ctx context.Context, userID uint, page, pageSize int) ([]*conversation.Conversation, int64, error)
+	GetUnreadTotal(ctx context.Context, userID uint) (int64, error)
+	CreateMessage(ctx context.Context, m *conversation.Message) error
+	GetMessagesByCursor(ctx context.Context, conversationID uint, c *cursor.Cursor, limit int) ([]*conversation.Message, bool, error)
+	MarkRead(ctx context.Context, conversationID, userID uint) error
+}
+
+// NotificationRepository определяет интерфейс для работы с уведомлениями
+type NotificationRepository interface {
+	Create(ctx context.Context, n *notification.Notification) error
+	GetUserNotifications(ctx context.Context, userID uint, unreadOnly bool, page, pageSize int) ([]*notification.Notification, int64, error)
+	GetUnreadAfter(ctx context.Context, userID, afterID uint, limit int) ([]*notification.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID, id uint) error
+	MarkAllRead(ctx context.Context, userID uint) error
+}
+
+// ReviewRepository определяет интерфейс для работы с отзывами
+type ReviewRepository interface {
+	Create(ctx context.Context, r *review.Review) error
+	GetUserReviews(ctx context.Context, userID uint, page, pageSize int) ([]*review.Review, int64, error)
+	GetRating(ctx context.Context, userID uint) (float64, int64, error)
+}
+
+// IdempotencyRepository определяет интерфейс для работы с ключами идемпотентности
+type IdempotencyRepository interface {
+	Create(ctx context.Context, rec *idempotency.Record) (bool, error)
+	Get(ctx context.Context, userID uint, key string) (*idempotency.Record, error)
+	Complete(ctx context.Context, rec *idempotency.Record) error
+	Delete(ctx context.Context, id uint) error
+	DeleteExpired(ctx context.Context) error
 }
 
 // Repository представляет собой фабрику репозиториев
 type Repository struct {
-	User  UserRepository
-	Book  BookRepository
-	Tag   TagRepository
-	State StateRepository
-	Token token.Repository
+	User         UserRepository
+	Book         BookRepository
+	Tag          TagRepository
+	State        StateRepository
+	Trade        TradeRepository
+	Token        token.Repository
+	Wishlist     WishlistRepository
+	Conversation ConversationRepository
+	Notification NotificationRepository
+	Review       ReviewRepository
+	Idempotency  IdempotencyRepository
 }
@@ -6,9 +6,12 @@ type TokenResponse struct {
 	// @Description JWT токен доступа
 	// @example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
 	Token string `json:"token"`
-	// @Description Токен для обновления доступа
-	// @example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	// @Description Одноразовый токен для обновления доступа
+	// @example 8mZ3oQ1q7bQd2vR5w0cJ4kTnY6xE9uHs-AaLpBfGiKo
 	RefreshToken string `json:"refresh_token"`
+	// @Description Время жизни токена доступа в секундах
+	// @example 900
+	ExpiresIn int64 `json:"expires_in"`
 }
 
 // LoginResponse представляет полный ответ при входе пользователя
@@ -17,9 +20,12 @@ type LoginResponse struct {
 	// @Description JWT токен доступа
 	// @example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
 	Token string `json:"token"`
-	// @Description Токен для обновления доступа
-	// @example eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	// @Description Одноразовый токен для обновления доступа
+	// @example 8mZ3oQ1q7bQd2vR5w0cJ4kTnY6xE9uHs-AaLpBfGiKo
 	RefreshToken string `json:"refresh_token"`
+	// @Description Время жизни токена доступа в секундах
+	// @example 900
+	ExpiresIn int64 `json:"expires_in"`
 	// @Description ID пользователя
 	// @example 1
 	UserID uint `json:"user_id"`
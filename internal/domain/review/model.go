@@ -0,0 +1,49 @@
+package review
+
+import (
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/gorm"
+	"errors"
+)
+
+// ErrAlreadyReviewed возвращается при повторном отзыве на тот же обмен
+var ErrAlreadyReviewed = errors.New("trade has already been reviewed by this user")
+
+// Review представляет отзыв участника завершенного обмена о другом участнике
+// @Description Отзыв об участнике обмена
+type Review struct {
+	gorm.Base
+	// @Description ID обмена, по итогам которого оставлен отзыв
+	// @example 1
+	TradeID uint `json:"trade_id" gorm:"not null;uniqueIndex:idx_review_trade_reviewer"`
+	// @Description ID автора отзыва
+	// @example 1
+	ReviewerID uint `json:"reviewer_id" gorm:"not null;uniqueIndex:idx_review_trade_reviewer"`
+	// @Description Информация об авторе отзыва
+	Reviewer *user.User `json:"reviewer,omitempty" gorm:"foreignKey:ReviewerID"`
+	// @Description ID пользователя, о котором оставлен отзыв
+	// @example 2
+	RevieweeID uint `json:"reviewee_id" gorm:"not null;index"`
+	// @Description Оценка от 1 до 5
+	// @example 5
+	Rating int `json:"rating" gorm:"type:tinyint unsigned;not null"`
+	// @Description Комментарий
+	// @example Книга в отличном состоянии, обмен прошел быстро
+	Comment string `json:"comment" gorm:"type:text"`
+}
+
+// TableName указывает имя таблицы для модели Review
+func (Review) TableName() string {
+	return "reviews"
+}
+
+// CreateReviewDTO представляет данные для отзыва об обмене
+// @Description Данные для отзыва о другом участнике завершенного обмена
+type CreateReviewDTO struct {
+	// @Description Оценка от 1 до 5
+	// @example 5
+	Rating int `json:"rating" validate:"required,min=1,max=5"`
+	// @Description Комментарий
+	// @example Книга в отличном состоянии, обмен прошел быстро
+	Comment string `json:"comment" validate:"max=1000"`
+}
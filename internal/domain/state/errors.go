@@ -0,0 +1,14 @@
+package state
+
+import "fmt"
+
+// TransitionError возвращается при попытке перевести книгу в состояние,
+// переход в которое из текущего состояния не разрешен
+type TransitionError struct {
+	FromStateID uint
+	ToStateID   uint
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transition from state %d to state %d is not allowed", e.FromStateID, e.ToStateID)
+}
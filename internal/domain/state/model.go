@@ -11,6 +11,11 @@ type State struct {
 	// @Description Название состояния
 	// @example available
 	Name string `gorm:"size:50;not null;unique" json:"name"`
+	// @Description Разрешенные переходы из этого состояния
+	Transitions []*Transition `gorm:"foreignKey:FromStateID" json:"transitions,omitempty"`
+	// @Description Версия состояния, увеличивается при каждом изменении, в том числе переходов
+	// @example 1
+	Version uint `gorm:"not null;default:1" json:"version"`
 }
 
 // TableName указывает имя таблицы для модели State
@@ -18,6 +23,25 @@ func (State) TableName() string {
 	return "states"
 }
 
+// Transition представляет разрешенный переход книги из одного состояния в другое
+// @Description Модель разрешенного перехода между состояниями книги
+type Transition struct {
+	gorm.Base
+	// @Description ID исходного состояния
+	// @example 1
+	FromStateID uint `gorm:"not null;uniqueIndex:idx_state_transition" json:"from_state_id"`
+	// @Description ID целевого состояния
+	// @example 2
+	ToStateID uint `gorm:"not null;uniqueIndex:idx_state_transition" json:"to_state_id"`
+	// @Description Информация о целевом состоянии
+	ToState *State `gorm:"foreignKey:ToStateID" json:"to_state,omitempty"`
+}
+
+// TableName указывает имя таблицы для модели Transition
+func (Transition) TableName() string {
+	return "state_transitions"
+}
+
 // CreateStateDTO представляет данные, необходимые для создания нового состояния
 // @Description Данные для создания нового состояния
 type CreateStateDTO struct {
@@ -32,4 +56,12 @@ type UpdateStateDTO struct {
 	// @Description Название состояния
 	// @example available
 	Name string `json:"name" validate:"required,min=3,max=50"`
-} 
\ No newline at end of file
+}
+
+// CreateTransitionDTO представляет данные, необходимые для добавления перехода
+// @Description Данные для добавления разрешенного перехода между состояниями
+type CreateTransitionDTO struct {
+	// @Description ID целевого состояния
+	// @example 2
+	ToStateID uint `json:"to_state_id" validate:"required,min=1"`
+}
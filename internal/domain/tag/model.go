@@ -11,9 +11,12 @@ type Tag struct {
 	// @Description Название тега
 	// @example fiction
 	Name string `gorm:"size:255;not null;unique" json:"name"`
-	// @Description Фото тега в формате base64
-	// @example data:image/jpeg;base64,/9j/4AAQSkZJRg...
+	// @Description URL фото тега
+	// @example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b
 	Photo string `gorm:"type:text" json:"photo,omitempty"`
+	// @Description Версия тега, увеличивается при каждом изменении
+	// @example 1
+	Version uint `gorm:"not null;default:1" json:"version"`
 }
 
 // TableName указывает имя таблицы для модели Tag
@@ -5,18 +5,26 @@ import (
 	"time"
 )
 
-// RefreshToken представляет собой модель токена обновления
+// RefreshToken представляет собой модель токена обновления.
+// Сам токен не хранится, в базе лежит только его SHA-256 хеш.
 type RefreshToken struct {
 	gorm.Base
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	UserID    uint      `json:"user_id" gorm:"not null;index"`
-	Token     string    `json:"token" gorm:"type:varchar(255);not null;uniqueIndex"`
-	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	UserID uint `json:"user_id" gorm:"not null;index"`
+	// TokenHash - хеш выданного клиенту токена
+	TokenHash string `json:"-" gorm:"column:token;type:varchar(255);not null;uniqueIndex"`
+	// FamilyID объединяет все токены, полученные ротацией от одного входа в систему
+	FamilyID  string     `json:"family_id" gorm:"type:varchar(64);not null;index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
 }
 
 // TableName указывает имя таблицы для модели RefreshToken
 func (RefreshToken) TableName() string {
 	return "refresh_tokens"
 }
+
+// IsExpired проверяет, истек ли срок действия токена
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
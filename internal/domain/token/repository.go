@@ -1,11 +1,14 @@
 package token
 
-import "time"
+import "context"
 
 type Repository interface {
-	Save(userID uint, token string, expiresAt time.Time) error
-	Validate(token string) (uint, error)
-	Delete(token string) error
-	DeleteExpired() error
-	DeleteUserTokens(userID uint) error
+	Save(ctx context.Context, t *RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// MarkUsed атомарно помечает токен использованным. Возвращает false,
+	// если токен уже был использован или отозван ранее.
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	DeleteExpired(ctx context.Context) error
+	RevokeUserTokens(ctx context.Context, userID uint) error
 }
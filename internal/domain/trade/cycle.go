@@ -0,0 +1,154 @@
+package trade
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/gorm"
+	"time"
+)
+
+// Cycle представляет кольцевой обмен между несколькими пользователями:
+// каждый участник отдает книгу следующему и получает книгу от предыдущего.
+// Обмен принимается, только когда его приняли все участники
+// @Description Кольцевой обмен между несколькими пользователями
+type Cycle struct {
+	gorm.Base
+	// @Description Статус кольцевого обмена
+	// @example pending
+	Status Status `json:"status" gorm:"type:varchar(20);not null;index"`
+	// BookKey - ключ набора книг (см. CycleKey), чтобы не предлагать одно кольцо повторно
+	BookKey string `json:"-" gorm:"type:varchar(255);not null;index"`
+	// @Description Участники обмена и их решения
+	Participants []*CycleParticipant `json:"participants" gorm:"foreignKey:CycleID"`
+	// @Description Книги, передаваемые в рамках обмена
+	Items []*CycleItem `json:"items" gorm:"foreignKey:CycleID"`
+}
+
+// TableName указывает имя таблицы для модели Cycle
+func (Cycle) TableName() string {
+	return "trade_cycles"
+}
+
+// CycleParticipant представляет участника кольцевого обмена
+// @Description Участник кольцевого обмена
+type CycleParticipant struct {
+	// @Description ID записи участника
+	// @example 1
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// @Description ID кольцевого обмена
+	// @example 1
+	CycleID uint `json:"cycle_id" gorm:"not null;uniqueIndex:idx_cycle_participant"`
+	// @Description ID пользователя
+	// @example 2
+	UserID uint `json:"user_id" gorm:"not null;uniqueIndex:idx_cycle_participant;index"`
+	// @Description Информация о пользователе
+	User *user.User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	// @Description Время, когда участник принял обмен
+	// @example 2024-03-20T10:00:00Z
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	// @Description Время, когда участник подтвердил получение книги
+	// @example 2024-03-25T10:00:00Z
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// TableName указывает имя таблицы для модели CycleParticipant
+func (CycleParticipant) TableName() string {
+	return "trade_cycle_participants"
+}
+
+// CycleItem представляет книгу, передаваемую в рамках кольцевого обмена
+// @Description Книга, участвующая в кольцевом обмене
+type CycleItem struct {
+	// @Description ID позиции обмена
+	// @example 1
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// @Description ID кольцевого обмена
+	// @example 1
+	CycleID uint `json:"cycle_id" gorm:"not null;index"`
+	// @Description ID книги. Пусто, если книгу удалили после завершения обмена
+	// @example 1
+	BookID *uint `json:"book_id" gorm:"index;type:int unsigned"`
+	// @Description Информация о книге
+	Book *book.Book `json:"book,omitempty" gorm:"foreignKey:BookID"`
+	// @Description ID пользователя, отдающего книгу
+	// @example 1
+	FromUserID uint `json:"from_user_id" gorm:"not null"`
+	// @Description ID пользователя, получающего книгу
+	// @example 2
+	ToUserID uint `json:"to_user_id" gorm:"not null"`
+}
+
+// TableName указывает имя таблицы для модели CycleItem
+func (CycleItem) TableName() string {
+	return "trade_cycle_items"
+}
+
+// BookIDs возвращает ID всех книг, участвующих в обмене, кроме удаленных
+func (c *Cycle) BookIDs() []uint {
+	ids := make([]uint, 0, len(c.Items))
+	for _, item := range c.Items {
+		if item.BookID != nil {
+			ids = append(ids, *item.BookID)
+		}
+	}
+	return ids
+}
+
+// Participant возвращает участника обмена по ID пользователя
+func (c *Cycle) Participant(userID uint) *CycleParticipant {
+	for _, p := range c.Participants {
+		if p.UserID == userID {
+			return p
+		}
+	}
+	return nil
+}
+
+// IsParticipant проверяет, является ли пользователь участником обмена
+func (c *Cycle) IsParticipant(userID uint) bool {
+	return c.Participant(userID) != nil
+}
+
+// AllAccepted проверяет, приняли ли обмен все участники
+func (c *Cycle) AllAccepted() bool {
+	for _, p := range c.Participants {
+		if p.AcceptedAt == nil {
+			return false
+		}
+	}
+	return len(c.Participants) > 0
+}
+
+// AllCompleted проверяет, подтвердили ли завершение обмена все участники
+func (c *Cycle) AllCompleted() bool {
+	for _, p := range c.Participants {
+		if p.CompletedAt == nil {
+			return false
+		}
+	}
+	return len(c.Participants) > 0
+}
+
+// Want описывает желание пользователя получить книгу другого пользователя:
+// ребро графа обменов от Wisher к Owner
+type Want struct {
+	Wisher uint
+	Owner  uint
+	BookID uint
+}
+
+// NewCycle создает ожидающий кольцевой обмен из цепочки желаний,
+// в которой владелец каждой книги является желающим следующего звена
+func NewCycle(wants []Want) *Cycle {
+	c := &Cycle{Status: StatusPending, BookKey: CycleKey(wants)}
+	for _, w := range wants {
+		bookID := w.BookID
+		c.Participants = append(c.Participants, &CycleParticipant{UserID: w.Wisher})
+		c.Items = append(c.Items, &CycleItem{
+			BookID:     &bookID,
+			FromUserID: w.Owner,
+			ToUserID:   w.Wisher,
+		})
+	}
+	return c
+}
@@ -0,0 +1,142 @@
+package trade
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MinCycleLength - минимальное число участников кольцевого обмена. Обмен между
+// двумя пользователями оформляется обычным предложением обмена, поэтому кольцом
+// не предлагается
+const MinCycleLength = 3
+
+// FindCycles ищет кольца обмена из MinCycleLength..maxLength участников.
+//
+// Желания образуют граф: ребро ведет от желающего к владельцу книги. Кольцо -
+// простой цикл в этом графе, где каждый участник получает книгу от следующего
+// и отдает свою предыдущему. Кольца с ключом из exclude (см. CycleKey) пропускаются,
+// перебор останавливается после limit найденных колец. Из найденных выбираются
+// непересекающиеся по книгам, более короткие первыми: чем меньше участников,
+// тем вероятнее, что обмен состоится
+func FindCycles(wants []Want, maxLength, limit int, exclude map[string]bool) [][]Want {
+	if maxLength < MinCycleLength || limit <= 0 {
+		return nil
+	}
+
+	// Между парой пользователей достаточно одной книги: берем книгу с меньшим ID,
+	// чтобы результат не зависел от порядка желаний
+	edges := make(map[uint]map[uint]Want)
+	for _, w := range wants {
+		if w.Wisher == w.Owner {
+			continue
+		}
+		if edges[w.Wisher] == nil {
+			edges[w.Wisher] = make(map[uint]Want)
+		}
+		if existing, ok := edges[w.Wisher][w.Owner]; !ok || w.BookID < existing.BookID {
+			edges[w.Wisher][w.Owner] = w
+		}
+	}
+
+	users := make([]uint, 0, len(edges))
+	neighbours := make(map[uint][]uint, len(edges))
+	for wisher, owners := range edges {
+		users = append(users, wisher)
+		for owner := range owners {
+			neighbours[wisher] = append(neighbours[wisher], owner)
+		}
+		sort.Slice(neighbours[wisher], func(i, j int) bool { return neighbours[wisher][i] < neighbours[wisher][j] })
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+
+	var found [][]Want
+	onPath := make(map[uint]bool)
+	var path []Want
+
+	// Каждое кольцо перечисляется один раз: начиная с участника с наименьшим ID
+	// и проходя только через участников с большими ID
+	var visit func(start, current uint)
+	visit = func(start, current uint) {
+		for _, next := range neighbours[current] {
+			if len(found) >= limit {
+				return
+			}
+			w := edges[current][next]
+
+			if next == start {
+				if len(path)+1 >= MinCycleLength {
+					cycle := make([]Want, len(path)+1)
+					copy(cycle, path)
+					cycle[len(path)] = w
+					if !exclude[CycleKey(cycle)] {
+						found = append(found, cycle)
+					}
+				}
+				continue
+			}
+			if next < start || onPath[next] || len(path)+1 >= maxLength {
+				continue
+			}
+
+			onPath[next] = true
+			path = append(path, w)
+			visit(start, next)
+			path = path[:len(path)-1]
+			onPath[next] = false
+		}
+	}
+
+	for _, start := range users {
+		if len(found) >= limit {
+			break
+		}
+		onPath[start] = true
+		visit(start, start)
+		onPath[start] = false
+	}
+
+	return selectDisjoint(found)
+}
+
+// selectDisjoint выбирает кольца без общих книг, начиная с самых коротких
+func selectDisjoint(cycles [][]Want) [][]Want {
+	sort.SliceStable(cycles, func(i, j int) bool { return len(cycles[i]) < len(cycles[j]) })
+
+	usedBooks := make(map[uint]bool)
+	var selected [][]Want
+	for _, cycle := range cycles {
+		free := true
+		for _, w := range cycle {
+			if usedBooks[w.BookID] {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+
+		for _, w := range cycle {
+			usedBooks[w.BookID] = true
+		}
+		selected = append(selected, cycle)
+	}
+	return selected
+}
+
+// CycleKey возвращает ключ кольца: отсортированные ID его книг через дефис.
+// Кольца с одинаковым набором книг считаются одним и тем же предложением
+func CycleKey(wants []Want) string {
+	ids := make([]int, 0, len(wants))
+	for _, w := range wants {
+		ids = append(ids, int(w.BookID))
+	}
+	sort.Ints(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, "-")
+}
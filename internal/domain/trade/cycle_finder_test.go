@@ -0,0 +1,187 @@
+package trade
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ring строит кольцо желаний: участник users[i] хочет книгу books[i] следующего
+// участника, последний хочет книгу первого
+func ring(users []uint, books []uint) []Want {
+	wants := make([]Want, len(users))
+	for i := range users {
+		wants[i] = Want{Wisher: users[i], Owner: users[(i+1)%len(users)], BookID: books[i]}
+	}
+	return wants
+}
+
+// cycleKeys возвращает ключи колец в порядке их выбора
+func cycleKeys(cycles [][]Want) []string {
+	var keys []string
+	for _, c := range cycles {
+		keys = append(keys, CycleKey(c))
+	}
+	return keys
+}
+
+func TestFindCycles(t *testing.T) {
+	threeRing := ring([]uint{1, 2, 3}, []uint{20, 30, 10})
+	fourRing := ring([]uint{1, 2, 3, 4}, []uint{20, 30, 40, 10})
+
+	tests := []struct {
+		name      string
+		wants     []Want
+		maxLength int
+		limit     int
+		exclude   map[string]bool
+		want      []string
+	}{
+		{
+			name:      "three participants",
+			wants:     threeRing,
+			maxLength: 4,
+			limit:     10,
+			want:      []string{"10-20-30"},
+		},
+		{
+			name:      "four participants at max length",
+			wants:     fourRing,
+			maxLength: 4,
+			limit:     10,
+			want:      []string{"10-20-30-40"},
+		},
+		{
+			name:      "longer than max length",
+			wants:     fourRing,
+			maxLength: 3,
+			limit:     10,
+		},
+		{
+			// Взаимные желания двух пользователей - обычное предложение обмена
+			name:      "two participants",
+			wants:     ring([]uint{1, 2}, []uint{20, 10}),
+			maxLength: 4,
+			limit:     10,
+		},
+		{
+			name: "overlapping cycles",
+			// Кольца 1-2-3 и 1-2-3-4 делят книги 20 и 30, выбирается более короткое
+			wants: append(ring([]uint{1, 2, 3}, []uint{20, 30, 10}),
+				Want{Wisher: 3, Owner: 4, BookID: 40},
+				Want{Wisher: 4, Owner: 1, BookID: 11},
+			),
+			maxLength: 4,
+			limit:     10,
+			want:      []string{"10-20-30"},
+		},
+		{
+			name: "disjoint cycles",
+			wants: append(ring([]uint{1, 2, 3}, []uint{20, 30, 10}),
+				ring([]uint{4, 5, 6, 7}, []uint{50, 60, 70, 40})...),
+			maxLength: 4,
+			limit:     10,
+			want:      []string{"10-20-30", "40-50-60-70"},
+		},
+		{
+			name:      "excluded cycle",
+			wants:     threeRing,
+			maxLength: 4,
+			limit:     10,
+			exclude:   map[string]bool{"10-20-30": true},
+		},
+		{
+			name:      "own book",
+			wants:     []Want{{Wisher: 1, Owner: 1, BookID: 10}},
+			maxLength: 4,
+			limit:     10,
+		},
+		{
+			name:      "max length below minimum",
+			wants:     threeRing,
+			maxLength: 2,
+			limit:     10,
+		},
+		{
+			name:      "no limit",
+			wants:     threeRing,
+			maxLength: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycles := FindCycles(tt.wants, tt.maxLength, tt.limit, tt.exclude)
+			if got := cycleKeys(cycles); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("FindCycles() = %v, want %v", got, tt.want)
+			}
+
+			// Каждый участник отдает книгу предыдущему: владелец книги звена
+			// является желающим следующего звена
+			for _, c := range cycles {
+				if len(c) < MinCycleLength || len(c) > tt.maxLength {
+					t.Errorf("cycle %v has %d participants, want %d..%d", c, len(c), MinCycleLength, tt.maxLength)
+				}
+				for i, w := range c {
+					if next := c[(i+1)%len(c)]; w.Owner != next.Wisher {
+						t.Errorf("cycle %v is broken at %d", c, i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSelectDisjoint(t *testing.T) {
+	short := ring([]uint{1, 2, 3}, []uint{20, 30, 10})
+	long := ring([]uint{1, 2, 3, 4}, []uint{20, 30, 40, 10})
+	other := ring([]uint{5, 6, 7}, []uint{60, 70, 50})
+
+	tests := []struct {
+		name   string
+		cycles [][]Want
+		want   []string
+	}{
+		{name: "empty"},
+		{name: "shorter first", cycles: [][]Want{long, short}, want: []string{"10-20-30"}},
+		{name: "keeps order of equal length", cycles: [][]Want{other, short}, want: []string{"50-60-70", "10-20-30"}},
+		{name: "all disjoint", cycles: [][]Want{long, other}, want: []string{"50-60-70", "10-20-30-40"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cycleKeys(selectDisjoint(tt.cycles)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectDisjoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCycleKey(t *testing.T) {
+	wants := ring([]uint{1, 2, 3, 4}, []uint{20, 300, 40, 100})
+
+	// Ключ не зависит от того, с какого участника начинается кольцо
+	for i := range wants {
+		rotated := append(append([]Want{}, wants[i:]...), wants[:i]...)
+		if got := CycleKey(rotated); got != "20-40-100-300" {
+			t.Errorf("CycleKey() of rotation %d = %q, want %q", i, got, "20-40-100-300")
+		}
+	}
+
+	tests := []struct {
+		name  string
+		wants []Want
+		want  string
+	}{
+		{name: "empty", want: ""},
+		{name: "numeric order", wants: ring([]uint{1, 2, 3}, []uint{9, 10, 100}), want: "9-10-100"},
+		{name: "other books", wants: ring([]uint{1, 2, 3}, []uint{9, 10, 101}), want: "9-10-101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CycleKey(tt.wants); got != tt.want {
+				t.Errorf("CycleKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
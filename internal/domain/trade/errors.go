@@ -0,0 +1,10 @@
+package trade
+
+import "errors"
+
+var (
+	// ErrBooksUnavailable возвращается, если книги обмена уже не доступны в ожидаемом состоянии
+	ErrBooksUnavailable = errors.New("some books are no longer available for this trade")
+	// ErrStatusChanged возвращается, если статус обмена был изменен параллельным запросом
+	ErrStatusChanged = errors.New("trade status was changed by another request")
+)
@@ -0,0 +1,153 @@
+package trade
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/gorm"
+	"time"
+)
+
+// Status представляет статус предложения обмена
+// @Description Перечисление возможных статусов обмена
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // Ожидает ответа получателя
+	StatusAccepted  Status = "accepted"  // Принято, книги в процессе обмена
+	StatusRejected  Status = "rejected"  // Отклонено получателем
+	StatusCancelled Status = "cancelled" // Отменено одним из участников
+	StatusCountered Status = "countered" // Получатель выдвинул встречное предложение
+	StatusCompleted Status = "completed" // Обмен завершен, книги обменяны
+)
+
+// IsValid проверяет, что статус входит в список известных
+func (s Status) IsValid() bool {
+	switch s {
+	case StatusPending, StatusAccepted, StatusRejected, StatusCancelled, StatusCountered, StatusCompleted:
+		return true
+	}
+	return false
+}
+
+// Trade представляет предложение обмена книгами между двумя пользователями
+// @Description Модель предложения обмена книгами
+type Trade struct {
+	gorm.Base
+	// @Description ID пользователя, предложившего обмен
+	// @example 1
+	ProposerID uint `json:"proposer_id" gorm:"not null;index"`
+	// @Description Информация о пользователе, предложившем обмен
+	Proposer *user.User `json:"proposer,omitempty" gorm:"foreignKey:ProposerID"`
+	// @Description ID пользователя, которому адресовано предложение
+	// @example 2
+	RecipientID uint `json:"recipient_id" gorm:"not null;index"`
+	// @Description Информация о получателе предложения
+	Recipient *user.User `json:"recipient,omitempty" gorm:"foreignKey:RecipientID"`
+	// @Description Статус обмена
+	// @example pending
+	Status Status `json:"status" gorm:"type:varchar(20);not null;index"`
+	// @Description ID предложения, на которое это предложение является встречным
+	// @example 3
+	ParentID *uint `json:"parent_id,omitempty" gorm:"index"`
+	// @Description Сообщение к предложению
+	// @example Меняю на вашу книгу, состояние отличное
+	Message string `json:"message" gorm:"type:text"`
+	// @Description Время, когда предложивший обмен подтвердил получение книг
+	// @example 2024-03-20T10:00:00Z
+	ProposerCompletedAt *time.Time `json:"proposer_completed_at,omitempty"`
+	// @Description Время, когда получатель подтвердил получение книг
+	// @example 2024-03-20T10:00:00Z
+	RecipientCompletedAt *time.Time `json:"recipient_completed_at,omitempty"`
+	// @Description Книги, участвующие в обмене
+	Items []*Item `json:"items" gorm:"foreignKey:TradeID"`
+}
+
+// TableName указывает имя таблицы для модели Trade
+func (Trade) TableName() string {
+	return "trades"
+}
+
+// Item представляет книгу, передаваемую в рамках обмена
+// @Description Книга, участвующая в обмене
+type Item struct {
+	// @Description ID позиции обмена
+	// @example 1
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// @Description ID обмена
+	// @example 1
+	TradeID uint `json:"trade_id" gorm:"not null;index"`
+	// @Description ID книги. Пусто, если книгу удалили после завершения обмена
+	// @example 1
+	BookID *uint `json:"book_id" gorm:"index;type:int unsigned"`
+	// @Description Информация о книге
+	Book *book.Book `json:"book,omitempty" gorm:"foreignKey:BookID"`
+	// @Description ID пользователя, отдающего книгу
+	// @example 1
+	FromUserID uint `json:"from_user_id" gorm:"not null"`
+	// @Description ID пользователя, получающего книгу
+	// @example 2
+	ToUserID uint `json:"to_user_id" gorm:"not null"`
+}
+
+// TableName указывает имя таблицы для модели Item
+func (Item) TableName() string {
+	return "trade_items"
+}
+
+// BookIDs возвращает ID всех книг, участвующих в обмене, кроме удаленных
+func (t *Trade) BookIDs() []uint {
+	ids := make([]uint, 0, len(t.Items))
+	for _, item := range t.Items {
+		if item.BookID != nil {
+			ids = append(ids, *item.BookID)
+		}
+	}
+	return ids
+}
+
+// IsParticipant проверяет, является ли пользователь участником обмена
+func (t *Trade) IsParticipant(userID uint) bool {
+	return t.ProposerID == userID || t.RecipientID == userID
+}
+
+// BothCompleted проверяет, подтвердили ли завершение обмена оба участника
+func (t *Trade) BothCompleted() bool {
+	return t.ProposerCompletedAt != nil && t.RecipientCompletedAt != nil
+}
+
+// BookStateChange описывает перевод книг обмена из одного состояния в другое
+type BookStateChange struct {
+	FromStateID uint
+	ToStateID   uint
+}
+
+// CreateTradeDTO представляет данные для создания предложения обмена
+// @Description Данные для создания предложения обмена
+type CreateTradeDTO struct {
+	// @Description ID пользователя, которому адресовано предложение
+	// @example 2
+	RecipientID uint `json:"recipient_id" validate:"required"`
+	// @Description ID книг предлагающего, отдаваемых в обмен
+	// @example [1, 2]
+	OfferedBookIDs []uint `json:"offered_book_ids" validate:"required,min=1,dive,min=1"`
+	// @Description ID книг получателя, запрашиваемых в обмен
+	// @example [5]
+	RequestedBookIDs []uint `json:"requested_book_ids" validate:"required,min=1,dive,min=1"`
+	// @Description Сообщение к предложению
+	// @example Меняю на вашу книгу, состояние отличное
+	Message string `json:"message" validate:"max=1000"`
+}
+
+// CounterTradeDTO представляет данные встречного предложения
+// @Description Данные для встречного предложения обмена
+type CounterTradeDTO struct {
+	// @Description ID книг отвечающего, отдаваемых в обмен
+	// @example [5, 6]
+	OfferedBookIDs []uint `json:"offered_book_ids" validate:"required,min=1,dive,min=1"`
+	// @Description ID книг инициатора исходного предложения, запрашиваемых в обмен
+	// @example [1]
+	RequestedBookIDs []uint `json:"requested_book_ids" validate:"required,min=1,dive,min=1"`
+	// @Description Сообщение к встречному предложению
+	// @example Могу отдать две книги за одну
+	Message string `json:"message" validate:"max=1000"`
+}
@@ -5,6 +5,25 @@ import (
 	"time"
 )
 
+// Role представляет роль пользователя в системе
+// @Description Перечисление возможных ролей пользователя
+type Role string
+
+const (
+	RoleUser      Role = "user"      // Обычный пользователь
+	RoleModerator Role = "moderator" // Модератор
+	RoleAdmin     Role = "admin"     // Администратор
+)
+
+// IsValid проверяет, что роль входит в список известных
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 // User представляет собой пользователя системы
 // @Description Модель пользователя системы обмена книгами
 type User struct {
@@ -20,17 +39,25 @@ type User struct {
 	Username string `json:"username" gorm:"type:varchar(50);not null"`
 	// @Description Пароль пользователя (не отображается в JSON)
 	Password string `json:"-" gorm:"type:varchar(255);not null"`
-	// @Description Аватар пользователя в формате base64
-	// @example data:image/jpeg;base64,/9j/4AAQSkZJRg...
+	// @Description URL аватара пользователя
+	// @example /media/3a7bd3e2360a3d29eea436fcfb7e44c735d117c42d1c1835420b6b9942dd4f1b
 	Avatar string `json:"avatar,omitempty" gorm:"type:text"`
+	// @Description Роль пользователя
+	// @example user
+	Role Role `json:"role" gorm:"type:varchar(20);not null;default:user"`
 	// @Description Список ID книг пользователя (не сохраняется в БД)
 	BookIDs []uint `json:"book_ids" gorm:"-"`
+	// @Description Репутация пользователя (не сохраняется в БД, возвращается в профиле пользователя)
+	Reputation *Reputation `json:"reputation,omitempty" gorm:"-"`
 	// @Description Дата создания
 	// @example 2024-03-20T10:00:00Z
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_users_created_at"`
 	// @Description Дата обновления
 	// @example 2024-03-20T10:00:00Z
 	UpdatedAt time.Time `json:"updated_at"`
+	// @Description Версия пользователя, увеличивается при каждом изменении
+	// @example 2
+	Version uint `json:"version" gorm:"not null;default:1"`
 }
 
 // TableName указывает имя таблицы для модели User
@@ -38,6 +65,20 @@ func (User) TableName() string {
 	return "users"
 }
 
+// Reputation содержит сводку отзывов о пользователе и число его завершенных обменов
+// @Description Репутация пользователя
+type Reputation struct {
+	// @Description Средняя оценка по отзывам, 0 если отзывов нет
+	// @example 4.5
+	Rating float64 `json:"rating"`
+	// @Description Число полученных отзывов
+	// @example 12
+	ReviewCount int64 `json:"review_count"`
+	// @Description Число завершенных обменов
+	// @example 15
+	CompletedTrades int64 `json:"completed_trades"`
+}
+
 // CreateUserDTO представляет данные для создания пользователя
 // @Description Данные для создания нового пользователя
 type CreateUserDTO struct {
@@ -63,6 +104,14 @@ type UpdateUserDTO struct {
 	Avatar string `json:"avatar,omitempty" binding:"omitempty"`
 }
 
+// UpdateUserRoleDTO представляет данные для изменения роли пользователя
+// @Description Данные для изменения роли пользователя
+type UpdateUserRoleDTO struct {
+	// @Description Новая роль пользователя
+	// @example moderator
+	Role Role `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
 // LoginDTO представляет данные для входа пользователя
 // @Description Данные для входа в систему
 type LoginDTO struct {
@@ -80,6 +129,7 @@ func (dto *CreateUserDTO) ToUser() *User {
 		Login:    dto.Login,
 		Username: dto.Username,
 		Password: dto.Password,
+		Role:     RoleUser,
 	}
 }
 
@@ -0,0 +1,129 @@
+package wishlist
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/tag"
+	"booktrading/internal/pkg/gorm"
+	"strings"
+	"time"
+)
+
+// Item представляет пожелание пользователя: книгу, которую он хочет получить.
+// Заданные критерии должны выполняться одновременно, пустые критерии не проверяются
+// @Description Пожелание из вишлиста пользователя
+type Item struct {
+	gorm.Base
+	// @Description ID пользователя, которому принадлежит пожелание
+	// @example 1
+	UserID uint `json:"user_id" gorm:"not null;index"`
+	// @Description Часть названия книги (без учета регистра)
+	// @example Война и мир
+	Title string `json:"title" gorm:"type:varchar(255);not null;default:''"`
+	// @Description Часть имени автора (без учета регистра)
+	// @example Толстой
+	Author string `json:"author" gorm:"type:varchar(255);not null;default:''"`
+	// @Description ISBN книги без дефисов
+	// @example 9785170906307
+	ISBN string `json:"isbn,omitempty" gorm:"type:varchar(13);not null;default:'';index"`
+	// @Description Теги, которые должны быть у книги (все одновременно)
+	Tags []*tag.Tag `json:"tags" gorm:"many2many:wishlist_item_tags"`
+}
+
+// TableName указывает имя таблицы для модели Item
+func (Item) TableName() string {
+	return "wishlist_items"
+}
+
+// Matches проверяет, подходит ли книга под пожелание
+func (i *Item) Matches(b *book.Book) bool {
+	if i.ISBN != "" && i.ISBN != b.ISBN {
+		return false
+	}
+	if i.Title != "" && !containsFold(b.Title, i.Title) {
+		return false
+	}
+	if i.Author != "" && !containsFold(b.Author, i.Author) {
+		return false
+	}
+
+	bookTags := make(map[uint]bool, len(b.Tags))
+	for _, t := range b.Tags {
+		bookTags[t.ID] = true
+	}
+	for _, t := range i.Tags {
+		if !bookTags[t.ID] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsFold проверяет вхождение подстроки без учета регистра
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// Match представляет книгу, найденную по пожеланию
+// @Description Совпадение книги с пожеланием из вишлиста
+type Match struct {
+	// @Description ID совпадения
+	// @example 1
+	ID uint `json:"id" gorm:"primaryKey;autoIncrement"`
+	// @Description ID пожелания
+	// @example 1
+	ItemID uint `json:"item_id" gorm:"not null;uniqueIndex:idx_wishlist_match"`
+	// @Description Пожелание, по которому найдена книга
+	Item *Item `json:"item,omitempty" gorm:"foreignKey:ItemID"`
+	// @Description ID книги
+	// @example 1
+	BookID uint `json:"book_id" gorm:"not null;uniqueIndex:idx_wishlist_match;index;type:int unsigned"`
+	// @Description Найденная книга
+	Book *book.Book `json:"book,omitempty" gorm:"foreignKey:BookID"`
+	// @Description ID пользователя, которому принадлежит пожелание
+	// @example 1
+	UserID uint `json:"user_id" gorm:"not null;index"`
+	// @Description Дата совпадения
+	// @example 2024-03-20T10:00:00Z
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName указывает имя таблицы для модели Match
+func (Match) TableName() string {
+	return "wishlist_matches"
+}
+
+// CreateItemDTO представляет данные для создания пожелания
+// @Description Данные для добавления книги в вишлист. Нужно указать хотя бы один критерий
+type CreateItemDTO struct {
+	// @Description Часть названия книги
+	// @example Война и мир
+	Title string `json:"title" validate:"max=255"`
+	// @Description Часть имени автора
+	// @example Толстой
+	Author string `json:"author" validate:"max=255"`
+	// @Description ISBN книги, дефисы допускаются
+	// @example 978-5-17-090630-7
+	ISBN string `json:"isbn" validate:"omitempty,isbn_code"`
+	// @Description ID тегов, которые должны быть у книги
+	// @example [1, 2]
+	TagIDs []uint `json:"tag_ids" validate:"omitempty,dive,min=1"`
+}
+
+// HasCriteria проверяет, что задан хотя бы один критерий поиска
+func (dto *CreateItemDTO) HasCriteria() bool {
+	return strings.TrimSpace(dto.Title) != "" ||
+		strings.TrimSpace(dto.Author) != "" ||
+		dto.ISBN != "" ||
+		len(dto.TagIDs) > 0
+}
+
+// ToItem преобразует DTO в модель Item
+func (dto *CreateItemDTO) ToItem(userID uint) *Item {
+	return &Item{
+		UserID: userID,
+		Title:  strings.TrimSpace(dto.Title),
+		Author: strings.TrimSpace(dto.Author),
+		ISBN:   book.NormalizeISBN(dto.ISBN),
+	}
+}
@@ -1,157 +1,94 @@
 package cache
 
 import (
-	"strings"
-	"sync"
+	"booktrading/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
-)
-
-// Cache представляет собой простой in-memory кеш
-type Cache struct {
-	mu    sync.RWMutex
-	items map[string]item
-	stop  chan struct{}
-}
-
-type item struct {
-	value      interface{}
-	expiration time.Time
-}
-
-// NewCache создает новый экземпляр кеша
-func NewCache() *Cache {
-	c := &Cache{
-		items: make(map[string]item),
-		stop:  make(chan struct{}),
-	}
-
-	// Запускаем горутину для очистки устаревших записей
-	go c.startCleanup()
-
-	return c
-}
-
-// startCleanup запускает периодическую очистку устаревших записей
-func (c *Cache) startCleanup() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
 
-	for {
-		select {
-		case <-ticker.C:
-			c.cleanup()
-		case <-c.stop:
-			return
-		}
-	}
-}
-
-// cleanup удаляет все устаревшие записи
-func (c *Cache) cleanup() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	now := time.Now()
-	for k, v := range c.items {
-		if now.After(v.expiration) {
-			delete(c.items, k)
-		}
-	}
-}
-
-// Set сохраняет значение в кеше
-func (c *Cache) Set(key string, value interface{}, duration time.Duration) {
-	if c == nil {
-		return
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.items[key] = item{
-		value:      value,
-		expiration: time.Now().Add(duration),
-	}
-}
-
-// Get получает значение из кеша
-func (c *Cache) Get(key string) (interface{}, bool) {
-	if c == nil {
-		return nil, false
-	}
-
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	item, found := c.items[key]
-	if !found {
-		return nil, false
-	}
+	"github.com/redis/go-redis/v9"
+)
 
-	if time.Now().After(item.expiration) {
-		delete(c.items, key)
-		return nil, false
-	}
+const (
+	// DriverMemory хранит значения в памяти процесса. Подходит для одного экземпляра сервиса
+	DriverMemory = "memory"
+	// DriverRedis хранит значения в Redis, общем для всех экземпляров сервиса
+	DriverRedis = "redis"
+)
 
-	return item.value, true
+// ErrClosed возвращается проверкой здоровья закрытого кеша
+var ErrClosed = errors.New("cache is closed")
+
+// Cache - хранилище сериализованных значений с ограниченным сроком жизни.
+// Значение можно пометить тегами и затем удалить все значения с тегом сразу
+type Cache interface {
+	// Get возвращает значение по ключу. found равен false, если значения нет или срок его жизни истек
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set сохраняет значение на время ttl и помечает его тегами tags.
+	// При ttl <= 0 используется срок жизни по умолчанию из конфигурации
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	// Delete удаляет значения по ключам
+	Delete(ctx context.Context, keys ...string) error
+	// DeletePattern удаляет все значения, ключ которых содержит pattern
+	DeletePattern(ctx context.Context, pattern string) error
+	// InvalidateTags удаляет все значения, помеченные хотя бы одним из тегов
+	InvalidateTags(ctx context.Context, tags ...string) error
+	// Stats возвращает счетчики обращений к кешу
+	Stats() Stats
+	// Ping проверяет доступность кеша
+	Ping(ctx context.Context) error
+	// Close освобождает ресурсы кеша
+	Close() error
 }
 
-// Delete удаляет значение из кеша
-func (c *Cache) Delete(key string) {
-	if c == nil {
-		return
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	delete(c.items, key)
+// Stats содержит счетчики обращений к кешу с момента его создания
+type Stats struct {
+	// Hits - число найденных значений
+	Hits uint64
+	// Misses - число обращений к отсутствующим или устаревшим значениям
+	Misses uint64
+	// Evictions - число записей, удаленных по истечении срока жизни
+	Evictions uint64
+	// Items - текущее число записей
+	Items int
 }
 
-// DeletePattern удаляет все значения, соответствующие паттерну
-func (c *Cache) DeletePattern(pattern string) {
-	if c == nil {
-		return
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	for k := range c.items {
-		if strings.Contains(k, pattern) {
-			delete(c.items, k)
-		}
+// New создает кеш, выбранный в конфигурации
+func New(cfg *config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case DriverMemory:
+		return NewMemory(cfg.TTL, cfg.CleanupInterval), nil
+	case DriverRedis:
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		return NewRedis(client, cfg.RedisKeyPrefix, cfg.TTL), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q", cfg.Driver)
 	}
 }
 
-// Flush очищает весь кеш
-func (c *Cache) Flush() {
-	if c == nil {
-		return
+// GetValue читает из кеша значение по ключу и десериализует его из JSON в T
+func GetValue[T any](ctx context.Context, c Cache, key string) (value T, found bool, err error) {
+	data, found, err := c.Get(ctx, key)
+	if err != nil || !found {
+		return value, false, err
 	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.items = make(map[string]item)
-}
-
-// ItemCount возвращает количество элементов в кеше
-func (c *Cache) ItemCount() int {
-	if c == nil {
-		return 0
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false, fmt.Errorf("failed to decode cached value %s: %w", key, err)
 	}
-
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return len(c.items)
+	return value, true, nil
 }
 
-// Stop останавливает очистку кеша
-func (c *Cache) Stop() {
-	if c == nil {
-		return
+// SetValue сериализует значение в JSON и сохраняет его в кеше на время ttl
+func SetValue(ctx context.Context, c Cache, key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value for cache %s: %w", key, err)
 	}
-	close(c.stop)
+	return c.Set(ctx, key, data, ttl, tags...)
 }
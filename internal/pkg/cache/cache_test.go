@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis создает кеш в Redis поверх miniredis, который останавливается вместе с тестом
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	c := NewRedis(redis.NewClient(&redis.Options{Addr: server.Addr()}), "test:", time.Minute)
+	t.Cleanup(func() { c.Close() })
+	return c, server
+}
+
+// backends возвращает все реализации Cache, которые должны вести себя одинаково
+func backends(t *testing.T) map[string]Cache {
+	t.Helper()
+	memory := NewMemory(time.Minute, time.Minute)
+	t.Cleanup(func() { memory.Close() })
+	redisCache, _ := newTestRedis(t)
+	return map[string]Cache{
+		DriverMemory: memory,
+		DriverRedis:  redisCache,
+	}
+}
+
+// seed записывает значения с тегами: ключ -> теги
+func seed(t *testing.T, c Cache, items map[string][]string) {
+	t.Helper()
+	for key, tags := range items {
+		if err := c.Set(context.Background(), key, []byte(key), 0, tags...); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+}
+
+// present возвращает отсортированные ключи, значения которых есть в кеше
+func present(t *testing.T, c Cache, keys []string) []string {
+	t.Helper()
+	var found []string
+	for _, key := range keys {
+		value, ok, err := c.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", key, err)
+		}
+		if ok {
+			if string(value) != key {
+				t.Errorf("Get(%s) = %q, want %q", key, value, key)
+			}
+			found = append(found, key)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	items := map[string][]string{
+		"book:1":          {"book:1", "user:1"},
+		"book:2":          {"book:2", "user:2", "tag:5"},
+		"books:page:1":    {"books"},
+		"books:tags:5":    {"books", "tag:5"},
+		"tags:popular":    {"tags"},
+		"user:1:books:*[": nil,
+	}
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	tests := []struct {
+		name       string
+		invalidate func(ctx context.Context, c Cache) error
+		want       []string
+	}{
+		{
+			name:       "nothing",
+			invalidate: func(ctx context.Context, c Cache) error { return nil },
+			want:       []string{"book:1", "book:2", "books:page:1", "books:tags:5", "tags:popular", "user:1:books:*["},
+		},
+		{
+			name:       "delete keys",
+			invalidate: func(ctx context.Context, c Cache) error { return c.Delete(ctx, "book:1", "tags:popular", "missing") },
+			want:       []string{"book:2", "books:page:1", "books:tags:5", "user:1:books:*["},
+		},
+		{
+			name:       "delete pattern",
+			invalidate: func(ctx context.Context, c Cache) error { return c.DeletePattern(ctx, "books:") },
+			want:       []string{"book:1", "book:2", "tags:popular"},
+		},
+		{
+			name:       "delete pattern with glob characters",
+			invalidate: func(ctx context.Context, c Cache) error { return c.DeletePattern(ctx, "*[") },
+			want:       []string{"book:1", "book:2", "books:page:1", "books:tags:5", "tags:popular"},
+		},
+		{
+			name:       "invalidate one tag",
+			invalidate: func(ctx context.Context, c Cache) error { return c.InvalidateTags(ctx, "tag:5") },
+			want:       []string{"book:1", "books:page:1", "tags:popular", "user:1:books:*["},
+		},
+		{
+			name:       "invalidate several tags",
+			invalidate: func(ctx context.Context, c Cache) error { return c.InvalidateTags(ctx, "user:1", "books") },
+			want:       []string{"book:2", "tags:popular", "user:1:books:*["},
+		},
+		{
+			name:       "invalidate unknown tag",
+			invalidate: func(ctx context.Context, c Cache) error { return c.InvalidateTags(ctx, "tag:404") },
+			want:       []string{"book:1", "book:2", "books:page:1", "books:tags:5", "tags:popular", "user:1:books:*["},
+		},
+	}
+
+	for _, tt := range tests {
+		for name, c := range backends(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				seed(t, c, items)
+
+				if err := tt.invalidate(ctx, c); err != nil {
+					t.Fatalf("invalidate error = %v", err)
+				}
+
+				got := present(t, c, keys)
+				if len(got) != len(tt.want) {
+					t.Fatalf("present keys = %v, want %v", got, tt.want)
+				}
+				for i := range got {
+					if got[i] != tt.want[i] {
+						t.Fatalf("present keys = %v, want %v", got, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCacheSetReplacesTags(t *testing.T) {
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed(t, c, map[string][]string{"book:1": {"tag:1"}})
+			seed(t, c, map[string][]string{"book:1": {"tag:2"}})
+
+			if err := c.InvalidateTags(ctx, "tag:2"); err != nil {
+				t.Fatalf("InvalidateTags() error = %v", err)
+			}
+			if got := present(t, c, []string{"book:1"}); len(got) != 0 {
+				t.Errorf("value is still cached after invalidating its new tag")
+			}
+		})
+	}
+}
+
+func TestGetSetValue(t *testing.T) {
+	type book struct {
+		ID    uint     `json:"id"`
+		Title string   `json:"title"`
+		Tags  []string `json:"tags"`
+	}
+
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			want := book{ID: 1, Title: "Dune", Tags: []string{"sci-fi"}}
+			if err := SetValue(ctx, c, "book:1", want, 0); err != nil {
+				t.Fatalf("SetValue() error = %v", err)
+			}
+
+			got, found, err := GetValue[book](ctx, c, "book:1")
+			if err != nil || !found {
+				t.Fatalf("GetValue() = %v, %v, want found", found, err)
+			}
+			if got.ID != want.ID || got.Title != want.Title || len(got.Tags) != 1 || got.Tags[0] != "sci-fi" {
+				t.Errorf("GetValue() = %+v, want %+v", got, want)
+			}
+
+			if _, found, err := GetValue[book](ctx, c, "book:2"); err != nil || found {
+				t.Errorf("GetValue() of missing key = %v, %v, want not found", found, err)
+			}
+
+			if err := c.Set(ctx, "broken", []byte("{"), 0); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if _, found, err := GetValue[book](ctx, c, "broken"); err == nil || found {
+				t.Errorf("GetValue() of invalid JSON = %v, %v, want error", found, err)
+			}
+
+			stats := c.Stats()
+			if stats.Hits != 2 || stats.Misses != 1 {
+				t.Errorf("Stats() = %+v, want 2 hits and 1 miss", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"booktrading/internal/config"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// Loader читает значения из кеша и при промахе загружает их из источника:
+//   - одновременные промахи по одному ключу выполняют одну загрузку, остальные запросы
+//     ждут ее результат, поэтому после инвалидации база получает один запрос, а не сотни
+//   - срок свежести случайно отклоняется на TTLJitter, чтобы записи, созданные вместе,
+//     не устаревали вместе
+//   - в течение StaleTTL после устаревания запись еще отдается, а новое значение
+//     загружается в фоне
+//   - отсутствие записи (ошибка notFound) кешируется на NegativeTTL
+//   - загрузка, начатая до инвалидации, не записывает свой результат в кеш:
+//     он мог быть прочитан до изменения
+//
+// Loader встраивает Cache, поэтому запись и инвалидация выполняются через него же.
+// Инвалидация учитывается, только если выполняется через Loader
+type Loader struct {
+	Cache
+	ttl         time.Duration
+	staleTTL    time.Duration
+	negativeTTL time.Duration
+	jitter      float64
+	loadTimeout time.Duration
+	notFound    error
+	group       singleflight.Group
+	// generation увеличивается при каждой инвалидации через Loader
+	generation atomic.Uint64
+}
+
+// LoadFunc загружает значение из источника и возвращает зависимости, которыми помечается
+// запись кеша, например теги книги
+type LoadFunc[T any] func(ctx context.Context) (value T, deps []string, err error)
+
+// entry - запись кеша вместе со сроком свежести значения
+type entry[T any] struct {
+	Value      T         `json:"v"`
+	FreshUntil time.Time `json:"f"`
+	// NotFound помечает закешированное отсутствие значения
+	NotFound bool `json:"nf,omitempty"`
+}
+
+// NewLoader создает Loader поверх кеша c. Ошибки загрузки, для которых
+// errors.Is(err, notFound), кешируются как отсутствие значения
+func NewLoader(c Cache, cfg *config.CacheConfig, notFound error) *Loader {
+	return &Loader{
+		Cache:       c,
+		ttl:         cfg.TTL,
+		staleTTL:    cfg.StaleTTL,
+		negativeTTL: cfg.NegativeTTL,
+		jitter:      cfg.TTLJitter,
+		loadTimeout: cfg.LoadTimeout,
+		notFound:    notFound,
+	}
+}
+
+// Load возвращает значение по ключу из кеша или загружает его функцией load.
+// Запись помечается зависимостями deps и зависимостями, которые вернула load.
+// deps сохраняются и для закешированного отсутствия значения: например, зависимость
+// от книги по ID сбросит запись, когда книга с этим ID появится
+func Load[T any](ctx context.Context, l *Loader, key string, load LoadFunc[T], deps ...string) (T, error) {
+	ctx, span := tracing.Start(ctx, "cache.Load", trace.WithAttributes(attribute.String("cache.key", key)))
+	defer span.End()
+
+	var zero T
+	e, found, err := GetValue[entry[T]](ctx, l.Cache, key)
+	if err != nil {
+		// Ошибка кеша считается промахом: значение будет загружено из источника
+		span.RecordError(err)
+		logger.FromContext(ctx).Error("Failed to read cache", err, "key", key)
+	}
+	if found {
+		stale := time.Now().After(e.FreshUntil)
+		span.SetAttributes(attribute.Bool("cache.hit", true), attribute.Bool("cache.stale", stale))
+		if stale {
+			// Результат фоновой загрузки никто не ждет: она только обновит запись
+			l.group.DoChan(key, func() (interface{}, error) {
+				return fill(ctx, l, key, load, deps)
+			})
+		}
+		if e.NotFound {
+			return zero, l.notFound
+		}
+		return e.Value, nil
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+
+	ch := l.group.DoChan(key, func() (interface{}, error) {
+		return fill(ctx, l, key, load, deps)
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		// Результат загрузки общий для всех ожидавших ее запросов, поэтому каждый
+		// получает свою копию значения и может ее изменять
+		var value T
+		if err := json.Unmarshal(res.Val.(json.RawMessage), &value); err != nil {
+			return zero, fmt.Errorf("failed to decode loaded value %s: %w", key, err)
+		}
+		return value, nil
+	}
+}
+
+// fill загружает значение, сохраняет его в кеше и возвращает сериализованным.
+// Загрузка общая для всех ожидающих ее запросов, поэтому не отменяется вместе
+// с запросом, который ее начал
+func fill[T any](ctx context.Context, l *Loader, key string, load LoadFunc[T], deps []string) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.loadTimeout)
+	defer cancel()
+
+	generation := l.generation.Load()
+	value, valueDeps, err := load(ctx)
+	tags := append(valueDeps, deps...)
+
+	switch {
+	case err == nil:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode value for cache %s: %w", key, err)
+		}
+		ttl := l.jittered(l.ttl)
+		l.store(ctx, generation, key, entry[json.RawMessage]{Value: data, FreshUntil: time.Now().Add(ttl)}, ttl+l.staleTTL, tags)
+		return data, nil
+	case l.notFound != nil && l.negativeTTL > 0 && errors.Is(err, l.notFound):
+		ttl := l.jittered(l.negativeTTL)
+		l.store(ctx, generation, key, entry[json.RawMessage]{NotFound: true, FreshUntil: time.Now().Add(ttl)}, ttl, tags)
+	}
+	return nil, err
+}
+
+// store сохраняет запись в кеше, если с начала загрузки (generation) не было
+// инвалидации. Ошибка кеша только логируется: значение уже загружено
+func (l *Loader) store(ctx context.Context, generation uint64, key string, value interface{}, ttl time.Duration, tags []string) {
+	if l.generation.Load() != generation {
+		return
+	}
+	if err := SetValue(ctx, l.Cache, key, value, ttl, tags...); err != nil {
+		logger.FromContext(ctx).Error("Failed to write cache", err, "key", key)
+	}
+}
+
+// jittered случайно отклоняет ttl не более чем на долю jitter в обе стороны
+func (l *Loader) jittered(ttl time.Duration) time.Duration {
+	if l.jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration((rand.Float64()*2-1)*l.jitter*float64(ttl))
+}
+
+// Delete удаляет значения по ключам. Загрузки, начатые до удаления, не запишут
+// свои результаты
+func (l *Loader) Delete(ctx context.Context, keys ...string) error {
+	l.generation.Add(1)
+	return l.Cache.Delete(ctx, keys...)
+}
+
+// DeletePattern удаляет значения по шаблону ключа так же, как Delete
+func (l *Loader) DeletePattern(ctx context.Context, pattern string) error {
+	l.generation.Add(1)
+	return l.Cache.DeletePattern(ctx, pattern)
+}
+
+// InvalidateTags удаляет значения с тегами так же, как Delete
+func (l *Loader) InvalidateTags(ctx context.Context, tags ...string) error {
+	l.generation.Add(1)
+	return l.Cache.InvalidateTags(ctx, tags...)
+}
@@ -0,0 +1,284 @@
+package cache
+
+import (
+	"booktrading/internal/config"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errTestNotFound = errors.New("not found")
+
+type testBook struct {
+	ID   uint     `json:"id"`
+	Tags []string `json:"tags"`
+}
+
+// newTestLoader создает Loader поверх in-memory кеша без отклонения TTL
+func newTestLoader(t *testing.T, cfg config.CacheConfig) (*Loader, *Memory) {
+	t.Helper()
+	if cfg.TTL == 0 {
+		cfg.TTL = time.Minute
+	}
+	if cfg.LoadTimeout == 0 {
+		cfg.LoadTimeout = time.Second
+	}
+	memory := NewMemory(time.Minute, time.Hour)
+	t.Cleanup(func() { memory.Close() })
+	return NewLoader(memory, &cfg, errTestNotFound), memory
+}
+
+// counting возвращает LoadFunc, которая считает вызовы и возвращает результат result
+func counting[T any](calls *atomic.Int32, result func(n int32) (T, error), deps ...string) LoadFunc[T] {
+	return func(ctx context.Context) (T, []string, error) {
+		n := calls.Add(1)
+		value, err := result(n)
+		return value, deps, err
+	}
+}
+
+func TestLoadCoalescesConcurrentMisses(t *testing.T) {
+	l, _ := newTestLoader(t, config.CacheConfig{})
+	const callers = 20
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func(ctx context.Context) (testBook, []string, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return testBook{ID: 1, Tags: []string{"sci-fi", "classic"}}, []string{"book:1"}, nil
+	}
+
+	results := make([]testBook, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], errs[0] = Load(context.Background(), l, "book:1", load)
+	}()
+	<-started
+	for i := 1; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = Load(context.Background(), l, "book:1", load)
+		}(i)
+	}
+	// Даем остальным запросам дождаться начатой загрузки. Опоздавшие найдут
+	// значение в кеше, поэтому загрузка все равно должна быть одна
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("load called %d times, want 1", n)
+	}
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatalf("Load() caller %d error = %v", i, errs[i])
+		}
+		if results[i].ID != 1 || len(results[i].Tags) != 2 {
+			t.Fatalf("Load() caller %d = %+v, want loaded book", i, results[i])
+		}
+	}
+
+	// Каждый запрос получил свою копию: изменение одной не видно в остальных
+	results[0].Tags[0] = "changed"
+	for i := 1; i < callers; i++ {
+		if results[i].Tags[0] != "sci-fi" {
+			t.Fatalf("caller %d sees a change made by caller 0: %v", i, results[i].Tags)
+		}
+	}
+	cached, err := Load(context.Background(), l, "book:1", load)
+	if err != nil || cached.Tags[0] != "sci-fi" {
+		t.Errorf("cached value = %+v, %v, want value unaffected by callers", cached, err)
+	}
+}
+
+func TestLoadSkipsWriteAfterInvalidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(ctx context.Context, l *Loader) error
+	}{
+		{
+			name:       "delete",
+			invalidate: func(ctx context.Context, l *Loader) error { return l.Delete(ctx, "book:1") },
+		},
+		{
+			name:       "delete pattern",
+			invalidate: func(ctx context.Context, l *Loader) error { return l.DeletePattern(ctx, "books:") },
+		},
+		{
+			name:       "invalidate unrelated tag",
+			invalidate: func(ctx context.Context, l *Loader) error { return l.InvalidateTags(ctx, "tag:5") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, memory := newTestLoader(t, config.CacheConfig{})
+			ctx := context.Background()
+
+			// Значение прочитано из базы до изменения, которое инвалидирует кеш
+			var calls atomic.Int32
+			load := func(ctx context.Context) (testBook, []string, error) {
+				n := calls.Add(1)
+				if n == 1 {
+					if err := tt.invalidate(ctx, l); err != nil {
+						t.Errorf("invalidate error = %v", err)
+					}
+				}
+				return testBook{ID: uint(n)}, nil, nil
+			}
+
+			got, err := Load(ctx, l, "book:1", load)
+			if err != nil || got.ID != 1 {
+				t.Fatalf("Load() = %+v, %v, want the loaded value", got, err)
+			}
+			if memory.ItemCount() != 0 {
+				t.Fatalf("value loaded before invalidation was written to the cache")
+			}
+
+			got, err = Load(ctx, l, "book:1", load)
+			if err != nil || got.ID != 2 {
+				t.Fatalf("second Load() = %+v, %v, want a fresh load", got, err)
+			}
+			if memory.ItemCount() != 1 {
+				t.Errorf("value loaded after invalidation was not cached")
+			}
+		})
+	}
+}
+
+func TestLoadCachesResults(t *testing.T) {
+	errDatabase := errors.New("database is down")
+
+	tests := []struct {
+		name        string
+		negativeTTL time.Duration
+		result      func(n int32) (testBook, error)
+		wantErr     error
+		wantCalls   int32
+	}{
+		{
+			name:      "value",
+			result:    func(n int32) (testBook, error) { return testBook{ID: 1}, nil },
+			wantCalls: 1,
+		},
+		{
+			name:        "not found",
+			negativeTTL: time.Minute,
+			result:      func(n int32) (testBook, error) { return testBook{}, errTestNotFound },
+			wantErr:     errTestNotFound,
+			wantCalls:   1,
+		},
+		{
+			name:      "not found without negative caching",
+			result:    func(n int32) (testBook, error) { return testBook{}, errTestNotFound },
+			wantErr:   errTestNotFound,
+			wantCalls: 3,
+		},
+		{
+			name:        "other errors are not cached",
+			negativeTTL: time.Minute,
+			result:      func(n int32) (testBook, error) { return testBook{}, errDatabase },
+			wantErr:     errDatabase,
+			wantCalls:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLoader(t, config.CacheConfig{NegativeTTL: tt.negativeTTL})
+			var calls atomic.Int32
+			load := counting(&calls, tt.result, "book:1")
+
+			for i := 0; i < 3; i++ {
+				if _, err := Load(context.Background(), l, "book:1", load); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() #%d error = %v, want %v", i, err, tt.wantErr)
+				}
+			}
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Errorf("load called %d times, want %d", n, tt.wantCalls)
+			}
+
+			// Запись сбрасывается по зависимости, которую вернула загрузка
+			if err := l.InvalidateTags(context.Background(), "book:1"); err != nil {
+				t.Fatalf("InvalidateTags() error = %v", err)
+			}
+			Load(context.Background(), l, "book:1", load)
+			if n := calls.Load(); n != tt.wantCalls+1 {
+				t.Errorf("load called %d times after invalidation, want %d", n, tt.wantCalls+1)
+			}
+		})
+	}
+}
+
+func TestLoadServesStaleWhileRevalidating(t *testing.T) {
+	l, _ := newTestLoader(t, config.CacheConfig{TTL: 10 * time.Millisecond, StaleTTL: time.Minute})
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	load := counting(&calls, func(n int32) (testBook, error) { return testBook{ID: uint(n)}, nil })
+
+	if got, err := Load(ctx, l, "book:1", load); err != nil || got.ID != 1 {
+		t.Fatalf("Load() = %+v, %v, want first value", got, err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// Устаревшее значение отдается сразу, новое загружается в фоне
+	if got, err := Load(ctx, l, "book:1", load); err != nil || got.ID != 1 {
+		t.Fatalf("Load() of stale value = %+v, %v, want stale value", got, err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, err := Load(ctx, l, "book:1", load)
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got.ID == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Load() = %+v, the value was not refreshed in the background", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLoadWaiterCancellation(t *testing.T) {
+	l, memory := newTestLoader(t, config.CacheConfig{})
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	load := func(ctx context.Context) (testBook, []string, error) {
+		defer close(done)
+		<-release
+		// Загрузка общая, поэтому не отменяется вместе с начавшим ее запросом
+		return testBook{ID: 1}, nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Load(ctx, l, "book:1", load); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Load() error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	<-done
+	deadline := time.Now().Add(time.Second)
+	for memory.ItemCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("load was cancelled together with the request that started it")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,213 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Memory представляет собой простой in-memory кеш. Каждый экземпляр сервиса
+// хранит и инвалидирует только свои записи
+type Memory struct {
+	mu         sync.RWMutex
+	items      map[string]item
+	tags       map[string]map[string]struct{}
+	defaultTTL time.Duration
+	stop       chan struct{}
+	stopOnce   sync.Once
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+type item struct {
+	value      []byte
+	expiration time.Time
+	tags       []string
+}
+
+// NewMemory создает новый экземпляр in-memory кеша, удаляющего устаревшие записи
+// каждые cleanupInterval. defaultTTL используется, если при записи срок жизни не задан
+func NewMemory(defaultTTL, cleanupInterval time.Duration) *Memory {
+	c := &Memory{
+		items:      make(map[string]item),
+		tags:       make(map[string]map[string]struct{}),
+		defaultTTL: defaultTTL,
+		stop:       make(chan struct{}),
+	}
+
+	// Запускаем горутину для очистки устаревших записей
+	go c.startCleanup(cleanupInterval)
+
+	return c
+}
+
+// startCleanup запускает периодическую очистку устаревших записей
+func (c *Memory) startCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.cleanup()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// cleanup удаляет все устаревшие записи
+func (c *Memory) cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, v := range c.items {
+		if now.After(v.expiration) {
+			c.deleteLocked(k)
+			c.evictions.Add(1)
+		}
+	}
+}
+
+// deleteLocked удаляет запись и ее ключ из тегов. Вызывается под блокировкой записи
+func (c *Memory) deleteLocked(key string) {
+	it, found := c.items[key]
+	if !found {
+		return
+	}
+	delete(c.items, key)
+	for _, tag := range it.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// Get получает значение из кеша
+func (c *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, found := c.items[key]
+	// Устаревшая запись удаляется при очистке: под блокировкой чтения менять map нельзя
+	if !found || time.Now().After(item.expiration) {
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+
+	c.hits.Add(1)
+	return item.value, true, nil
+}
+
+// Set сохраняет значение в кеше
+func (c *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deleteLocked(key)
+	c.items[key] = item{
+		value:      value,
+		expiration: time.Now().Add(ttl),
+		tags:       tags,
+	}
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+	return nil
+}
+
+// Delete удаляет значения из кеша
+func (c *Memory) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		c.deleteLocked(key)
+	}
+	return nil
+}
+
+// DeletePattern удаляет все значения, ключ которых содержит pattern
+func (c *Memory) DeletePattern(ctx context.Context, pattern string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.items {
+		if strings.Contains(k, pattern) {
+			c.deleteLocked(k)
+		}
+	}
+	return nil
+}
+
+// InvalidateTags удаляет все значения, помеченные хотя бы одним из тегов. Ключи берутся
+// из индекса тегов, поэтому остальные записи не перебираются
+func (c *Memory) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.deleteLocked(key)
+		}
+	}
+	return nil
+}
+
+// Flush очищает весь кеш
+func (c *Memory) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]item)
+	c.tags = make(map[string]map[string]struct{})
+}
+
+// ItemCount возвращает количество элементов в кеше
+func (c *Memory) ItemCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.items)
+}
+
+// Stats возвращает счетчики обращений к кешу
+func (c *Memory) Stats() Stats {
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Items:     c.ItemCount(),
+	}
+}
+
+// Ping проверяет доступность кеша. Закрытый кеш считается недоступным
+func (c *Memory) Ping(ctx context.Context) error {
+	select {
+	case <-c.stop:
+		return ErrClosed
+	default:
+		return ctx.Err()
+	}
+}
+
+// Close останавливает фоновую очистку устаревших записей
+func (c *Memory) Close() error {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	return nil
+}
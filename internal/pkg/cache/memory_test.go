@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryExpiration(t *testing.T) {
+	c := NewMemory(time.Minute, time.Hour)
+	defer c.Close()
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "short", []byte("v"), time.Millisecond, "books"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ctx, "default", []byte("v"), 0, "books"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found, _ := c.Get(ctx, "short"); found {
+		t.Error("Get() returned an expired value")
+	}
+	if _, found, _ := c.Get(ctx, "default"); !found {
+		t.Error("Get() did not return a value with the default TTL")
+	}
+
+	c.cleanup()
+	stats := c.Stats()
+	if stats.Items != 1 || stats.Evictions != 1 {
+		t.Errorf("Stats() after cleanup = %+v, want 1 item and 1 eviction", stats)
+	}
+	// Очистка убирает ключ и из индекса тегов
+	if keys := c.tags["books"]; len(keys) != 1 {
+		t.Errorf("tag index after cleanup = %v, want only the live key", keys)
+	}
+}
+
+func TestMemoryTagIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   func(ctx context.Context, c *Memory) error
+		wantTags map[string]int
+	}{
+		{
+			name:     "set",
+			action:   func(ctx context.Context, c *Memory) error { return nil },
+			wantTags: map[string]int{"a": 2, "b": 1},
+		},
+		{
+			name:     "delete",
+			action:   func(ctx context.Context, c *Memory) error { return c.Delete(ctx, "k1") },
+			wantTags: map[string]int{"a": 1},
+		},
+		{
+			name:     "invalidate",
+			action:   func(ctx context.Context, c *Memory) error { return c.InvalidateTags(ctx, "b") },
+			wantTags: map[string]int{"a": 1},
+		},
+		{
+			name:     "pattern",
+			action:   func(ctx context.Context, c *Memory) error { return c.DeletePattern(ctx, "k") },
+			wantTags: map[string]int{},
+		},
+		{
+			name: "flush",
+			action: func(ctx context.Context, c *Memory) error {
+				c.Flush()
+				return nil
+			},
+			wantTags: map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemory(time.Minute, time.Hour)
+			defer c.Close()
+			ctx := context.Background()
+			c.Set(ctx, "k1", []byte("1"), 0, "a", "b")
+			c.Set(ctx, "k2", []byte("2"), 0, "a")
+
+			if err := tt.action(ctx, c); err != nil {
+				t.Fatalf("action error = %v", err)
+			}
+
+			// Пустые теги не должны оставаться в индексе
+			if len(c.tags) != len(tt.wantTags) {
+				t.Fatalf("tag index = %v, want %v", c.tags, tt.wantTags)
+			}
+			for tag, n := range tt.wantTags {
+				if len(c.tags[tag]) != n {
+					t.Errorf("tag %s has %d keys, want %d", tag, len(c.tags[tag]), n)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryPing(t *testing.T) {
+	c := NewMemory(time.Minute, time.Hour)
+	if err := c.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Ping(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Ping() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+
+	c.Close()
+	c.Close()
+	if err := c.Ping(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Ping() after Close() error = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatch - сколько ключей запрашивать у Redis за одну итерацию SCAN
+const scanBatch = 100
+
+// addToTag добавляет ключ в множество тега и продлевает жизнь множества до срока
+// жизни значения, если он больше: тег должен жить, пока живет хотя бы одно его значение
+var addToTag = redis.NewScript(`
+redis.call('SADD', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+// Redis - кеш в Redis, общий для всех экземпляров сервиса, поэтому инвалидация
+// на одном экземпляре видна остальным. Значения хранятся под ключами prefix+"v:"+key,
+// теги - множествами ключей значений под prefix+"t:"+tag
+type Redis struct {
+	client     redis.UniversalClient
+	prefix     string
+	defaultTTL time.Duration
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewRedis создает новый экземпляр кеша в Redis. prefix отделяет ключи кеша от других
+// данных в том же Redis, defaultTTL используется, если при записи срок жизни не задан
+func NewRedis(client redis.UniversalClient, prefix string, defaultTTL time.Duration) *Redis {
+	return &Redis{client: client, prefix: prefix, defaultTTL: defaultTTL}
+}
+
+func (c *Redis) valueKey(key string) string {
+	return c.prefix + "v:" + key
+}
+
+func (c *Redis) tagKey(tag string) string {
+	return c.prefix + "t:" + tag
+}
+
+// Get получает значение из кеша
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.valueKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.misses.Add(1)
+		return nil, false, nil
+	}
+	if err != nil {
+		c.misses.Add(1)
+		return nil, false, err
+	}
+
+	c.hits.Add(1)
+	return value, true, nil
+}
+
+// Set сохраняет значение в кеше и добавляет его ключ в множества тегов
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, c.valueKey(key), value, ttl)
+		for _, tag := range tags {
+			addToTag.Eval(ctx, pipe, []string{c.tagKey(tag)}, c.valueKey(key), ttl.Milliseconds())
+		}
+		return nil
+	})
+	return err
+}
+
+// Delete удаляет значения из кеша
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, c.valueKey(key))
+		}
+		return nil
+	})
+	return err
+}
+
+// DeletePattern удаляет все значения, ключ которых содержит pattern. Ключи перебираются
+// командой SCAN, поэтому операция не блокирует Redis, но ее время растет с числом ключей
+func (c *Redis) DeletePattern(ctx context.Context, pattern string) error {
+	match := c.valueKey("*" + escapeGlob(pattern) + "*")
+
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, match, scanBatch).Iterator()
+		var batch []string
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == scanBatch {
+				if err := unlink(ctx, client, batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		return unlink(ctx, client, batch)
+	}
+
+	// В кластере ключи распределены по узлам, и каждый master сканируется отдельно
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	}
+	return scan(ctx, c.client)
+}
+
+// InvalidateTags удаляет все значения, помеченные хотя бы одним из тегов, и сами теги.
+// Множества тегов читаются одним запросом, поэтому время операции зависит только от
+// числа удаляемых значений
+func (c *Redis) InvalidateTags(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	cmds := make([]*redis.StringSliceCmd, len(tags))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, tag := range tags {
+			cmds[i] = pipe.SMembers(ctx, c.tagKey(tag))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for i, cmd := range cmds {
+		keys = append(keys, cmd.Val()...)
+		keys = append(keys, c.tagKey(tags[i]))
+	}
+	return unlink(ctx, c.client, keys)
+}
+
+// Stats возвращает число попаданий и промахов этого экземпляра сервиса. Вытеснения
+// и число записей Redis считает сам, они доступны в его статистике (INFO)
+func (c *Redis) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Ping проверяет доступность Redis
+func (c *Redis) Ping(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// Close закрывает подключение к Redis
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
+
+// unlink удаляет ключи по одному в конвейере: в кластере ключи из одной команды
+// должны лежать в одном слоте
+func unlink(ctx context.Context, client redis.Cmdable, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// escapeGlob экранирует спецсимволы шаблона Redis MATCH
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
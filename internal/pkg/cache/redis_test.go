@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRedisExpiration(t *testing.T) {
+	c, server := newTestRedis(t)
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "short", []byte("v"), time.Second, "books"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ctx, "long", []byte("v"), time.Hour, "books"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ctx, "default", []byte("v"), 0); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if ttl := server.TTL("test:v:default"); ttl != time.Minute {
+		t.Errorf("TTL of value without ttl = %v, want default %v", ttl, time.Minute)
+	}
+	// Тег живет, пока живет самое долгое из его значений
+	if ttl := server.TTL("test:t:books"); ttl != time.Hour {
+		t.Errorf("TTL of tag = %v, want %v", ttl, time.Hour)
+	}
+
+	server.FastForward(2 * time.Second)
+	if _, found, _ := c.Get(ctx, "short"); found {
+		t.Error("Get() returned an expired value")
+	}
+	if _, found, _ := c.Get(ctx, "long"); !found {
+		t.Error("Get() did not return a live value")
+	}
+
+	if err := c.InvalidateTags(ctx, "books"); err != nil {
+		t.Fatalf("InvalidateTags() error = %v", err)
+	}
+	if server.Exists("test:v:long") || server.Exists("test:t:books") {
+		t.Error("InvalidateTags() left the value or the tag set")
+	}
+}
+
+func TestRedisPrefix(t *testing.T) {
+	c, server := newTestRedis(t)
+	ctx := context.Background()
+
+	// Чужие ключи в том же Redis не затрагиваются ни шаблоном, ни тегами
+	server.Set("other:books:1", "v")
+	if err := c.Set(ctx, "books:1", []byte("v"), 0, "books"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.DeletePattern(ctx, "books:"); err != nil {
+		t.Fatalf("DeletePattern() error = %v", err)
+	}
+
+	if !server.Exists("other:books:1") {
+		t.Error("DeletePattern() deleted a key outside of the cache prefix")
+	}
+	if server.Exists("test:v:books:1") {
+		t.Error("DeletePattern() left a matching value")
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "books:", want: "books:"},
+		{in: "*", want: `\*`},
+		{in: "a?b", want: `a\?b`},
+		{in: "[x]", want: `\[x\]`},
+		{in: `c:\d`, want: `c:\\d`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeGlob(tt.in); got != tt.want {
+			t.Errorf("escapeGlob(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
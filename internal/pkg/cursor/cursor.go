@@ -0,0 +1,105 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor возвращается, если курсор поврежден, подделан или выдан для другого списка
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Position - ключ строки в упорядоченном по (created_at, id) списке
+type Position struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uint      `json:"i"`
+}
+
+// Cursor указывает на позицию в списке и направление перехода от нее
+type Cursor struct {
+	Position
+	// Backward - переход к предыдущей странице (записи перед позицией)
+	Backward bool `json:"b,omitempty"`
+	// Scope - список, для которого выдан курсор
+	Scope string `json:"s"`
+}
+
+// Signer кодирует курсоры в непрозрачные строки, подписанные HMAC-SHA256
+type Signer struct {
+	key []byte
+}
+
+// NewSigner создает новый экземпляр Signer
+func NewSigner(secret string) *Signer {
+	return &Signer{key: []byte(secret)}
+}
+
+// Encode кодирует курсор в строку вида <payload>.<signature>
+func (s *Signer) Encode(c *Cursor) string {
+	payload, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+// Decode проверяет подпись и область курсора и возвращает его содержимое
+func (s *Signer) Decode(token, scope string) (*Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(payload, &c); err != nil || c.Scope != scope {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+func (s *Signer) sign(payload string) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// Page описывает соседние страницы относительно полученной
+type Page struct {
+	Next *Cursor
+	Prev *Cursor
+}
+
+// NewPage вычисляет курсоры соседних страниц.
+// req - курсор запроса (nil для первой страницы), hasMore - есть ли еще записи
+// в направлении запроса, first и last - позиции первой и последней записи страницы
+func NewPage(req *Cursor, scope string, hasMore bool, first, last *Position) *Page {
+	page := &Page{}
+	if first == nil || last == nil {
+		return page
+	}
+
+	backward := req != nil && req.Backward
+	// Вперед можно идти, если записи остались дальше или мы пришли сюда с конца списка
+	if hasMore || backward {
+		page.Next = &Cursor{Position: *last, Scope: scope}
+	}
+	// Назад можно идти, если мы пришли сюда по курсору вперед или записи остались ранее
+	if (!backward && req != nil) || (backward && hasMore) {
+		page.Prev = &Cursor{Position: *first, Backward: true, Scope: scope}
+	}
+
+	return page
+}
@@ -0,0 +1,119 @@
+package cursor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	signer := NewSigner("secret")
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{
+			name:   "forward",
+			cursor: Cursor{Position: Position{CreatedAt: createdAt, ID: 42}, Scope: "books"},
+		},
+		{
+			name:   "backward",
+			cursor: Cursor{Position: Position{CreatedAt: createdAt, ID: 7}, Backward: true, Scope: "users:3:books"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signer.Encode(&tt.cursor)
+
+			got, err := signer.Decode(token, tt.cursor.Scope)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(tt.cursor.CreatedAt) || got.ID != tt.cursor.ID ||
+				got.Backward != tt.cursor.Backward || got.Scope != tt.cursor.Scope {
+				t.Errorf("Decode() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestSignerDecodeInvalid(t *testing.T) {
+	signer := NewSigner("secret")
+	token := signer.Encode(&Cursor{Position: Position{CreatedAt: time.Unix(1700000000, 0), ID: 1}, Scope: "books"})
+	payload, signature, _ := strings.Cut(token, ".")
+
+	// Подпись другого списка с тем же ключом
+	otherScope := NewSigner("secret").Encode(&Cursor{Position: Position{ID: 1}, Scope: "users"})
+	_, otherSignature, _ := strings.Cut(otherScope, ".")
+
+	tests := []struct {
+		name  string
+		token string
+		scope string
+	}{
+		{name: "empty", token: "", scope: "books"},
+		{name: "no signature", token: payload, scope: "books"},
+		{name: "wrong scope", token: token, scope: "users"},
+		{name: "other key", token: NewSigner("other").Encode(&Cursor{Scope: "books"}), scope: "books"},
+		{name: "tampered payload", token: "x" + payload + "." + signature, scope: "books"},
+		{name: "swapped signature", token: payload + "." + otherSignature, scope: "books"},
+		{name: "bad signature encoding", token: payload + ".!!!", scope: "books"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := signer.Decode(tt.token, tt.scope); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Decode() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	first := &Position{CreatedAt: time.Unix(200, 0), ID: 20}
+	last := &Position{CreatedAt: time.Unix(100, 0), ID: 10}
+	forward := &Cursor{Position: Position{ID: 30}, Scope: "books"}
+	backward := &Cursor{Position: Position{ID: 5}, Backward: true, Scope: "books"}
+
+	tests := []struct {
+		name     string
+		req      *Cursor
+		hasMore  bool
+		first    *Position
+		last     *Position
+		wantNext bool
+		wantPrev bool
+	}{
+		{name: "empty page", req: forward, hasMore: true},
+		{name: "single page", req: nil, hasMore: false, first: first, last: last},
+		{name: "first of many", req: nil, hasMore: true, first: first, last: last, wantNext: true},
+		{name: "middle forward", req: forward, hasMore: true, first: first, last: last, wantNext: true, wantPrev: true},
+		{name: "last forward", req: forward, hasMore: false, first: first, last: last, wantPrev: true},
+		{name: "middle backward", req: backward, hasMore: true, first: first, last: last, wantNext: true, wantPrev: true},
+		{name: "first backward", req: backward, hasMore: false, first: first, last: last, wantNext: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(tt.req, "books", tt.hasMore, tt.first, tt.last)
+
+			if (page.Next != nil) != tt.wantNext {
+				t.Fatalf("Next = %+v, want present = %v", page.Next, tt.wantNext)
+			}
+			if page.Next != nil && (page.Next.Position != *tt.last || page.Next.Backward || page.Next.Scope != "books") {
+				t.Errorf("Next = %+v, want forward cursor after %+v", *page.Next, *tt.last)
+			}
+
+			if (page.Prev != nil) != tt.wantPrev {
+				t.Fatalf("Prev = %+v, want present = %v", page.Prev, tt.wantPrev)
+			}
+			if page.Prev != nil && (page.Prev.Position != *tt.first || !page.Prev.Backward || page.Prev.Scope != "books") {
+				t.Errorf("Prev = %+v, want backward cursor before %+v", *page.Prev, *tt.first)
+			}
+		})
+	}
+}
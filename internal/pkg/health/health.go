@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShuttingDown возвращается проверкой готовности во время остановки сервера
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc проверяет одну зависимость сервиса. nil означает, что зависимость доступна
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker выполняет проверки готовности сервиса принимать запросы
+type Checker struct {
+	checks       []check
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewChecker создает новый экземпляр Checker. timeout ограничивает время каждой проверки
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку зависимости с именем name
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Shutdown помечает сервис остановленным: после этого он не готов принимать запросы,
+// и балансировщик перестает направлять на него трафик, пока выполняющиеся запросы завершаются
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check параллельно выполняет все проверки и возвращает их результаты по именам.
+// ok равен true, только если все проверки прошли успешно
+func (c *Checker) Check(ctx context.Context) (results map[string]error, ok bool) {
+	results = make(map[string]error, len(c.checks))
+	if c.shuttingDown.Load() {
+		results["server"] = ErrShuttingDown
+		return results, false
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			err := ch.fn(ctx)
+
+			mu.Lock()
+			results[ch.name] = err
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+
+	ok = true
+	for _, err := range results {
+		if err != nil {
+			ok = false
+		}
+	}
+	return results, ok
+}
@@ -0,0 +1,138 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// Размеры вариантов изображения по длинной стороне
+const (
+	ThumbnailSize = 200
+	MediumSize    = 800
+)
+
+// maxPixels ограничивает размер декодируемого изображения (защита от "бомб" декомпрессии)
+const maxPixels = 50_000_000
+
+// jpegQuality - качество перекодирования JPEG
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format: only JPEG and PNG are allowed")
+	ErrTooManyPixels     = errors.New("image dimensions are too large")
+)
+
+// Result содержит перекодированное изображение и его уменьшенные варианты.
+// Все варианты кодируются заново, поэтому не содержат EXIF и других метаданных
+type Result struct {
+	ContentType string
+	Original    []byte
+	Medium      []byte
+	Thumbnail   []byte
+}
+
+// Process декодирует JPEG или PNG, поворачивает его согласно EXIF Orientation,
+// удаляет метаданные и строит варианты ThumbnailSize и MediumSize
+func Process(data []byte) (*Result, error) {
+	img, format, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{ContentType: "image/" + format}
+	if result.Original, err = encode(img, format); err != nil {
+		return nil, err
+	}
+	if result.Medium, err = encode(fit(img, MediumSize), format); err != nil {
+		return nil, err
+	}
+	if result.Thumbnail, err = encode(fit(img, ThumbnailSize), format); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Strip перекодирует изображение без метаданных, не меняя его размер.
+// Возвращает новое содержимое и его тип
+func Strip(data []byte) ([]byte, string, error) {
+	img, format, err := decode(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	encoded, err := encode(img, format)
+	if err != nil {
+		return nil, "", err
+	}
+	return encoded, "image/" + format, nil
+}
+
+// decode декодирует JPEG или PNG и применяет EXIF Orientation
+func decode(data []byte) (image.Image, string, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, "", ErrUnsupportedFormat
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Метаданные ориентации теряются при перекодировании, поэтому применяем их к пикселям
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	return img, format, nil
+}
+
+// fit уменьшает изображение так, чтобы длинная сторона не превышала size.
+// Изображения меньше size не увеличиваются
+func fit(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	if w >= h {
+		h = max(1, h*size/w)
+		w = size
+	} else {
+		w = max(1, w*size/h)
+		h = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+	return dst
+}
+
+// encode кодирует изображение в исходный формат
+func encode(img image.Image, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -1,11 +1,15 @@
 package jwt
 
 import (
+	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/token"
 	"booktrading/internal/domain/user"
 	"booktrading/internal/pkg/logger"
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -19,27 +23,33 @@ var (
 	ErrInvalidClaims  = errors.New("invalid token claims")
 	ErrInvalidRefresh = errors.New("invalid refresh token")
 	ErrExpiredRefresh = errors.New("refresh token has expired")
+	ErrRefreshReused  = errors.New("refresh token reuse detected, session revoked")
 )
 
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
-	ExpiresIn    int64  `json:"expires_in"`
+	// ExpiresIn - время жизни access token в секундах
+	ExpiresIn int64 `json:"expires_in"`
 }
 
 type Service struct {
 	tokenAuth   *jwtauth.JWTAuth
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
 	refreshRepo token.Repository
 	userRepo    interface {
-		GetByID(id uint) (*user.User, error)
+		GetByID(ctx context.Context, id uint) (*user.User, error)
 	}
 }
 
-func NewService(secretKey string, refreshRepo token.Repository, userRepo interface {
-	GetByID(id uint) (*user.User, error)
+func NewService(secretKey string, accessTTL, refreshTTL time.Duration, refreshRepo token.Repository, userRepo interface {
+	GetByID(ctx context.Context, id uint) (*user.User, error)
 }) *Service {
 	return &Service{
 		tokenAuth:   jwtauth.New("HS256", []byte(secretKey), nil),
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
 		refreshRepo: refreshRepo,
 		userRepo:    userRepo,
 	}
@@ -51,10 +61,36 @@ func generateRefreshToken() (string, error) {
 	if _, err := rand.Read(b); err != nil {
 		return "", fmt.Errorf("failed to generate refresh token: %w", err)
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func (s *Service) GenerateTokenPair(user *user.User) (*TokenPair, error) {
+// generateFamilyID генерирует идентификатор семейства refresh токенов
+func generateFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token family: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken возвращает хеш refresh token, под которым он хранится в базе
+func hashToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateTokenPair выдает новую пару токенов и начинает новое семейство refresh токенов.
+// Используется при входе в систему.
+func (s *Service) GenerateTokenPair(ctx context.Context, user *user.User) (*TokenPair, error) {
+	familyID, err := generateFamilyID()
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokenPair(ctx, user, familyID)
+}
+
+// issueTokenPair выдает пару токенов в рамках указанного семейства
+func (s *Service) issueTokenPair(ctx context.Context, user *user.User, familyID string) (*TokenPair, error) {
 	if user == nil {
 		return nil, fmt.Errorf("user is required")
 	}
@@ -67,34 +103,41 @@ func (s *Service) GenerateTokenPair(user *user.User) (*TokenPair, error) {
 		return nil, fmt.Errorf("invalid user login")
 	}
 
+	now := time.Now()
+
 	// Generate access token
-	_, accessToken, err := s.tokenAuth.Encode(map[string]interface{}{
+	claims := map[string]interface{}{
 		"user_id": user.ID,
 		"login":   user.Login,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(),
-	})
+		"role":    user.Role,
+	}
+	jwtauth.SetIssuedAt(claims, now)
+	jwtauth.SetExpiry(claims, now.Add(s.accessTTL))
+	_, accessToken, err := s.tokenAuth.Encode(claims)
 	if err != nil {
 		return nil, err
 	}
 
 	// Generate refresh token
-	_, refreshToken, err := s.tokenAuth.Encode(map[string]interface{}{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(24 * time.Hour * 7).Unix(), // 7 days
-	})
+	refreshToken, err := generateRefreshToken()
 	if err != nil {
 		return nil, err
 	}
 
-	// Сохраняем refresh token в базе данных
-	if err := s.refreshRepo.Save(user.ID, refreshToken, time.Now().Add(24*time.Hour*7)); err != nil {
+	// Сохраняем хеш refresh token в базе данных
+	if err := s.refreshRepo.Save(ctx, &token.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}); err != nil {
 		return nil, fmt.Errorf("failed to save refresh token: %w", err)
 	}
 
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-		ExpiresIn:    time.Now().Add(24 * time.Hour * 7).Unix(),
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
 	}, nil
 }
 
@@ -103,8 +146,11 @@ func (s *Service) ValidateToken(tokenString string) (map[string]interface{}, err
 		return nil, ErrInvalidToken
 	}
 
-	token, err := s.tokenAuth.Decode(tokenString)
+	token, err := jwtauth.VerifyToken(s.tokenAuth, tokenString)
 	if err != nil {
+		if errors.Is(err, jwtauth.ErrExpired) {
+			return nil, ErrExpiredToken
+		}
 		return nil, ErrInvalidToken
 	}
 
@@ -119,63 +165,103 @@ func (s *Service) ValidateToken(tokenString string) (map[string]interface{}, err
 		return nil, ErrInvalidClaims
 	}
 
+	role, _ := token.Get("role")
+
 	return map[string]interface{}{
 		"user_id": userID,
 		"login":   login,
+		"role":    role,
 	}, nil
 }
 
-func (s *Service) RefreshTokenPair(refreshToken string) (*TokenPair, error) {
-	// Проверяем refresh token в базе данных
-	userID, err := s.refreshRepo.Validate(refreshToken)
+// RefreshTokenPair обменивает refresh token на новую пару токенов.
+// Каждый refresh token можно использовать только один раз: повторное
+// предъявление уже использованного токена считается кражей и отзывает
+// все токены его семейства.
+func (s *Service) RefreshTokenPair(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	stored, err := s.lookup(ctx, refreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate refresh token: %w", err)
+		return nil, err
 	}
 
-	// Получаем пользователя
-	user, err := s.userRepo.GetByID(userID)
+	if stored.UsedAt != nil {
+		s.revokeReusedFamily(ctx, stored)
+		return nil, ErrRefreshReused
+	}
+
+	if stored.IsExpired(time.Now()) {
+		return nil, ErrExpiredRefresh
+	}
+
+	// Помечаем токен использованным. Если параллельный запрос успел раньше,
+	// это тоже повторное использование
+	marked, err := s.refreshRepo.MarkUsed(ctx, stored.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, err
+	}
+	if !marked {
+		s.revokeReusedFamily(ctx, stored)
+		return nil, ErrRefreshReused
 	}
 
-	// Удаляем использованный refresh token
-	if err := s.refreshRepo.Delete(refreshToken); err != nil {
-		logger.Error("Failed to delete used refresh token", err)
+	// Получаем пользователя
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// Генерируем новую пару токенов
-	return s.GenerateTokenPair(user)
+	// Генерируем новую пару токенов в том же семействе
+	return s.issueTokenPair(ctx, user, stored.FamilyID)
 }
 
-func (s *Service) RevokeRefreshToken(refreshToken string) error {
-	return s.refreshRepo.Delete(refreshToken)
+// RevokeRefreshToken завершает сессию: отзывает refresh token и все токены его семейства
+func (s *Service) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	stored, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeFamily(ctx, stored.FamilyID)
 }
 
-func (s *Service) RevokeAllUserTokens(userID uint) error {
-	return s.refreshRepo.DeleteUserTokens(userID)
+func (s *Service) RevokeAllUserTokens(ctx context.Context, userID uint) error {
+	return s.refreshRepo.RevokeUserTokens(ctx, userID)
 }
 
-func (s *Service) CleanupExpiredTokens() error {
-	return s.refreshRepo.DeleteExpired()
+func (s *Service) CleanupExpiredTokens(ctx context.Context) error {
+	return s.refreshRepo.DeleteExpired(ctx)
 }
 
 func (s *Service) GetTokenAuth() *jwtauth.JWTAuth {
 	return s.tokenAuth
 }
 
-// ValidateRefreshToken проверяет refresh token и возвращает пользователя
-func (s *Service) ValidateRefreshToken(tokenString string) (*user.User, error) {
-	token, err := s.tokenAuth.Decode(tokenString)
+// lookup находит действующий (не отозванный) refresh token
+func (s *Service) lookup(ctx context.Context, refreshToken string) (*token.RefreshToken, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefresh
+	}
+
+	stored, err := s.refreshRepo.GetByHash(ctx, hashToken(refreshToken))
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidRefresh
+		}
 		return nil, err
 	}
 
-	userID, ok := token.Get("user_id")
-	if !ok {
-		return nil, ErrInvalidToken
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidRefresh
 	}
 
-	return &user.User{
-		ID: uint(userID.(float64)),
-	}, nil
+	return stored, nil
+}
+
+// revokeReusedFamily отзывает семейство токенов после обнаружения повторного использования
+func (s *Service) revokeReusedFamily(ctx context.Context, stored *token.RefreshToken) {
+	log := logger.FromContext(ctx)
+	log.Error("Refresh token reuse detected", ErrRefreshReused, "user_id", stored.UserID, "token_family", stored.FamilyID)
+	// Отзыв не должен прерываться, даже если клиент уже отключился
+	if err := s.refreshRepo.RevokeFamily(context.WithoutCancel(ctx), stored.FamilyID); err != nil {
+		log.Error("Failed to revoke refresh token family", err, "token_family", stored.FamilyID)
+	}
 }
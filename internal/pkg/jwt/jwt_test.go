@@ -0,0 +1,251 @@
+package jwt
+
+import (
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/token"
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeRefreshRepo хранит refresh токены в памяти
+type fakeRefreshRepo struct {
+	tokens []*token.RefreshToken
+}
+
+func (r *fakeRefreshRepo) Save(ctx context.Context, t *token.RefreshToken) error {
+	t.ID = uint(len(r.tokens) + 1)
+	stored := *t
+	r.tokens = append(r.tokens, &stored)
+	return nil
+}
+
+func (r *fakeRefreshRepo) GetByHash(ctx context.Context, tokenHash string) (*token.RefreshToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *fakeRefreshRepo) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	t := r.tokens[id-1]
+	if t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (r *fakeRefreshRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshRepo) DeleteExpired(ctx context.Context) error {
+	return nil
+}
+
+func (r *fakeRefreshRepo) RevokeUserTokens(ctx context.Context, userID uint) error {
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// revoked возвращает число отозванных токенов семейства
+func (r *fakeRefreshRepo) revoked(familyID string) int {
+	n := 0
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeUserRepo отдает пользователя по ID
+type fakeUserRepo struct {
+	users map[uint]*user.User
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uint) (*user.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return u, nil
+}
+
+// errorHook считает записи лога уровня error
+type errorHook struct {
+	messages []string
+}
+
+func (h *errorHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if level == zerolog.ErrorLevel {
+		h.messages = append(h.messages, msg)
+	}
+}
+
+var testUser = &user.User{ID: 7, Login: "reader", Role: user.RoleUser}
+
+func newTestService() (*Service, *fakeRefreshRepo) {
+	repo := &fakeRefreshRepo{}
+	users := &fakeUserRepo{users: map[uint]*user.User{testUser.ID: testUser}}
+	return NewService("secret", time.Minute, time.Hour, repo, users), repo
+}
+
+// familyOf возвращает семейство, к которому принадлежит refresh token
+func familyOf(t *testing.T, repo *fakeRefreshRepo, refreshToken string) string {
+	t.Helper()
+	stored, err := repo.GetByHash(context.Background(), hashToken(refreshToken))
+	if err != nil {
+		t.Fatalf("refresh token is not stored: %v", err)
+	}
+	return stored.FamilyID
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	s, repo := newTestService()
+	ctx := context.Background()
+
+	login, err := s.GenerateTokenPair(ctx, testUser)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	if stored, _ := repo.GetByHash(ctx, login.RefreshToken); stored != nil {
+		t.Fatal("refresh token is stored in plain text")
+	}
+
+	rotated, err := s.RefreshTokenPair(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokenPair() error = %v", err)
+	}
+	if rotated.RefreshToken == login.RefreshToken {
+		t.Fatal("RefreshTokenPair() returned the same refresh token")
+	}
+	if familyOf(t, repo, rotated.RefreshToken) != familyOf(t, repo, login.RefreshToken) {
+		t.Error("rotated token started a new family")
+	}
+	claims, err := s.ValidateToken(rotated.AccessToken)
+	if err != nil || claims["login"] != testUser.Login {
+		t.Errorf("ValidateToken() = %v, %v, want claims of %s", claims, err, testUser.Login)
+	}
+
+	// Следующая ротация работает уже от нового токена
+	next, err := s.RefreshTokenPair(ctx, rotated.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokenPair() of rotated token error = %v", err)
+	}
+	if familyOf(t, repo, next.RefreshToken) != familyOf(t, repo, login.RefreshToken) {
+		t.Error("second rotation started a new family")
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s, repo := newTestService()
+	hook := &errorHook{}
+	ctx := logger.WithContext(context.Background(), logger.Global().With("request_id", "req-1").Hook(hook))
+
+	login, err := s.GenerateTokenPair(ctx, testUser)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	other, err := s.GenerateTokenPair(ctx, testUser)
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	rotated, err := s.RefreshTokenPair(ctx, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokenPair() error = %v", err)
+	}
+
+	// Старый токен после ротации - признак кражи: отзывается все семейство
+	if _, err := s.RefreshTokenPair(ctx, login.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("RefreshTokenPair() of used token error = %v, want %v", err, ErrRefreshReused)
+	}
+	family := familyOf(t, repo, login.RefreshToken)
+	if n := repo.revoked(family); n != 2 {
+		t.Errorf("revoked %d tokens of the family, want 2", n)
+	}
+	if _, err := s.RefreshTokenPair(ctx, rotated.RefreshToken); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("RefreshTokenPair() of token from revoked family error = %v, want %v", err, ErrInvalidRefresh)
+	}
+	if _, err := s.RefreshTokenPair(ctx, login.RefreshToken); !errors.Is(err, ErrInvalidRefresh) {
+		t.Errorf("RefreshTokenPair() of revoked token error = %v, want %v", err, ErrInvalidRefresh)
+	}
+
+	// Другие сессии пользователя не затрагиваются
+	if _, err := s.RefreshTokenPair(ctx, other.RefreshToken); err != nil {
+		t.Errorf("RefreshTokenPair() of another session error = %v", err)
+	}
+
+	// Событие пишется логгером запроса
+	if len(hook.messages) != 1 || hook.messages[0] != "Refresh token reuse detected" {
+		t.Errorf("request logger errors = %v, want reuse event", hook.messages)
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken func(login *TokenPair) string
+		wantErr      error
+	}{
+		{name: "logout", refreshToken: func(login *TokenPair) string { return login.RefreshToken }},
+		{name: "empty token", refreshToken: func(*TokenPair) string { return "" }, wantErr: ErrInvalidRefresh},
+		{name: "unknown token", refreshToken: func(*TokenPair) string { return "unknown" }, wantErr: ErrInvalidRefresh},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService()
+			ctx := context.Background()
+
+			login, err := s.GenerateTokenPair(ctx, testUser)
+			if err != nil {
+				t.Fatalf("GenerateTokenPair() error = %v", err)
+			}
+			rotated, err := s.RefreshTokenPair(ctx, login.RefreshToken)
+			if err != nil {
+				t.Fatalf("RefreshTokenPair() error = %v", err)
+			}
+
+			err = s.RevokeRefreshToken(ctx, tt.refreshToken(rotated))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RevokeRefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// После выхода токены сессии отклоняются на сервере
+			if n := repo.revoked(familyOf(t, repo, login.RefreshToken)); n != 2 {
+				t.Errorf("revoked %d tokens of the session, want 2", n)
+			}
+			if _, err := s.RefreshTokenPair(ctx, rotated.RefreshToken); !errors.Is(err, ErrInvalidRefresh) {
+				t.Errorf("RefreshTokenPair() after logout error = %v, want %v", err, ErrInvalidRefresh)
+			}
+			if err := s.RevokeRefreshToken(ctx, rotated.RefreshToken); !errors.Is(err, ErrInvalidRefresh) {
+				t.Errorf("second RevokeRefreshToken() error = %v, want %v", err, ErrInvalidRefresh)
+			}
+		})
+	}
+}
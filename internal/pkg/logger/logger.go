@@ -1,51 +1,128 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"io"
 	"os"
+	"time"
+
+	"github.com/rs/zerolog"
 )
 
-var (
-	InfoLogger  *log.Logger
-	ErrorLogger *log.Logger
+const (
+	// FormatJSON выводит записи лога в формате JSON, по одной на строку
+	FormatJSON = "json"
+	// FormatConsole выводит записи лога в читаемом виде для локальной разработки
+	FormatConsole = "console"
 )
 
-// Init initializes the loggers
-func Init() {
-	InfoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-	ErrorLogger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+// Logger пишет структурированные записи лога. Поля передаются парами ключ-значение:
+//
+//	logger.Info("Book created", "book_id", b.ID, "owner_id", b.OwnerID)
+type Logger struct {
+	zl zerolog.Logger
+}
+
+type contextKey struct{}
+
+// global - логгер по умолчанию, используемый функциями пакета и вне HTTP запросов
+var global = &Logger{zl: newZerolog(os.Stdout, zerolog.DebugLevel, FormatJSON)}
+
+// Init настраивает глобальный логгер: минимальный уровень записей и формат вывода
+func Init(level zerolog.Level, format string) {
+	global = &Logger{zl: newZerolog(os.Stdout, level, format)}
 }
 
-// Info logs an info message
-func Info(message string) {
-	if InfoLogger == nil {
-		Init()
+func newZerolog(w io.Writer, level zerolog.Level, format string) zerolog.Logger {
+	if format == FormatConsole {
+		w = zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}
 	}
-	InfoLogger.Println(message)
+	return zerolog.New(w).Level(level).With().Timestamp().Logger()
+}
+
+// Global возвращает глобальный логгер
+func Global() *Logger {
+	return global
 }
 
-// Error logs an error message
-func Error(message string, err error) {
-	if ErrorLogger == nil {
-		Init()
+// FromContext возвращает логгер запроса из ctx или глобальный логгер, если его там нет
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return l
 	}
-	ErrorLogger.Printf("%s: %v\n", message, err)
+	return global
+}
+
+// WithContext возвращает копию ctx, содержащую логгер l
+func WithContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// With создает дочерний логгер, добавляющий fields ко всем записям
+func (l *Logger) With(fields ...interface{}) *Logger {
+	return &Logger{zl: l.zl.With().Fields(fields).Logger()}
+}
+
+// Hook создает дочерний логгер, вызывающий h для каждой записи
+func (l *Logger) Hook(h zerolog.Hook) *Logger {
+	return &Logger{zl: l.zl.Hook(h)}
+}
+
+// Append добавляет fields к самому логгеру, а не к дочернему. Нужен middleware, которые
+// дополняют логгер запроса, созданный раньше них. Не потокобезопасен: вызывается до того,
+// как логгер начнут использовать другие горутины
+func (l *Logger) Append(fields ...interface{}) {
+	l.zl.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Fields(fields)
+	})
 }
 
 // Debug логирует отладочное сообщение
-func Debug(msg string, fields ...interface{}) {
-	log.Printf("DEBUG: %s\n", msg)
+func (l *Logger) Debug(msg string, fields ...interface{}) {
+	l.zl.Debug().Fields(fields).Msg(msg)
+}
+
+// Info логирует информационное сообщение
+func (l *Logger) Info(msg string, fields ...interface{}) {
+	l.zl.Info().Fields(fields).Msg(msg)
 }
 
 // Warn логирует предупреждение
+func (l *Logger) Warn(msg string, fields ...interface{}) {
+	l.zl.Warn().Fields(fields).Msg(msg)
+}
+
+// Error логирует ошибку
+func (l *Logger) Error(msg string, err error, fields ...interface{}) {
+	l.zl.Error().Err(err).Fields(fields).Msg(msg)
+}
+
+// Fatal логирует ошибку и завершает программу
+func (l *Logger) Fatal(msg string, err error, fields ...interface{}) {
+	l.zl.Fatal().Err(err).Fields(fields).Msg(msg)
+}
+
+// Debug логирует отладочное сообщение глобальным логгером
+func Debug(msg string, fields ...interface{}) {
+	global.Debug(msg, fields...)
+}
+
+// Info логирует информационное сообщение глобальным логгером
+func Info(msg string, fields ...interface{}) {
+	global.Info(msg, fields...)
+}
+
+// Warn логирует предупреждение глобальным логгером
 func Warn(msg string, fields ...interface{}) {
-	log.Printf("WARN: %s\n", msg)
+	global.Warn(msg, fields...)
 }
 
-// Fatal logs a fatal error and exits the program
-func Fatal(message string, err error) {
-	if ErrorLogger == nil {
-		Init()
-	}
-	ErrorLogger.Fatalf("%s: %v\n", message, err)
+// Error логирует ошибку глобальным логгером
+func Error(msg string, err error, fields ...interface{}) {
+	global.Error(msg, err, fields...)
+}
+
+// Fatal логирует ошибку глобальным логгером и завершает программу
+func Fatal(msg string, err error, fields ...interface{}) {
+	global.Fatal(msg, err, fields...)
 }
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// businessScrapeTimeout ограничивает время запросов к базе при сборе бизнес-метрик
+const businessScrapeTimeout = 5 * time.Second
+
+// BookCounter считает книги по состояниям
+type BookCounter interface {
+	CountByState(ctx context.Context) (map[string]int64, error)
+}
+
+// TradeCounter считает обмены по статусам
+type TradeCounter interface {
+	CountByStatus(ctx context.Context) (map[trade.Status]int64, error)
+}
+
+var (
+	booksDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "books"),
+		"Number of books by state.",
+		[]string{"state"}, nil,
+	)
+	tradesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "trades"),
+		"Number of trades by status.",
+		[]string{"status"}, nil,
+	)
+	activeTradesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "trades_active"),
+		"Number of pending and accepted trades.",
+		nil, nil,
+	)
+)
+
+// businessCollector считает бизнес-метрики запросами к базе при каждом сборе метрик
+type businessCollector struct {
+	books  BookCounter
+	trades TradeCounter
+}
+
+// RegisterBusiness публикует число книг по состояниям и обменов по статусам
+func RegisterBusiness(books BookCounter, trades TradeCounter) {
+	prometheus.MustRegister(&businessCollector{books: books, trades: trades})
+}
+
+// Describe отправляет описания метрик коллектора
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- booksDesc
+	ch <- tradesDesc
+	ch <- activeTradesDesc
+}
+
+// Collect запрашивает текущие значения из базы. Если запрос не удался,
+// метрика пропускается, а не сбрасывается в ноль
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessScrapeTimeout)
+	defer cancel()
+
+	if books, err := c.books.CountByState(ctx); err != nil {
+		logger.Error("Failed to collect book metrics", err)
+	} else {
+		for state, count := range books {
+			ch <- prometheus.MustNewConstMetric(booksDesc, prometheus.GaugeValue, float64(count), state)
+		}
+	}
+
+	trades, err := c.trades.CountByStatus(ctx)
+	if err != nil {
+		logger.Error("Failed to collect trade metrics", err)
+		return
+	}
+	for status, count := range trades {
+		ch <- prometheus.MustNewConstMetric(tradesDesc, prometheus.GaugeValue, float64(count), string(status))
+	}
+	active := trades[trade.StatusPending] + trades[trade.StatusAccepted]
+	ch <- prometheus.MustNewConstMetric(activeTradesDesc, prometheus.GaugeValue, float64(active))
+}
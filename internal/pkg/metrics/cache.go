@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"booktrading/internal/pkg/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheStats - источник статистики кеша
+type CacheStats interface {
+	Stats() cache.Stats
+}
+
+// RegisterCache публикует счетчики попаданий, промахов и вытеснений кеша и число его записей
+func RegisterCache(c CacheStats) {
+	prometheus.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "hits_total",
+			Help:      "Number of cache lookups that found a value.",
+		}, func() float64 { return float64(c.Stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "misses_total",
+			Help:      "Number of cache lookups that found no value or an expired one.",
+		}, func() float64 { return float64(c.Stats().Misses) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "evictions_total",
+			Help:      "Number of cache entries removed after expiration.",
+		}, func() float64 { return float64(c.Stats().Evictions) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "items",
+			Help:      "Number of entries in the cache.",
+		}, func() float64 { return float64(c.Stats().Items) }),
+	)
+}
@@ -0,0 +1,151 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileNamePattern описывает имя файла миграции: 001_initial_schema.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// namePattern описывает допустимое имя новой миграции
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Migration - одна версия схемы базы данных
+type Migration struct {
+	// Version - номер миграции, миграции применяются по возрастанию номеров
+	Version uint
+	// Name - имя миграции без номера
+	Name string
+	// Up - SQL, применяющий миграцию
+	Up string
+	// Down - SQL, откатывающий миграцию. Может быть пустым, тогда откат невозможен
+	Down string
+	// Checksum - SHA-256 от Up. Примененную миграцию изменять нельзя
+	Checksum string
+}
+
+// String возвращает имя миграции вместе с номером
+func (m *Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// Load читает миграции из файловой системы и сортирует их по номеру.
+// Файлы, не оканчивающиеся на .sql, пропускаются
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		parts := fileNamePattern.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("invalid migration file name %q, expected NNN_name.up.sql or NNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: parts[2]}
+			byVersion[m.Version] = m
+		}
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("migration %d has different names: %q and %q", m.Version, m.Name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			m.Up = string(content)
+			m.Checksum = checksum(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Create создает в каталоге dir пустые файлы новой миграции со следующим номером
+// и возвращает их пути
+func Create(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !namePattern.MatchString(name) {
+		return "", "", errors.New("migration name may contain only latin letters, digits and underscores")
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	var version uint = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	m := &Migration{Version: version, Name: name}
+	upPath := filepath.Join(dir, m.String()+".up.sql")
+	downPath := filepath.Join(dir, m.String()+".down.sql")
+
+	if err := createFile(upPath, "-- "+strings.ReplaceAll(name, "_", " ")+"\n"); err != nil {
+		return "", "", err
+	}
+	if err := createFile(downPath, ""); err != nil {
+		os.Remove(upPath)
+		return "", "", err
+	}
+
+	return upPath, downPath, nil
+}
+
+// createFile создает файл, если его еще нет
+func createFile(name, content string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create migration file: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write migration file: %w", err)
+	}
+	return f.Close()
+}
+
+// checksum вычисляет контрольную сумму содержимого миграции
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+package migrate
+
+import (
+	"booktrading/migrations"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		want     []string
+		wantDown []bool
+		wantErr  string
+	}{
+		{
+			name:  "empty",
+			files: fstest.MapFS{},
+			want:  []string{},
+		},
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"010_add_tags.up.sql":         {Data: []byte("CREATE TABLE tags (id INT);")},
+				"002_add_users.up.sql":        {Data: []byte("CREATE TABLE users (id INT);")},
+				"002_add_users.down.sql":      {Data: []byte("DROP TABLE users;")},
+				"001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE books (id INT);")},
+				"001_initial_schema.down.sql": {Data: []byte("DROP TABLE books;")},
+			},
+			want:     []string{"001_initial_schema", "002_add_users", "010_add_tags"},
+			wantDown: []bool{true, true, false},
+		},
+		{
+			name: "skips other files and directories",
+			files: fstest.MapFS{
+				"001_initial_schema.up.sql": {Data: []byte("CREATE TABLE books (id INT);")},
+				"migrations.go":             {Data: []byte("package migrations")},
+				"README.md":                 {Data: []byte("# migrations")},
+				"old/002_old.up.sql":        {Data: []byte("SELECT 1;")},
+			},
+			want:     []string{"001_initial_schema"},
+			wantDown: []bool{false},
+		},
+		{
+			name: "invalid file name",
+			files: fstest.MapFS{
+				"1-initial.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name: "different names of one version",
+			files: fstest.MapFS{
+				"001_initial_schema.up.sql": {Data: []byte("SELECT 1;")},
+				"001_initial.down.sql":      {Data: []byte("SELECT 1;")},
+			},
+			wantErr: "has different names",
+		},
+		{
+			name: "down without up",
+			files: fstest.MapFS{
+				"001_initial_schema.down.sql": {Data: []byte("DROP TABLE books;")},
+			},
+			wantErr: "has no up script",
+		},
+		{
+			name: "blank up",
+			files: fstest.MapFS{
+				"001_initial_schema.up.sql": {Data: []byte("  \n")},
+			},
+			wantErr: "has no up script",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("Load() returned %d migrations, want %d", len(got), len(tt.want))
+			}
+			for i, m := range got {
+				if m.String() != tt.want[i] {
+					t.Errorf("migration %d = %s, want %s", i, m, tt.want[i])
+				}
+				if (m.Down != "") != tt.wantDown[i] {
+					t.Errorf("migration %s has down = %v, want %v", m, m.Down != "", tt.wantDown[i])
+				}
+				if m.Checksum != checksum([]byte(m.Up)) {
+					t.Errorf("migration %s checksum = %s, want checksum of up script", m, m.Checksum)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatal("Load() returned no migrations")
+	}
+	// Все миграции проекта должны откатываться
+	for _, m := range got {
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %s has no down script", m)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "001_initial_schema.up.sql"), []byte("SELECT 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	up, down, err := Create(dir, " Add Book Photos ")
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if filepath.Base(up) != "002_add_book_photos.up.sql" || filepath.Base(down) != "002_add_book_photos.down.sql" {
+		t.Errorf("Create() = %s, %s, want 002_add_book_photos files", up, down)
+	}
+
+	// Новая миграция не должна ломать загрузку: up файл не пустой
+	got, err := Load(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("Load() after Create() error = %v", err)
+	}
+	if len(got) != 2 || got[1].String() != "002_add_book_photos" {
+		t.Errorf("Load() after Create() = %v, want 2 migrations", got)
+	}
+
+	if _, _, err := Create(dir, "add-book-photos"); err == nil {
+		t.Error("Create() with invalid name error = nil, want error")
+	}
+}
@@ -0,0 +1,333 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// lockName - имя блокировки MySQL, не дающей двум процессам мигрировать одновременно
+	lockName = "schema_migrations"
+	// lockTimeout - сколько секунд ждать блокировку
+	lockTimeout = 30
+)
+
+var (
+	// ErrPending возвращается, если в базе применены не все миграции
+	ErrPending = errors.New("database schema has pending migrations")
+	// ErrModified возвращается, если примененная миграция была изменена
+	ErrModified = errors.New("applied migration was modified")
+	// ErrUnknown возвращается, если в базе применена миграция, файла которой нет
+	ErrUnknown = errors.New("applied migration is unknown")
+	// ErrLocked возвращается, если миграции уже выполняет другой процесс
+	ErrLocked = errors.New("migrations are being applied by another process")
+	// ErrNoDown возвращается при откате миграции без down скрипта
+	ErrNoDown = errors.New("migration has no down script")
+)
+
+// Status описывает состояние одной миграции
+type Status struct {
+	Version uint
+	Name    string
+	// AppliedAt - время применения миграции, nil если она еще не применена
+	AppliedAt *time.Time
+	// Modified - файл примененной миграции изменился после применения
+	Modified bool
+	// Unknown - миграция применена, но ее файла нет
+	Unknown bool
+}
+
+// applied - запись о примененной миграции в таблице schema_migrations
+type applied struct {
+	version   uint
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator применяет и откатывает миграции, записывая примененные версии
+// в таблицу schema_migrations.
+//
+// MySQL не откатывает изменения схемы в транзакции, поэтому миграция, упавшая
+// на середине, может оставить часть изменений: их нужно исправить вручную.
+// Соединение должно быть открыто с параметром multiStatements=true, чтобы
+// миграция могла содержать несколько запросов
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+	// DryRun - только определять, какие миграции будут применены или откачены,
+	// не изменяя базу данных
+	DryRun bool
+}
+
+// New создает новый экземпляр Migrator
+func New(db *sql.DB, migrations []*Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up применяет непримененные миграции по возрастанию номеров. Если limit больше нуля,
+// применяется не больше limit миграций. Возвращает примененные миграции
+func (m *Migrator) Up(ctx context.Context, limit int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verified(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+			if limit > 0 && len(done) == limit {
+				break
+			}
+
+			if !m.DryRun {
+				if _, err := conn.ExecContext(ctx, migration.Up); err != nil {
+					return fmt.Errorf("migration %s failed, its statements before the failed one may be already applied: %w", migration, err)
+				}
+				if _, err := conn.ExecContext(ctx,
+					"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, NOW(3))",
+					migration.Version, migration.Name, migration.Checksum,
+				); err != nil {
+					return fmt.Errorf("failed to record migration %s: %w", migration, err)
+				}
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down откатывает последние limit примененных миграций в обратном порядке.
+// Возвращает откаченные миграции
+func (m *Migrator) Down(ctx context.Context, limit int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verified(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < limit; i-- {
+			migration := m.migrations[i]
+			if _, ok := records[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("%w: %s", ErrNoDown, migration)
+			}
+
+			if !m.DryRun {
+				if _, err := conn.ExecContext(ctx, migration.Down); err != nil {
+					return fmt.Errorf("rollback of migration %s failed, its statements before the failed one may be already applied: %w", migration, err)
+				}
+				if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+					return fmt.Errorf("failed to remove migration %s record: %w", migration, err)
+				}
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Baseline отмечает миграции с номером не больше version примененными, не выполняя их.
+// Нужна для баз, схема которых уже была создана без schema_migrations
+func (m *Migrator) Baseline(ctx context.Context, version uint) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		records, err := m.verified(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := records[migration.Version]; ok {
+				continue
+			}
+
+			if !m.DryRun {
+				if _, err := conn.ExecContext(ctx,
+					"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, NOW(3))",
+					migration.Version, migration.Name, migration.Checksum,
+				); err != nil {
+					return fmt.Errorf("failed to record migration %s: %w", migration, err)
+				}
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status возвращает состояние всех известных и примененных миграций по возрастанию номеров
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	records, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return m.status(records), nil
+}
+
+// Check проверяет, что все миграции применены и не изменены после применения
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if err := s.err(); err != nil {
+			return err
+		}
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%03d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", ErrPending, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// verified создает таблицу schema_migrations, если ее еще нет, и возвращает
+// примененные миграции, проверив, что они не изменены
+func (m *Migrator) verified(ctx context.Context, conn *sql.Conn) (map[uint]applied, error) {
+	if !m.DryRun {
+		if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT UNSIGNED NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at DATETIME(3) NOT NULL,
+			PRIMARY KEY (version)
+		)`); err != nil {
+			return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+	}
+
+	records, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range m.status(records) {
+		if err := s.err(); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// applied читает примененные миграции. Если таблицы schema_migrations еще нет,
+// примененных миграций нет
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[uint]applied, error) {
+	var exists int
+	if err := conn.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'",
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations table: %w", err)
+	}
+
+	records := make(map[uint]applied)
+	if exists == 0 {
+		return records, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r applied
+		if err := rows.Scan(&r.version, &r.name, &r.checksum, &r.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		records[r.version] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return records, nil
+}
+
+// status сопоставляет известные миграции с примененными
+func (m *Migrator) status(records map[uint]applied) []Status {
+	statuses := make([]Status, 0, len(m.migrations)+len(records))
+	known := make(map[uint]bool, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		s := Status{Version: migration.Version, Name: migration.Name}
+		if r, ok := records[migration.Version]; ok {
+			appliedAt := r.appliedAt
+			s.AppliedAt = &appliedAt
+			s.Modified = r.checksum != migration.Checksum
+		}
+		statuses = append(statuses, s)
+	}
+
+	for version, r := range records {
+		if known[version] {
+			continue
+		}
+		appliedAt := r.appliedAt
+		statuses = append(statuses, Status{Version: version, Name: r.name, AppliedAt: &appliedAt, Unknown: true})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses
+}
+
+// err возвращает ошибку, если состояние миграции не позволяет работать со схемой
+func (s Status) err() error {
+	switch {
+	case s.Modified:
+		return fmt.Errorf("%w: %03d_%s", ErrModified, s.Version, s.Name)
+	case s.Unknown:
+		return fmt.Errorf("%w: %03d_%s", ErrUnknown, s.Version, s.Name)
+	}
+	return nil
+}
+
+// withLock выполняет fn на отдельном соединении, удерживая блокировку миграций
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrLocked
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+
+	return fn(conn)
+}
@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMigratorStatus(t *testing.T) {
+	appliedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	migrations := []*Migration{
+		{Version: 1, Name: "initial_schema", Checksum: "a"},
+		{Version: 2, Name: "add_users", Checksum: "b"},
+		{Version: 3, Name: "add_tags", Checksum: "c"},
+	}
+
+	tests := []struct {
+		name    string
+		records map[uint]applied
+		want    []Status
+		wantErr error
+	}{
+		{
+			name:    "nothing applied",
+			records: map[uint]applied{},
+			want: []Status{
+				{Version: 1, Name: "initial_schema"},
+				{Version: 2, Name: "add_users"},
+				{Version: 3, Name: "add_tags"},
+			},
+		},
+		{
+			name: "partially applied",
+			records: map[uint]applied{
+				1: {version: 1, name: "initial_schema", checksum: "a", appliedAt: appliedAt},
+			},
+			want: []Status{
+				{Version: 1, Name: "initial_schema", AppliedAt: &appliedAt},
+				{Version: 2, Name: "add_users"},
+				{Version: 3, Name: "add_tags"},
+			},
+		},
+		{
+			name: "modified after applying",
+			records: map[uint]applied{
+				1: {version: 1, name: "initial_schema", checksum: "a", appliedAt: appliedAt},
+				2: {version: 2, name: "add_users", checksum: "changed", appliedAt: appliedAt},
+			},
+			want: []Status{
+				{Version: 1, Name: "initial_schema", AppliedAt: &appliedAt},
+				{Version: 2, Name: "add_users", AppliedAt: &appliedAt, Modified: true},
+				{Version: 3, Name: "add_tags"},
+			},
+			wantErr: ErrModified,
+		},
+		{
+			name: "unknown applied migration",
+			records: map[uint]applied{
+				1: {version: 1, name: "initial_schema", checksum: "a", appliedAt: appliedAt},
+				5: {version: 5, name: "removed", checksum: "e", appliedAt: appliedAt},
+			},
+			want: []Status{
+				{Version: 1, Name: "initial_schema", AppliedAt: &appliedAt},
+				{Version: 2, Name: "add_users"},
+				{Version: 3, Name: "add_tags"},
+				{Version: 5, Name: "removed", AppliedAt: &appliedAt, Unknown: true},
+			},
+			wantErr: ErrUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(nil, migrations).status(tt.records)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("status() returned %d entries, want %d", len(got), len(tt.want))
+			}
+			var err error
+			for i, s := range got {
+				want := tt.want[i]
+				if s.Version != want.Version || s.Name != want.Name ||
+					s.Modified != want.Modified || s.Unknown != want.Unknown ||
+					(s.AppliedAt == nil) != (want.AppliedAt == nil) ||
+					(s.AppliedAt != nil && !s.AppliedAt.Equal(*want.AppliedAt)) {
+					t.Errorf("status()[%d] = %+v, want %+v", i, s, want)
+				}
+				if err == nil {
+					err = s.err()
+				}
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"sync"
+)
+
+// subscriptionBuffer - число событий, которые могут ждать отправки клиенту.
+// Если клиент не успевает их читать, новые события ему не доставляются:
+// они остаются во входящих уведомлениях
+const subscriptionBuffer = 16
+
+// Event - событие, доставляемое подключенному клиенту
+type Event struct {
+	// ID - идентификатор события (ID сохраненного уведомления)
+	ID uint
+	// Type - тип события
+	Type string
+	// Data - данные события в формате JSON
+	Data []byte
+}
+
+// Subscription - подписка одного подключения пользователя на его события
+type Subscription struct {
+	userID uint
+	events chan Event
+}
+
+// Events возвращает канал событий подписки
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Hub рассылает события подключенным пользователям. У пользователя может быть
+// несколько одновременных подключений, каждое получает все его события
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[uint]map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub создает новый экземпляр Hub
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[uint]map[*Subscription]struct{})}
+}
+
+// Subscribe подписывает подключение пользователя на события
+func (h *Hub) Subscribe(userID uint) *Subscription {
+	s := &Subscription{userID: userID, events: make(chan Event, subscriptionBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// После остановки сервера новые подписки сразу закрыты
+	if h.closed {
+		close(s.events)
+		return s
+	}
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][s] = struct{}{}
+	return s
+}
+
+// Unsubscribe отменяет подписку и закрывает ее канал
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs := h.subscribers[s.userID]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.subscribers, s.userID)
+	}
+	close(s.events)
+}
+
+// Publish отправляет событие всем подключениям пользователя, не блокируясь на медленных клиентах
+func (h *Hub) Publish(userID uint, e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for s := range h.subscribers[userID] {
+		select {
+		case s.events <- e:
+		default:
+		}
+	}
+}
+
+// Close закрывает все подписки, завершая потоки событий подключенных клиентов.
+// Вызывается при остановке сервера, чтобы долгоживущие соединения не задерживали ее
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for userID, subs := range h.subscribers {
+		for s := range subs {
+			close(s.events)
+		}
+		delete(h.subscribers, userID)
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"booktrading/internal/config"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// blobStores возвращает хранилища для общих проверок BlobStore. S3 проверяется,
+// только если задан STORAGE_TEST_S3_ENDPOINT, например локальный MinIO:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	STORAGE_TEST_S3_ENDPOINT=localhost:9000 go test ./internal/pkg/storage/
+func blobStores(t *testing.T) map[string]BlobStore {
+	t.Helper()
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	stores := map[string]BlobStore{"local": local}
+
+	if endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT"); endpoint != "" {
+		s3, err := NewS3Store(&config.StorageConfig{
+			S3Endpoint:  endpoint,
+			S3AccessKey: envOr("STORAGE_TEST_S3_ACCESS_KEY", "minioadmin"),
+			S3SecretKey: envOr("STORAGE_TEST_S3_SECRET_KEY", "minioadmin"),
+			S3Bucket:    envOr("STORAGE_TEST_S3_BUCKET", "booktrading-test"),
+		})
+		if err != nil {
+			t.Fatalf("NewS3Store() error = %v", err)
+		}
+		stores["s3"] = s3
+	}
+	return stores
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestBlobStore(t *testing.T) {
+	png := string(testPNG(t, 4, 4))
+
+	tests := []struct {
+		name            string
+		key             string
+		puts            []string
+		wantContent     string
+		wantContentType string
+	}{
+		{name: "png", key: strings.Repeat("a", 64), puts: []string{png}, wantContent: png, wantContentType: "image/png"},
+		{name: "overwrite", key: strings.Repeat("b", 64), puts: []string{"first", "second"}, wantContent: "second", wantContentType: "text/plain; charset=utf-8"},
+		{name: "short key", key: "k", puts: []string{"value"}, wantContent: "value", wantContentType: "text/plain; charset=utf-8"},
+	}
+
+	for name, store := range blobStores(t) {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				t.Cleanup(func() { store.Delete(ctx, tt.key) })
+
+				for _, content := range tt.puts {
+					if err := store.Put(ctx, tt.key, strings.NewReader(content), int64(len(content)), tt.wantContentType); err != nil {
+						t.Fatalf("Put() error = %v", err)
+					}
+				}
+
+				exists, err := store.Exists(ctx, tt.key)
+				if err != nil || !exists {
+					t.Fatalf("Exists() = %v, %v, want true", exists, err)
+				}
+
+				r, info, err := store.Get(ctx, tt.key)
+				if err != nil {
+					t.Fatalf("Get() error = %v", err)
+				}
+				data, err := io.ReadAll(r)
+				r.Close()
+				if err != nil {
+					t.Fatalf("failed to read object: %v", err)
+				}
+				if string(data) != tt.wantContent {
+					t.Errorf("Get() content = %q, want %q", data, tt.wantContent)
+				}
+				if info.Size != int64(len(tt.wantContent)) || info.ContentType != tt.wantContentType {
+					t.Errorf("Get() info = %+v, want size %d and type %s", *info, len(tt.wantContent), tt.wantContentType)
+				}
+
+				if err := store.Delete(ctx, tt.key); err != nil {
+					t.Fatalf("Delete() error = %v", err)
+				}
+				if exists, err := store.Exists(ctx, tt.key); err != nil || exists {
+					t.Errorf("Exists() after Delete() = %v, %v, want false", exists, err)
+				}
+			})
+		}
+
+		t.Run(name+"/missing", func(t *testing.T) {
+			ctx := context.Background()
+			key := strings.Repeat("f", 64)
+			if _, _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() error = %v, want %v", err, ErrNotFound)
+			}
+			if err := store.Delete(ctx, key); err != nil {
+				t.Errorf("Delete() of missing object error = %v, want nil", err)
+			}
+		})
+	}
+}
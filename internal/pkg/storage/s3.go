@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"booktrading/internal/config"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store хранит объекты в S3-совместимом хранилище (AWS S3, MinIO)
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store подключается к хранилищу и создает бакет, если его нет
+func NewS3Store(cfg *config.StorageConfig) (*S3Store, error) {
+	client, err := minio.New(cfg.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %q: %w", cfg.S3Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket %q: %w", cfg.S3Bucket, err)
+		}
+	}
+
+	return &S3Store{client: client, bucket: cfg.S3Bucket}, nil
+}
+
+// Put сохраняет объект в бакет
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+		// Содержимое адресуется хешем и никогда не меняется
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	return err
+}
+
+// Get открывает объект на чтение
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s.mapError(err)
+	}
+
+	// GetObject ленивый: ошибка отсутствия объекта появляется только при Stat или чтении
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, s.mapError(err)
+	}
+
+	return obj, &ObjectInfo{ContentType: stat.ContentType, Size: stat.Size}, nil
+}
+
+// Exists проверяет наличие объекта
+func (s *S3Store) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{}); err != nil {
+		if err := s.mapError(err); err != ErrNotFound {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// Delete удаляет объект
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// mapError преобразует ошибку отсутствия объекта в ErrNotFound
+func (s *S3Store) mapError(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
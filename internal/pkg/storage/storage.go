@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"booktrading/internal/config"
+	"booktrading/internal/pkg/imaging"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// MaxImageSize - максимальный размер изображения (5MB)
+const MaxImageSize = 5 * 1024 * 1024
+
+var (
+	ErrNotFound         = errors.New("blob not found")
+	ErrInvalidImage     = errors.New("invalid image: only JPEG and PNG data URIs are allowed")
+	ErrImageTooLarge    = errors.New("image size exceeds 5MB limit")
+	ErrUnsupportedValue = errors.New("image must be a base64 data URI or a media URL")
+)
+
+// Суффиксы ключей уменьшенных вариантов изображения
+const (
+	thumbnailSuffix = "-thumb"
+	mediumSuffix    = "-medium"
+)
+
+// keyPattern - формат ключа: SHA-256 хеш оригинала в hex с необязательным суффиксом варианта
+var keyPattern = regexp.MustCompile(`^[0-9a-f]{64}(-thumb|-medium)?$`)
+
+// Image содержит URL оригинала изображения и его уменьшенных вариантов
+type Image struct {
+	URL          string
+	MediumURL    string
+	ThumbnailURL string
+}
+
+// ObjectInfo содержит метаданные сохраненного объекта
+type ObjectInfo struct {
+	ContentType string
+	Size        int64
+}
+
+// BlobStore определяет интерфейс хранилища двоичных объектов
+type BlobStore interface {
+	// Put сохраняет объект под ключом. Повторное сохранение того же ключа допустимо
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get открывает объект на чтение. Возвращает ErrNotFound, если объекта нет
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Exists проверяет наличие объекта
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete удаляет объект. Отсутствие объекта ошибкой не считается
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore создает хранилище по конфигурации
+func NewBlobStore(cfg *config.StorageConfig) (BlobStore, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStore(cfg.LocalPath)
+	case "s3":
+		return NewS3Store(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// Media сохраняет изображения в BlobStore по хешу содержимого и строит их URL
+type Media struct {
+	store   BlobStore
+	baseURL string
+}
+
+// NewMedia создает новый экземпляр Media. baseURL - префикс, по которому
+// раздаются файлы, например /media
+func NewMedia(store BlobStore, baseURL string) *Media {
+	return &Media{
+		store:   store,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Save сохраняет изображение без метаданных и возвращает его URL
+func (m *Media) Save(ctx context.Context, data []byte) (string, error) {
+	if len(data) > MaxImageSize {
+		return "", ErrImageTooLarge
+	}
+
+	stripped, contentType, err := imaging.Strip(data)
+	if err != nil {
+		return "", mapImagingError(err)
+	}
+
+	key := hashKey(stripped)
+	if err := m.put(ctx, key, stripped, contentType); err != nil {
+		return "", err
+	}
+
+	return m.URL(key), nil
+}
+
+// SaveImage сохраняет изображение без метаданных вместе с вариантами
+// imaging.MediumSize и imaging.ThumbnailSize
+func (m *Media) SaveImage(ctx context.Context, data []byte) (*Image, error) {
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+
+	result, err := imaging.Process(data)
+	if err != nil {
+		return nil, mapImagingError(err)
+	}
+
+	key := hashKey(result.Original)
+	if err := m.storeVariants(ctx, key, result); err != nil {
+		return nil, err
+	}
+
+	return m.image(key), nil
+}
+
+// SaveDataURI сохраняет изображение из base64 data URI и возвращает его URL.
+// Пустая строка и уже сохраненные media URL возвращаются без изменений
+func (m *Media) SaveDataURI(ctx context.Context, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if _, ok := m.KeyFromURL(value); ok {
+		return value, nil
+	}
+
+	data, err := decodeDataURI(value)
+	if err != nil {
+		return "", err
+	}
+
+	return m.Save(ctx, data)
+}
+
+// SaveImageDataURI сохраняет изображение из base64 data URI вместе с вариантами.
+// Для уже сохраненного media URL недостающие варианты строятся из оригинала
+func (m *Media) SaveImageDataURI(ctx context.Context, value string) (*Image, error) {
+	if key, ok := m.KeyFromURL(value); ok {
+		return m.EnsureVariants(ctx, key)
+	}
+
+	data, err := decodeDataURI(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.SaveImage(ctx, data)
+}
+
+// EnsureVariants строит варианты для ранее сохраненного оригинала, если их еще нет
+func (m *Media) EnsureVariants(ctx context.Context, key string) (*Image, error) {
+	key = originalKey(key)
+	if !keyPattern.MatchString(key) {
+		return nil, ErrNotFound
+	}
+
+	thumbExists, err := m.store.Exists(ctx, key+thumbnailSuffix)
+	if err != nil {
+		return nil, err
+	}
+	mediumExists, err := m.store.Exists(ctx, key+mediumSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if thumbExists && mediumExists {
+		return m.image(key), nil
+	}
+
+	r, _, err := m.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, MaxImageSize+1))
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	result, err := imaging.Process(data)
+	if err != nil {
+		return nil, mapImagingError(err)
+	}
+
+	// Оригинал уже сохранен под этим ключом, поэтому дописываем только варианты
+	result.Original = nil
+	if err := m.storeVariants(ctx, key, result); err != nil {
+		return nil, err
+	}
+
+	return m.image(key), nil
+}
+
+// storeVariants сохраняет оригинал и варианты под ключами, производными от key
+func (m *Media) storeVariants(ctx context.Context, key string, result *imaging.Result) error {
+	blobs := map[string][]byte{
+		key:                   result.Original,
+		key + mediumSuffix:    result.Medium,
+		key + thumbnailSuffix: result.Thumbnail,
+	}
+	for k, data := range blobs {
+		if data == nil {
+			continue
+		}
+		if err := m.put(ctx, k, data, result.ContentType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put сохраняет объект, если его еще нет. Одинаковые изображения хранятся один раз
+func (m *Media) put(ctx context.Context, key string, data []byte, contentType string) error {
+	exists, err := m.store.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	if err := m.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+	return nil
+}
+
+// image строит URL оригинала и вариантов по ключу оригинала
+func (m *Media) image(key string) *Image {
+	return &Image{
+		URL:          m.URL(key),
+		MediumURL:    m.URL(key + mediumSuffix),
+		ThumbnailURL: m.URL(key + thumbnailSuffix),
+	}
+}
+
+// Open открывает сохраненное изображение по ключу
+func (m *Media) Open(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if !keyPattern.MatchString(key) {
+		return nil, nil, ErrNotFound
+	}
+	return m.store.Get(ctx, key)
+}
+
+// URL возвращает URL изображения по ключу
+func (m *Media) URL(key string) string {
+	return m.baseURL + "/" + key
+}
+
+// KeyFromURL извлекает ключ из media URL
+func (m *Media) KeyFromURL(url string) (string, bool) {
+	key, ok := strings.CutPrefix(url, m.baseURL+"/")
+	if !ok || !keyPattern.MatchString(key) {
+		return "", false
+	}
+	return key, true
+}
+
+// decodeDataURI извлекает содержимое из base64 data URI
+func decodeDataURI(value string) ([]byte, error) {
+	if !strings.HasPrefix(value, "data:") {
+		return nil, ErrUnsupportedValue
+	}
+
+	header, payload, ok := strings.Cut(value, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return nil, ErrInvalidImage
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	return data, nil
+}
+
+// hashKey возвращает ключ объекта: SHA-256 хеш содержимого в hex
+func hashKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// originalKey отбрасывает суффикс варианта
+func originalKey(key string) string {
+	key = strings.TrimSuffix(key, thumbnailSuffix)
+	return strings.TrimSuffix(key, mediumSuffix)
+}
+
+// mapImagingError сводит ошибки декодирования к ошибкам пакета storage
+func mapImagingError(err error) error {
+	switch {
+	case errors.Is(err, imaging.ErrTooManyPixels):
+		return ErrImageTooLarge
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		return ErrInvalidImage
+	default:
+		return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// testPNG кодирует однотонное PNG изображение заданного размера
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func dataURI(contentType string, data []byte) string {
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// newTestMedia создает Media поверх локального хранилища во временном каталоге
+func newTestMedia(t *testing.T) (*Media, *LocalStore) {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	return NewMedia(store, "/media/"), store
+}
+
+func TestMediaSaveImageDataURI(t *testing.T) {
+	media, store := newTestMedia(t)
+	ctx := context.Background()
+	photo := dataURI("image/png", testPNG(t, 1000, 500))
+
+	img, err := media.SaveImageDataURI(ctx, photo)
+	if err != nil {
+		t.Fatalf("SaveImageDataURI() error = %v", err)
+	}
+
+	key, ok := media.KeyFromURL(img.URL)
+	if !ok {
+		t.Fatalf("URL %q is not a media URL", img.URL)
+	}
+	if img.MediumURL != img.URL+mediumSuffix || img.ThumbnailURL != img.URL+thumbnailSuffix {
+		t.Errorf("variant URLs = %s, %s, want URLs derived from %s", img.MediumURL, img.ThumbnailURL, img.URL)
+	}
+	for _, k := range []string{key, key + mediumSuffix, key + thumbnailSuffix} {
+		if exists, err := store.Exists(ctx, k); err != nil || !exists {
+			t.Errorf("object %s exists = %v, %v, want true", k, exists, err)
+		}
+	}
+
+	// То же изображение получает тот же адрес
+	again, err := media.SaveImageDataURI(ctx, photo)
+	if err != nil || *again != *img {
+		t.Errorf("second SaveImageDataURI() = %+v, %v, want %+v", again, err, img)
+	}
+
+	// Для уже сохраненного URL недостающие варианты строятся заново
+	if err := store.Delete(ctx, key+thumbnailSuffix); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	restored, err := media.SaveImageDataURI(ctx, img.URL)
+	if err != nil || *restored != *img {
+		t.Fatalf("SaveImageDataURI() of media URL = %+v, %v, want %+v", restored, err, img)
+	}
+	if exists, _ := store.Exists(ctx, key+thumbnailSuffix); !exists {
+		t.Error("missing thumbnail was not rebuilt")
+	}
+}
+
+func TestMediaSaveDataURI(t *testing.T) {
+	media, _ := newTestMedia(t)
+	ctx := context.Background()
+	saved, err := media.SaveDataURI(ctx, dataURI("image/png", testPNG(t, 2, 2)))
+	if err != nil {
+		t.Fatalf("SaveDataURI() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{name: "empty", value: "", want: ""},
+		{name: "media URL", value: saved, want: saved},
+		{name: "plain URL", value: "https://example.com/photo.png", wantErr: ErrUnsupportedValue},
+		{name: "not base64 data URI", value: "data:image/png,abc", wantErr: ErrInvalidImage},
+		{name: "invalid base64", value: "data:image/png;base64,!!!", wantErr: ErrInvalidImage},
+		{name: "not an image", value: dataURI("image/png", []byte("hello")), wantErr: ErrInvalidImage},
+		{name: "too large", value: dataURI("image/png", make([]byte, MaxImageSize+1)), wantErr: ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := media.SaveDataURI(ctx, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SaveDataURI() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("SaveDataURI() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMediaKeyFromURL(t *testing.T) {
+	media, _ := newTestMedia(t)
+	hash := strings.Repeat("0123456789abcdef", 4)
+
+	tests := []struct {
+		url     string
+		wantKey string
+		wantOK  bool
+	}{
+		{url: "/media/" + hash, wantKey: hash, wantOK: true},
+		{url: "/media/" + hash + "-thumb", wantKey: hash + "-thumb", wantOK: true},
+		{url: "/media/" + hash + "-medium", wantKey: hash + "-medium", wantOK: true},
+		{url: "/media/" + hash + "-large"},
+		{url: "/media/" + strings.ToUpper(hash)},
+		{url: "/media/../" + hash},
+		{url: "/files/" + hash},
+		{url: hash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			key, ok := media.KeyFromURL(tt.url)
+			if key != tt.wantKey || ok != tt.wantOK {
+				t.Errorf("KeyFromURL() = %q, %v, want %q, %v", key, ok, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMediaOpenRejectsInvalidKeys(t *testing.T) {
+	media, _ := newTestMedia(t)
+
+	for _, key := range []string{"", "../secret", strings.Repeat("a", 63), strings.Repeat("a", 64) + "-large"} {
+		if _, _, err := media.Open(context.Background(), key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) error = %v, want %v", key, err, ErrNotFound)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type testBook struct {
+	ID    uint
+	Title string
+}
+
+// newDryRunDB открывает GORM с плагином трассировки в режиме DryRun:
+// SQL строится и проходит через callbacks, но соединение с базой не открывается
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:password@tcp(127.0.0.1:3306)/test",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Use(NewGormPlugin()); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	return db
+}
+
+// attr возвращает значение атрибута спана
+func attr(attrs []attribute.KeyValue, key attribute.Key) string {
+	for _, kv := range attrs {
+		if kv.Key == key {
+			return kv.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestGormPlugin(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       func(db *gorm.DB) error
+		wantSpan  string
+		wantTable string
+		wantSQL   string
+	}{
+		{
+			name:      "query",
+			run:       func(db *gorm.DB) error { return db.Where("title = ?", "Dune").Find(&[]testBook{}).Error },
+			wantSpan:  "gorm.query",
+			wantTable: "test_books",
+			wantSQL:   "SELECT * FROM `test_books` WHERE title = ?",
+		},
+		{
+			name:      "create",
+			run:       func(db *gorm.DB) error { return db.Create(&testBook{Title: "Dune"}).Error },
+			wantSpan:  "gorm.create",
+			wantTable: "test_books",
+			wantSQL:   "INSERT INTO `test_books`",
+		},
+		{
+			name:      "update",
+			run:       func(db *gorm.DB) error { return db.Model(&testBook{ID: 1}).Update("title", "Dune").Error },
+			wantSpan:  "gorm.update",
+			wantTable: "test_books",
+			wantSQL:   "UPDATE `test_books` SET `title`=? WHERE `id` = ?",
+		},
+		{
+			name:      "delete",
+			run:       func(db *gorm.DB) error { return db.Delete(&testBook{ID: 1}).Error },
+			wantSpan:  "gorm.delete",
+			wantTable: "test_books",
+			wantSQL:   "DELETE FROM `test_books` WHERE `test_books`.`id` = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t, 1)
+			db := newDryRunDB(t)
+
+			ctx, parent := Start(context.Background(), "BookRepository.Test")
+			if err := tt.run(db.WithContext(ctx)); err != nil {
+				t.Fatalf("query error = %v", err)
+			}
+			parent.End()
+
+			spans := recorder.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("recorded %d spans, want statement and parent spans", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != tt.wantSpan {
+				t.Errorf("span name = %s, want %s", span.Name(), tt.wantSpan)
+			}
+			if span.Parent().SpanID() != spans[1].SpanContext().SpanID() {
+				t.Error("statement span is not a child of the request span")
+			}
+			if table := attr(span.Attributes(), semconv.DBSQLTableKey); table != tt.wantTable {
+				t.Errorf("table = %q, want %q", table, tt.wantTable)
+			}
+			// В спан попадает SQL с плейсхолдерами, без значений параметров
+			statement := attr(span.Attributes(), semconv.DBStatementKey)
+			if !strings.HasPrefix(statement, tt.wantSQL) || strings.Contains(statement, "Dune") {
+				t.Errorf("statement = %q, want %q without parameter values", statement, tt.wantSQL)
+			}
+			if span.Status().Code == codes.Error {
+				t.Errorf("successful statement has error status %v", span.Status())
+			}
+		})
+	}
+}
+
+func TestGormPluginRecordsErrors(t *testing.T) {
+	recorder := newRecorder(t, 1)
+	db := newDryRunDB(t)
+
+	// Удаление без условия GORM отклоняет до выполнения запроса
+	err := db.WithContext(context.Background()).Delete(&testBook{}).Error
+	if err == nil {
+		t.Fatal("delete without conditions error = nil, want gorm.ErrMissingWhereClause")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error || len(spans[0].Events()) == 0 {
+		t.Errorf("span status = %v with %d events, want recorded error", spans[0].Status(), len(spans[0].Events()))
+	}
+}
@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"booktrading/internal/config"
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// newRecorder настраивает глобальный TracerProvider, сохраняющий спаны в памяти.
+// После теста спаны снова ничего не делают
+func newRecorder(t *testing.T, sampleRatio float64) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := NewProvider("booktrading-test", sampleRatio, recorder)
+
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestStartCreatesChildSpans(t *testing.T) {
+	recorder := newRecorder(t, 1)
+
+	ctx, parent := Start(context.Background(), "BookUseCase.GetAllBooks")
+	_, child := Start(ctx, "cache.Load")
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	gotChild, gotParent := spans[0], spans[1]
+	if gotChild.Name() != "cache.Load" || gotParent.Name() != "BookUseCase.GetAllBooks" {
+		t.Fatalf("spans = %s, %s, want cache.Load, BookUseCase.GetAllBooks", gotChild.Name(), gotParent.Name())
+	}
+	if gotChild.Parent().SpanID() != gotParent.SpanContext().SpanID() ||
+		gotChild.SpanContext().TraceID() != gotParent.SpanContext().TraceID() {
+		t.Error("child span is not part of the parent span trace")
+	}
+	if name := gotParent.Resource().Set(); name.Len() == 0 {
+		t.Error("span has no service resource")
+	}
+}
+
+func TestNewProviderSampling(t *testing.T) {
+	tests := []struct {
+		name        string
+		sampleRatio float64
+		parent      trace.SpanContext
+		wantSpans   int
+	}{
+		{name: "sample all", sampleRatio: 1, wantSpans: 1},
+		{name: "sample none", sampleRatio: 0, wantSpans: 0},
+		{
+			// Решение вызывающего сервиса о сэмплировании сохраняется
+			name:        "sampled parent",
+			sampleRatio: 0,
+			parent: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    trace.TraceID{1},
+				SpanID:     trace.SpanID{1},
+				TraceFlags: trace.FlagsSampled,
+				Remote:     true,
+			}),
+			wantSpans: 1,
+		},
+		{
+			name:        "not sampled parent",
+			sampleRatio: 1,
+			parent: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID: trace.TraceID{1},
+				SpanID:  trace.SpanID{1},
+				Remote:  true,
+			}),
+			wantSpans: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := newRecorder(t, tt.sampleRatio)
+
+			ctx := context.Background()
+			if tt.parent.IsValid() {
+				ctx = trace.ContextWithRemoteSpanContext(ctx, tt.parent)
+			}
+			_, span := Start(ctx, "GET /api/v1/books")
+			span.End()
+
+			if n := len(recorder.Ended()); n != tt.wantSpans {
+				t.Errorf("recorded %d spans, want %d", n, tt.wantSpans)
+			}
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{name: "default", exporter: ""},
+		{name: "none", exporter: ExporterNone},
+		{name: "unknown", exporter: "jaeger", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+			shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: tt.exporter, ServiceName: "booktrading"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Setup() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown() error = %v", err)
+			}
+
+			// Без экспортера спаны не записываются
+			_, span := Start(context.Background(), "noop")
+			if span.IsRecording() {
+				t.Error("span is recording with the no-op exporter")
+			}
+		})
+	}
+}
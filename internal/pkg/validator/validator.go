@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"booktrading/internal/domain/book"
 	"booktrading/internal/domain/tag"
 	"encoding/base64"
 	"fmt"
@@ -55,6 +56,11 @@ func New() *Validate {
 		return true
 	})
 
+	// Регистрация валидации ISBN-10/ISBN-13 с контрольной суммой, дефисы и пробелы допускаются
+	_ = v.RegisterValidation("isbn_code", func(fl validator.FieldLevel) bool {
+		return book.ValidISBN(book.NormalizeISBN(fl.Field().String()))
+	})
+
 	// Регистрация пользовательской валидации для состояния
 	_ = v.RegisterValidation("state", func(fl validator.FieldLevel) bool {
 		value := fl.Field().String()
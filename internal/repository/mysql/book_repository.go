@@ -5,16 +5,25 @@ import (
 	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/state"
 	"booktrading/internal/domain/tag"
+	"booktrading/internal/domain/trade"
 	"booktrading/internal/domain/user"
+	"booktrading/internal/domain/wishlist"
+	"booktrading/internal/pkg/cursor"
 	"booktrading/internal/pkg/logger"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// fulltextMatch - условие полнотекстового поиска по индексу idx_books_fulltext
+const fulltextMatch = "MATCH(books.title, books.author, books.description) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
 type BookRepository struct {
 	db *gorm.DB
 }
@@ -61,8 +70,8 @@ func validatePhotos(photos []string) error {
 	return nil
 }
 
-func (r *BookRepository) Create(bookData *book.Book) error {
-	tx := r.db.Begin()
+func (r *BookRepository) Create(ctx context.Context, bookData *book.Book) error {
+	tx := r.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to start transaction: %w", tx.Error)
 	}
@@ -102,12 +111,19 @@ func (r *BookRepository) Create(bookData *book.Book) error {
 		return fmt.Errorf("state with ID %d not found", bookData.StateID)
 	}
 
-	// Сохраняем книгу
-	if err := tx.Create(bookData).Error; err != nil {
+	// Сохраняем книгу. Фотографии сохраняются ниже вместе с позициями в галерее
+	bookData.Version = 1
+	if err := tx.Omit("Photos").Create(bookData).Error; err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to create book: %w", err)
 	}
 
+	// Сохраняем фотографии
+	if err := insertPhotos(tx, bookData.ID, bookData.Photos, 0); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create book photos: %w", err)
+	}
+
 	// Создаем связи с тегами
 	if err := tx.Model(bookData).Association("Tags").Replace(bookData.Tags); err != nil {
 		tx.Rollback()
@@ -117,9 +133,9 @@ func (r *BookRepository) Create(bookData *book.Book) error {
 	return tx.Commit().Error
 }
 
-func (r *BookRepository) GetByID(id uint) (*book.Book, error) {
+func (r *BookRepository) GetByID(ctx context.Context, id uint) (*book.Book, error) {
 	var b book.Book
-	if err := r.db.Preload("Tags").Preload("State").Preload("User").Preload("Photos").First(&b, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).First(&b, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, repository.ErrNotFound
 		}
@@ -128,26 +144,28 @@ func (r *BookRepository) GetByID(id uint) (*book.Book, error) {
 	return &b, nil
 }
 
-func (r *BookRepository) Update(b *book.Book) error {
+// Update сохраняет книгу, если ее версия в базе все еще равна b.Version.
+// Если photos не nil, фотографии книги заменяются ими в той же транзакции
+func (r *BookRepository) Update(ctx context.Context, b *book.Book, photos []*book.BookPhoto) error {
 	// Проверяем существование книги
 	var existingBook book.Book
-	if err := r.db.First(&existingBook, b.ID).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&existingBook, b.ID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			logger.Error("Book not found", fmt.Errorf("book with ID %d not found", b.ID))
+			logger.FromContext(ctx).Error("Book not found", fmt.Errorf("book with ID %d not found", b.ID))
 			return errors.New("book not found")
 		}
-		logger.Error("Failed to get book", err)
+		logger.FromContext(ctx).Error("Failed to get book", err)
 		return err
 	}
 
 	// Проверяем уникальность названия книги для пользователя
 	var count int64
-	if err := r.db.Model(&book.Book{}).Where("user_id = ? AND title = ? AND id != ?", b.UserID, b.Title, b.ID).Count(&count).Error; err != nil {
-		logger.Error("Failed to check book title uniqueness", err)
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).Where("user_id = ? AND title = ? AND id != ?", b.UserID, b.Title, b.ID).Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to check book title uniqueness", err)
 		return err
 	}
 	if count > 0 {
-		logger.Error("Book title already exists", fmt.Errorf("book with title '%s' already exists for user %d", b.Title, b.UserID))
+		logger.FromContext(ctx).Error("Book title already exists", fmt.Errorf("book with title '%s' already exists for user %d", b.Title, b.UserID))
 		return errors.New("book title already exists")
 	}
 
@@ -158,12 +176,12 @@ func (r *BookRepository) Update(b *book.Book) error {
 			tagIDs = append(tagIDs, t.ID)
 		}
 		var count int64
-		if err := r.db.Model(&tag.Tag{}).Where("id IN ?", tagIDs).Count(&count).Error; err != nil {
-			logger.Error("Failed to check tags existence", err)
+		if err := r.db.WithContext(ctx).Model(&tag.Tag{}).Where("id IN ?", tagIDs).Count(&count).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to check tags existence", err)
 			return err
 		}
 		if int(count) != len(tagIDs) {
-			logger.Error("Some tags not found", fmt.Errorf("some tags from %v not found", tagIDs))
+			logger.FromContext(ctx).Error("Some tags not found", fmt.Errorf("some tags from %v not found", tagIDs))
 			return errors.New("some tags not found")
 		}
 	}
@@ -171,51 +189,118 @@ func (r *BookRepository) Update(b *book.Book) error {
 	// Проверяем существование состояния
 	if b.StateID != 0 {
 		var stateExists bool
-		if err := r.db.Model(&state.State{}).Select("1").Where("id = ?", b.StateID).Take(&stateExists).Error; err != nil {
-			logger.Error("Failed to check state existence", err)
+		if err := r.db.WithContext(ctx).Model(&state.State{}).Select("1").Where("id = ?", b.StateID).Take(&stateExists).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to check state existence", err)
 			return err
 		}
 		if !stateExists {
-			logger.Error("State not found", fmt.Errorf("state with ID %d not found", b.StateID))
+			logger.FromContext(ctx).Error("State not found", fmt.Errorf("state with ID %d not found", b.StateID))
 			return errors.New("state not found")
 		}
 	}
 
-	// Обновляем книгу в транзакции
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	// Обновляем книгу в транзакции, только если ее не изменили после чтения
+	var updatedAt time.Time
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Проверяем, что переход из текущего состояния в новое разрешен. Строка книги
+		// блокируется, чтобы состояние не изменилось до обновления
+		if b.StateID != 0 {
+			var current book.Book
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "state_id").
+				First(&current, b.ID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return repository.ErrNotFound
+				}
+				return err
+			}
+			if err := checkStateTransition(tx, current.StateID, b.StateID); err != nil {
+				return err
+			}
+		}
+
 		// Обновляем основные данные книги
-		if err := tx.Save(b).Error; err != nil {
+		var err error
+		updatedAt, err = updateVersioned(tx, &book.Book{}, b.ID, b.Version, map[string]interface{}{
+			"title":       b.Title,
+			"author":      b.Author,
+			"description": b.Description,
+			"isbn":        b.ISBN,
+			"user_id":     b.UserID,
+			"state_id":    b.StateID,
+		})
+		if err != nil {
 			return err
 		}
 
-		// Обновляем связи с тегами
-		if err := tx.Model(b).Association("Tags").Replace(b.Tags); err != nil {
+		// Обновляем связи с тегами. Association.Replace здесь не подходит: он заново
+		// сохраняет книгу и перезаписывает ее время изменения
+		if err := tx.Exec("DELETE FROM book_tags WHERE book_id = ?", b.ID).Error; err != nil {
 			return err
 		}
+		for _, t := range b.Tags {
+			if err := tx.Exec("INSERT INTO book_tags (book_id, tag_id) VALUES (?, ?)", b.ID, t.ID).Error; err != nil {
+				return err
+			}
+		}
 
-		return nil
+		// Заменяем фотографии. Строка книги уже заблокирована обновлением выше
+		if photos == nil {
+			return nil
+		}
+		if err := tx.Where("book_id = ?", b.ID).Delete(&book.BookPhoto{}).Error; err != nil {
+			return err
+		}
+		return insertPhotos(tx, b.ID, photos, 0)
 	})
+	if err != nil {
+		return err
+	}
+
+	b.Version++
+	b.UpdatedAt = updatedAt
+	return nil
 }
 
-func (r *BookRepository) Delete(id uint) error {
+func (r *BookRepository) Delete(ctx context.Context, id, version uint) error {
 	// Проверяем существование книги
 	var b book.Book
-	if err := r.db.First(&b, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&b, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			logger.Error("Book not found", fmt.Errorf("book with ID %d not found", id))
+			logger.FromContext(ctx).Error("Book not found", fmt.Errorf("book with ID %d not found", id))
 			return errors.New("book not found")
 		}
-		logger.Error("Failed to check book existence", err)
+		logger.FromContext(ctx).Error("Failed to check book existence", err)
 		return err
 	}
 
-	// Удаляем книгу в транзакции
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	// Удаляем книгу в транзакции, только если ее не изменили после чтения
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkVersion(tx, &book.Book{}, id, version); err != nil {
+			return err
+		}
+
+		// Книгу, которую уже предложили или ждут в обмене, удалять нельзя.
+		// Завершенные и отмененные обмены сохраняются без книги
+		if err := checkNotInActiveTrade(tx, id); err != nil {
+			return err
+		}
+
+		// Удаляем связи с тегами
+		if err := tx.Exec("DELETE FROM book_tags WHERE book_id = ?", id).Error; err != nil {
+			return err
+		}
+
 		// Удаляем фотографии
 		if err := tx.Where("book_id = ?", id).Delete(&book.BookPhoto{}).Error; err != nil {
 			return err
 		}
 
+		// Удаляем совпадения с вишлистами
+		if err := tx.Where("book_id = ?", id).Delete(&wishlist.Match{}).Error; err != nil {
+			return err
+		}
+
 		// Удаляем книгу
 		if err := tx.Delete(&book.Book{}, id).Error; err != nil {
 			return err
@@ -225,17 +310,44 @@ func (r *BookRepository) Delete(id uint) error {
 	})
 }
 
-func (r *BookRepository) List() ([]*book.Book, error) {
+// checkNotInActiveTrade возвращает book.ErrBookInTrade, если книга участвует
+// в ожидающем или принятом обмене либо кольцевом обмене
+func checkNotInActiveTrade(tx *gorm.DB, bookID uint) error {
+	active := []trade.Status{trade.StatusPending, trade.StatusAccepted}
+
+	var trades int64
+	if err := tx.Model(&trade.Item{}).
+		Joins("JOIN trades ON trades.id = trade_items.trade_id").
+		Where("trade_items.book_id = ? AND trades.status IN ?", bookID, active).
+		Count(&trades).Error; err != nil {
+		return err
+	}
+
+	var cycles int64
+	if err := tx.Model(&trade.CycleItem{}).
+		Joins("JOIN trade_cycles ON trade_cycles.id = trade_cycle_items.cycle_id").
+		Where("trade_cycle_items.book_id = ? AND trade_cycles.status IN ?", bookID, active).
+		Count(&cycles).Error; err != nil {
+		return err
+	}
+
+	if trades+cycles > 0 {
+		return book.ErrBookInTrade
+	}
+	return nil
+}
+
+func (r *BookRepository) List(ctx context.Context) ([]*book.Book, error) {
 	var books []*book.Book
-	if err := r.db.Preload("Tags").Preload("State").Preload("User").Preload("Photos").Find(&books).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).Find(&books).Error; err != nil {
 		return nil, err
 	}
 	return books, nil
 }
 
-func (r *BookRepository) GetByTag(tagID uint) ([]*book.Book, error) {
+func (r *BookRepository) GetByTag(ctx context.Context, tagID uint) ([]*book.Book, error) {
 	var books []*book.Book
-	if err := r.db.Preload("Tags").Preload("State").Preload("User").
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").
 		Joins("JOIN book_tags ON book_tags.book_id = books.id").
 		Where("book_tags.tag_id = ?", tagID).
 		Find(&books).Error; err != nil {
@@ -244,20 +356,110 @@ func (r *BookRepository) GetByTag(tagID uint) ([]*book.Book, error) {
 	return books, nil
 }
 
-func (r *BookRepository) GetByTags(tagIDs []uint) ([]*book.Book, error) {
+func (r *BookRepository) GetByTags(ctx context.Context, tagIDs []uint) ([]*book.Book, error) {
 	var books []*book.Book
-	if err := r.db.Preload("Tags").Preload("State").Preload("User").Preload("Photos").
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).
 		Joins("JOIN book_tags ON books.id = book_tags.book_id").
 		Where("book_tags.tag_id IN ?", tagIDs).
 		Group("books.id").
 		Find(&books).Error; err != nil {
 		return nil, err
 	}
+	useThumbnails(books)
 	return books, nil
 }
 
-func (r *BookRepository) AddTags(bookID uint, tagIDs []uint) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+// Search ищет книги по полнотекстовому запросу и фильтрам и считает фасеты
+// по тегам и состояниям для всего найденного множества
+func (r *BookRepository) Search(ctx context.Context, params *book.SearchParams) (*book.SearchResult, error) {
+	filter := searchScope(params)
+	result := &book.SearchResult{Facets: &book.SearchFacets{}}
+
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).Scopes(filter).Count(&result.Total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count books: %w", err)
+	}
+
+	query := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).
+		Scopes(filter)
+	if params.Sort == book.SortRelevance && params.Query != "" {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  fulltextMatch + " DESC",
+			Vars: []interface{}{params.Query},
+		}})
+	}
+	offset := (params.Page - 1) * params.PageSize
+	if err := query.Order("books.created_at DESC").Order("books.id DESC").
+		Offset(offset).Limit(params.PageSize).
+		Find(&result.Books).Error; err != nil {
+		return nil, fmt.Errorf("failed to search books: %w", err)
+	}
+	useThumbnails(result.Books)
+
+	// Фасеты считаются по всем найденным книгам, а не только по текущей странице
+	matched := r.db.WithContext(ctx).Model(&book.Book{}).Scopes(filter).Select("books.id")
+
+	if err := r.db.WithContext(ctx).Table("book_tags").
+		Select("tags.id AS tag_id, tags.name AS name, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.id = book_tags.tag_id").
+		Where("book_tags.book_id IN (?)", matched).
+		Group("tags.id, tags.name").
+		Order("count DESC, tags.name").
+		Scan(&result.Facets.Tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tag facets: %w", err)
+	}
+
+	if err := r.db.WithContext(ctx).Table("books").
+		Select("states.id AS state_id, states.name AS name, COUNT(*) AS count").
+		Joins("JOIN states ON states.id = books.state_id").
+		Where("books.id IN (?)", matched).
+		Group("states.id, states.name").
+		Order("count DESC, states.name").
+		Scan(&result.Facets.States).Error; err != nil {
+		return nil, fmt.Errorf("failed to count state facets: %w", err)
+	}
+
+	return result, nil
+}
+
+// searchScope применяет фильтры поиска к запросу по таблице books
+func searchScope(params *book.SearchParams) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if params.Query != "" {
+			db = db.Where(fulltextMatch, params.Query)
+		}
+		if params.Author != "" {
+			db = db.Where("books.author LIKE ?", "%"+params.Author+"%")
+		}
+		if params.StateID != 0 {
+			db = db.Where("books.state_id = ?", params.StateID)
+		}
+		if params.UserID != 0 {
+			db = db.Where("books.user_id = ?", params.UserID)
+		}
+		if params.CreatedFrom != nil {
+			db = db.Where("books.created_at >= ?", *params.CreatedFrom)
+		}
+		if params.CreatedTo != nil {
+			db = db.Where("books.created_at <= ?", *params.CreatedTo)
+		}
+		if len(params.TagIDs) > 0 {
+			if params.TagMode == book.TagModeAnd {
+				// Книга должна содержать все перечисленные теги
+				db = db.Where("books.id IN (SELECT book_id FROM book_tags WHERE tag_id IN ? GROUP BY book_id HAVING COUNT(DISTINCT tag_id) = ?)",
+					params.TagIDs, len(params.TagIDs))
+			} else {
+				db = db.Where("books.id IN (SELECT book_id FROM book_tags WHERE tag_id IN ?)", params.TagIDs)
+			}
+		}
+		return db
+	}
+}
+
+func (r *BookRepository) AddTags(ctx context.Context, bookID uint, tagIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := touch(tx, &book.Book{}, bookID); err != nil {
+			return err
+		}
 		for _, tagID := range tagIDs {
 			if err := tx.Exec("INSERT INTO book_tags (book_id, tag_id) VALUES (?, ?)", bookID, tagID).Error; err != nil {
 				return err
@@ -267,49 +469,296 @@ func (r *BookRepository) AddTags(bookID uint, tagIDs []uint) error {
 	})
 }
 
-func (r *BookRepository) GetAll(page, pageSize int) ([]*book.Book, int64, error) {
+func (r *BookRepository) GetAll(ctx context.Context, page, pageSize int) ([]*book.Book, int64, error) {
 	var books []*book.Book
 	var total int64
 
-	if err := r.db.Model(&book.Book{}).Count(&total).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	if err := r.db.Preload("Tags").Preload("State").Preload("User").Preload("Photos").
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).
 		Offset(offset).Limit(pageSize).
 		Find(&books).Error; err != nil {
 		return nil, 0, err
 	}
+	useThumbnails(books)
 
 	return books, total, nil
 }
 
-func (r *BookRepository) GetUserBooks(userID uint, page, pageSize int) ([]*book.Book, int64, error) {
+func (r *BookRepository) GetUserBooks(ctx context.Context, userID uint, page, pageSize int) ([]*book.Book, int64, error) {
 	var books []*book.Book
 	var total int64
 
-	if err := r.db.Model(&book.Book{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	if err := r.db.Preload("Tags").Preload("State").Preload("User").Preload("Photos").
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).
 		Where("user_id = ?", userID).
 		Offset(offset).Limit(pageSize).
 		Find(&books).Error; err != nil {
 		return nil, 0, err
 	}
+	useThumbnails(books)
 
 	return books, total, nil
 }
 
-// CreatePhoto создает новую фотографию для книги
-func (r *BookRepository) CreatePhoto(photo *book.BookPhoto) error {
-	return r.db.Create(photo).Error
+// GetAllByCursor получает страницу книг после (или перед) позицией курсора
+func (r *BookRepository) GetAllByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*book.Book, bool, error) {
+	var books []*book.Book
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).
+		Scopes(keysetScope("books", c, limit)).
+		Find(&books).Error; err != nil {
+		return nil, false, err
+	}
+
+	books, hasMore := trimKeysetPage(books, c, limit)
+	useThumbnails(books)
+	return books, hasMore, nil
+}
+
+// GetUserBooksByCursor получает страницу книг пользователя после (или перед) позицией курсора
+func (r *BookRepository) GetUserBooksByCursor(ctx context.Context, userID uint, c *cursor.Cursor, limit int) ([]*book.Book, bool, error) {
+	var books []*book.Book
+	if err := r.db.WithContext(ctx).Preload("Tags").Preload("State").Preload("User").Preload("Photos", orderPhotos).
+		Where("books.user_id = ?", userID).
+		Scopes(keysetScope("books", c, limit)).
+		Find(&books).Error; err != nil {
+		return nil, false, err
+	}
+
+	books, hasMore := trimKeysetPage(books, c, limit)
+	useThumbnails(books)
+	return books, hasMore, nil
+}
+
+// GetByState получает все книги в указанном состоянии вместе с тегами
+func (r *BookRepository) GetByState(ctx context.Context, stateID uint) ([]*book.Book, error) {
+	var books []*book.Book
+	if err := r.db.WithContext(ctx).Preload("Tags").Where("state_id = ?", stateID).Find(&books).Error; err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// CountByState возвращает число книг в каждом состоянии по имени состояния
+func (r *BookRepository) CountByState(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Name  string
+		Count int64
+	}
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).
+		Select("states.name AS name, COUNT(*) AS count").
+		Joins("JOIN states ON states.id = books.state_id").
+		Group("states.name").
+		Scan(&rows).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count books by state", err)
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Name] = row.Count
+	}
+	return counts, nil
+}
+
+// GetPhotos получает фотографии книги в порядке галереи
+func (r *BookRepository) GetPhotos(ctx context.Context, bookID uint) ([]*book.BookPhoto, error) {
+	var photos []*book.BookPhoto
+	if err := orderPhotos(r.db.WithContext(ctx).Where("book_id = ?", bookID)).Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// CreatePhotos добавляет фотографии в конец галереи книги одной транзакцией.
+// Количество фотографий проверяется под блокировкой строки книги, поэтому
+// параллельные загрузки не могут превысить book.MaxPhotos
+func (r *BookRepository) CreatePhotos(ctx context.Context, bookID uint, photos []*book.BookPhoto, version uint) error {
+	if len(photos) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := lockPhotos(tx, bookID, version)
+		if err != nil {
+			return err
+		}
+		if len(existing)+len(photos) > book.MaxPhotos {
+			return book.ErrTooManyPhotos
+		}
+
+		mainCount := 0
+		for _, p := range photos {
+			if p.IsMain {
+				mainCount++
+			}
+		}
+		if mainCount > 1 {
+			return book.ErrMultipleMainPhotos
+		}
+
+		// Первая фотография книги всегда главная
+		if len(existing) == 0 && mainCount == 0 {
+			photos[0].IsMain = true
+		}
+		if mainCount > 0 {
+			if err := tx.Model(&book.BookPhoto{}).Where("book_id = ?", bookID).Update("is_main", false).Error; err != nil {
+				return err
+			}
+		}
+
+		return insertPhotos(tx, bookID, photos, len(existing))
+	})
+}
+
+// DeletePhoto удаляет фотографию книги. Если удалена главная фотография,
+// главной становится первая из оставшихся
+func (r *BookRepository) DeletePhoto(ctx context.Context, bookID, photoID, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		photos, err := lockPhotos(tx, bookID, version)
+		if err != nil {
+			return err
+		}
+
+		idx := findPhoto(photos, photoID)
+		if idx < 0 {
+			return book.ErrPhotoNotFound
+		}
+		deleted := photos[idx]
+		if err := tx.Delete(deleted).Error; err != nil {
+			return err
+		}
+
+		remaining := append(photos[:idx:idx], photos[idx+1:]...)
+		if deleted.IsMain && len(remaining) > 0 {
+			remaining[0].IsMain = true
+		}
+		return savePhotoOrder(tx, remaining)
+	})
+}
+
+// SetMainPhoto делает фотографию главной, снимая отметку с остальных
+func (r *BookRepository) SetMainPhoto(ctx context.Context, bookID, photoID, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		photos, err := lockPhotos(tx, bookID, version)
+		if err != nil {
+			return err
+		}
+		if findPhoto(photos, photoID) < 0 {
+			return book.ErrPhotoNotFound
+		}
+
+		for _, p := range photos {
+			p.IsMain = p.ID == photoID
+		}
+		return savePhotoOrder(tx, photos)
+	})
+}
+
+// ReorderPhotos меняет порядок фотографий. photoIDs должен содержать
+// все фотографии книги ровно по одному разу
+func (r *BookRepository) ReorderPhotos(ctx context.Context, bookID uint, photoIDs []uint, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		photos, err := lockPhotos(tx, bookID, version)
+		if err != nil {
+			return err
+		}
+		if len(photoIDs) != len(photos) {
+			return book.ErrInvalidPhotoOrder
+		}
+
+		ordered := make([]*book.BookPhoto, 0, len(photos))
+		for _, id := range photoIDs {
+			idx := findPhoto(photos, id)
+			if idx < 0 {
+				return book.ErrInvalidPhotoOrder
+			}
+			ordered = append(ordered, photos[idx])
+			// Исключаем фотографию, чтобы повторный ID не прошел проверку
+			photos = append(photos[:idx:idx], photos[idx+1:]...)
+		}
+		return savePhotoOrder(tx, ordered)
+	})
 }
 
 // DeletePhotos удаляет все фотографии книги
-func (r *BookRepository) DeletePhotos(bookID uint) error {
-	return r.db.Where("book_id = ?", bookID).Delete(&book.BookPhoto{}).Error
+func (r *BookRepository) DeletePhotos(ctx context.Context, bookID, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockPhotos(tx, bookID, version); err != nil {
+			return err
+		}
+		return tx.Where("book_id = ?", bookID).Delete(&book.BookPhoto{}).Error
+	})
+}
+
+// orderPhotos упорядочивает фотографии в порядке галереи
+func orderPhotos(db *gorm.DB) *gorm.DB {
+	return db.Order("book_photos.position, book_photos.id")
+}
+
+// lockPhotos блокирует строку книги до конца транзакции и возвращает ее фотографии.
+// Все изменения галереи книги выполняются последовательно. Фотографии входят в
+// представление книги, поэтому галерея меняется, только если версия книги все еще
+// равна version, и версия увеличивается
+func lockPhotos(tx *gorm.DB, bookID, version uint) ([]*book.BookPhoto, error) {
+	if _, err := updateVersioned(tx, &book.Book{}, bookID, version, map[string]interface{}{}); err != nil {
+		return nil, err
+	}
+
+	var photos []*book.BookPhoto
+	if err := orderPhotos(tx.Where("book_id = ?", bookID)).Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// insertPhotos сохраняет фотографии книги в порядке среза, начиная с позиции offset
+func insertPhotos(tx *gorm.DB, bookID uint, photos []*book.BookPhoto, offset int) error {
+	if len(photos) == 0 {
+		return nil
+	}
+	for i, p := range photos {
+		p.ID = 0
+		p.BookID = bookID
+		p.Position = offset + i
+	}
+	return tx.Create(&photos).Error
+}
+
+// findPhoto возвращает индекс фотографии с указанным ID или -1
+func findPhoto(photos []*book.BookPhoto, photoID uint) int {
+	for i, p := range photos {
+		if p.ID == photoID {
+			return i
+		}
+	}
+	return -1
+}
+
+// savePhotoOrder сохраняет позиции и отметку главной фотографии в порядке среза
+func savePhotoOrder(tx *gorm.DB, photos []*book.BookPhoto) error {
+	for i, p := range photos {
+		p.Position = i
+		if err := tx.Model(p).Select("position", "is_main").Updates(p).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// useThumbnails подменяет фотографии книг в списке на миниатюры.
+// Полноразмерные фотографии возвращает только GetByID
+func useThumbnails(books []*book.Book) {
+	for _, b := range books {
+		for _, p := range b.Photos {
+			p.UseThumbnail()
+		}
+	}
 }
@@ -0,0 +1,191 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/conversation"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+func NewConversationRepository(db *gorm.DB) repository.ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// Create сохраняет переписку с участниками и первым сообщением в одной транзакции
+func (r *ConversationRepository) Create(ctx context.Context, c *conversation.Conversation, first *conversation.Message) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c.LastMessageAt = time.Now()
+		if err := tx.Create(c).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to create conversation", err)
+			return fmt.Errorf("failed to create conversation: %w", err)
+		}
+
+		first.ConversationID = c.ID
+		return createMessage(tx, first)
+	})
+}
+
+// GetByID получает переписку по ID вместе с участниками
+func (r *ConversationRepository) GetByID(ctx context.Context, id uint) (*conversation.Conversation, error) {
+	var c conversation.Conversation
+	if err := r.db.WithContext(ctx).Preload("Participants.User").First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to get conversation by ID", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindByBook находит переписку о книге, в которой участвует пользователь
+func (r *ConversationRepository) FindByBook(ctx context.Context, bookID, userID uint) (*conversation.Conversation, error) {
+	var c conversation.Conversation
+	if err := r.db.WithContext(ctx).Preload("Participants.User").
+		Where("book_id = ?", bookID).
+		Where("id IN (?)", r.participantConversations(ctx, userID)).
+		First(&c).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to find book conversation", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FindByTrade находит переписку об обмене
+func (r *ConversationRepository) FindByTrade(ctx context.Context, tradeID uint) (*conversation.Conversation, error) {
+	var c conversation.Conversation
+	if err := r.db.WithContext(ctx).Preload("Participants.User").
+		Where("trade_id = ?", tradeID).
+		First(&c).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to find trade conversation", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetUserConversations получает переписки пользователя с пагинацией, начиная с самых свежих
+func (r *ConversationRepository) GetUserConversations(ctx context.Context, userID uint, page, pageSize int) ([]*conversation.Conversation, int64, error) {
+	var conversations []*conversation.Conversation
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&conversation.Conversation{}).
+		Where("id IN (?)", r.participantConversations(ctx, userID))
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count user conversations", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Participants.User").
+		Order("last_message_at DESC").Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&conversations).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get user conversations", err)
+		return nil, 0, err
+	}
+
+	return conversations, total, nil
+}
+
+// GetUnreadTotal получает общее число непрочитанных сообщений пользователя
+func (r *ConversationRepository) GetUnreadTotal(ctx context.Context, userID uint) (int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&conversation.Participant{}).
+		Select("COALESCE(SUM(unread_count), 0)").
+		Where("user_id = ?", userID).
+		Scan(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get unread messages count", err)
+		return 0, err
+	}
+	return total, nil
+}
+
+// CreateMessage сохраняет сообщение и увеличивает счетчики непрочитанных остальных участников
+func (r *ConversationRepository) CreateMessage(ctx context.Context, m *conversation.Message) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return createMessage(tx, m)
+	})
+}
+
+// GetMessagesByCursor получает страницу сообщений переписки после (или перед) позицией курсора.
+// Без курсора возвращаются последние сообщения: переписку читают с конца
+func (r *ConversationRepository) GetMessagesByCursor(ctx context.Context, conversationID uint, c *cursor.Cursor, limit int) ([]*conversation.Message, bool, error) {
+	var messages []*conversation.Message
+
+	query := r.db.WithContext(ctx).Where("messages.conversation_id = ?", conversationID)
+	page := c
+	if c == nil {
+		page = &cursor.Cursor{Backward: true}
+		query = query.Order("messages.created_at DESC").Order("messages.id DESC").Limit(limit + 1)
+	} else {
+		query = query.Scopes(keysetScope("messages", c, limit))
+	}
+
+	if err := query.Find(&messages).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get conversation messages", err)
+		return nil, false, err
+	}
+
+	messages, hasMore := trimKeysetPage(messages, page, limit)
+	return messages, hasMore, nil
+}
+
+// MarkRead сбрасывает счетчик непрочитанных сообщений участника
+func (r *ConversationRepository) MarkRead(ctx context.Context, conversationID, userID uint) error {
+	if err := r.db.WithContext(ctx).Model(&conversation.Participant{}).
+		Where("conversation_id = ? AND user_id = ?", conversationID, userID).
+		Updates(map[string]interface{}{
+			"unread_count": 0,
+			"last_read_at": time.Now(),
+		}).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to mark conversation as read", err)
+		return err
+	}
+	return nil
+}
+
+// participantConversations возвращает подзапрос ID переписок пользователя
+func (r *ConversationRepository) participantConversations(ctx context.Context, userID uint) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&conversation.Participant{}).Select("conversation_id").Where("user_id = ?", userID)
+}
+
+// createMessage сохраняет сообщение, обновляет время последнего сообщения переписки
+// и увеличивает счетчики непрочитанных всех участников, кроме отправителя
+func createMessage(tx *gorm.DB, m *conversation.Message) error {
+	if err := tx.Create(m).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to create message", err)
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+
+	if err := tx.Model(&conversation.Conversation{}).
+		Where("id = ?", m.ConversationID).
+		Update("last_message_at", m.CreatedAt).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to update conversation", err)
+		return err
+	}
+
+	if err := tx.Model(&conversation.Participant{}).
+		Where("conversation_id = ? AND user_id <> ?", m.ConversationID, m.SenderID).
+		Update("unread_count", gorm.Expr("unread_count + 1")).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to update unread counters", err)
+		return err
+	}
+	return nil
+}
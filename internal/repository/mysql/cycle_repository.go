@@ -0,0 +1,241 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateCycle сохраняет кольцевой обмен вместе с участниками и книгами
+func (r *TradeRepository) CreateCycle(ctx context.Context, c *trade.Cycle) error {
+	if err := r.db.WithContext(ctx).Create(c).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to create trade cycle", err)
+		return fmt.Errorf("failed to create trade cycle: %w", err)
+	}
+	return nil
+}
+
+// GetCycleByID получает кольцевой обмен по ID вместе с участниками и книгами
+func (r *TradeRepository) GetCycleByID(ctx context.Context, id uint) (*trade.Cycle, error) {
+	var c trade.Cycle
+	if err := preloadCycle(r.db).First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to get trade cycle by ID", err)
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetUserCycles получает кольцевые обмены, в которых участвует пользователь, с пагинацией
+func (r *TradeRepository) GetUserCycles(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Cycle, int64, error) {
+	var cycles []*trade.Cycle
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&trade.Cycle{}).
+		Where("id IN (?)", r.db.WithContext(ctx).Model(&trade.CycleParticipant{}).Select("cycle_id").Where("user_id = ?", userID))
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count user trade cycles", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := preloadCycle(query).
+		Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&cycles).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get user trade cycles", err)
+		return nil, 0, err
+	}
+
+	return cycles, total, nil
+}
+
+// GetPendingCycleBookIDs получает ID книг, уже предложенных в ожидающих кольцевых обменах
+func (r *TradeRepository) GetPendingCycleBookIDs(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&trade.CycleItem{}).
+		Joins("JOIN trade_cycles ON trade_cycles.id = trade_cycle_items.cycle_id").
+		Where("trade_cycles.status = ?", trade.StatusPending).
+		Pluck("trade_cycle_items.book_id", &ids).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get pending trade cycle books", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetCycleKeys получает ключи всех когда-либо предложенных кольцевых обменов
+func (r *TradeRepository) GetCycleKeys(ctx context.Context) ([]string, error) {
+	var keys []string
+	if err := r.db.WithContext(ctx).Model(&trade.Cycle{}).Distinct().Pluck("book_key", &keys).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get trade cycle keys", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// AcceptCycle отмечает согласие участника. Когда согласны все участники, кольцо
+// переводится в статус accepted и все его книги меняют состояние в той же транзакции
+func (r *TradeRepository) AcceptCycle(ctx context.Context, c *trade.Cycle, userID uint, change *trade.BookStateChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем кольцо: решения участников обрабатываются по очереди
+		var locked trade.Cycle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Participants").Preload("Items").
+			First(&locked, c.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != trade.StatusPending {
+			return trade.ErrStatusChanged
+		}
+
+		participant := locked.Participant(userID)
+		if participant == nil {
+			return fmt.Errorf("user %d is not a participant of trade cycle %d", userID, c.ID)
+		}
+		if participant.AcceptedAt == nil {
+			now := time.Now()
+			if err := tx.Model(participant).Update("accepted_at", now).Error; err != nil {
+				logger.FromContext(ctx).Error("Failed to accept trade cycle", err)
+				return err
+			}
+			participant.AcceptedAt = &now
+		}
+
+		if !locked.AllAccepted() {
+			*c = locked
+			return nil
+		}
+
+		if err := setCycleStatus(tx, c.ID, trade.StatusPending, trade.StatusAccepted); err != nil {
+			return err
+		}
+		locked.Status = trade.StatusAccepted
+
+		owners := make(map[uint]uint, len(locked.Items))
+		for _, item := range locked.Items {
+			if item.BookID == nil {
+				return trade.ErrBooksUnavailable
+			}
+			owners[*item.BookID] = item.FromUserID
+		}
+		if err := moveBooks(tx, owners, nil, change); err != nil {
+			return err
+		}
+
+		*c = locked
+		return cancelCompetingOffers(tx, locked.BookIDs(), 0, locked.ID)
+	})
+}
+
+// CompleteCycle отмечает, что участник получил книгу. Когда получение подтвердили
+// все участники, кольцо переводится в статус completed, а книги в той же транзакции
+// меняют состояние и переходят к получателям
+func (r *TradeRepository) CompleteCycle(ctx context.Context, c *trade.Cycle, userID uint, change *trade.BookStateChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем кольцо: подтверждения участников обрабатываются по очереди
+		var locked trade.Cycle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Participants").Preload("Items").
+			First(&locked, c.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != trade.StatusAccepted {
+			return trade.ErrStatusChanged
+		}
+
+		participant := locked.Participant(userID)
+		if participant == nil {
+			return fmt.Errorf("user %d is not a participant of trade cycle %d", userID, c.ID)
+		}
+		if participant.CompletedAt == nil {
+			now := time.Now()
+			if err := tx.Model(participant).Update("completed_at", now).Error; err != nil {
+				logger.FromContext(ctx).Error("Failed to confirm trade cycle completion", err)
+				return err
+			}
+			participant.CompletedAt = &now
+		}
+
+		if !locked.AllCompleted() {
+			*c = locked
+			return nil
+		}
+
+		if err := setCycleStatus(tx, c.ID, trade.StatusAccepted, trade.StatusCompleted); err != nil {
+			return err
+		}
+		locked.Status = trade.StatusCompleted
+
+		owners := make(map[uint]uint, len(locked.Items))
+		recipients := make(map[uint]uint, len(locked.Items))
+		for _, item := range locked.Items {
+			if item.BookID == nil {
+				return trade.ErrBooksUnavailable
+			}
+			owners[*item.BookID] = item.FromUserID
+			recipients[*item.BookID] = item.ToUserID
+		}
+		if err := moveBooks(tx, owners, recipients, change); err != nil {
+			return err
+		}
+
+		*c = locked
+		return nil
+	})
+}
+
+// UpdateCycleStatus переводит кольцевой обмен из статуса from в c.Status и, если задано,
+// меняет состояние всех его книг в той же транзакции
+func (r *TradeRepository) UpdateCycleStatus(ctx context.Context, c *trade.Cycle, from trade.Status, change *trade.BookStateChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := setCycleStatus(tx, c.ID, from, c.Status); err != nil {
+			return err
+		}
+
+		if change == nil {
+			return nil
+		}
+
+		owners := make(map[uint]uint, len(c.Items))
+		for _, item := range c.Items {
+			if item.BookID == nil {
+				return trade.ErrBooksUnavailable
+			}
+			owners[*item.BookID] = item.FromUserID
+		}
+		return moveBooks(tx, owners, nil, change)
+	})
+}
+
+// preloadCycle подгружает участников и книги кольцевого обмена
+func preloadCycle(db *gorm.DB) *gorm.DB {
+	return db.Preload("Participants.User").Preload("Items.Book.State")
+}
+
+// setCycleStatus атомарно меняет статус кольцевого обмена, если он все еще равен from
+func setCycleStatus(tx *gorm.DB, id uint, from, to trade.Status) error {
+	result := tx.Model(&trade.Cycle{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to update trade cycle status", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return trade.ErrStatusChanged
+	}
+	return nil
+}
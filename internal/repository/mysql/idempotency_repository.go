@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/idempotency"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) repository.IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Create сохраняет ключ, если у пользователя еще нет записи с таким ключом.
+// Возвращает false, если запись уже есть. Уникальный индекс гарантирует, что из
+// одновременных запросов с одним ключом запись создаст только один
+func (r *IdempotencyRepository) Create(ctx context.Context, rec *idempotency.Record) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(rec)
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("Failed to create idempotency key", result.Error)
+		return false, fmt.Errorf("failed to create idempotency key: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Get получает запись пользователя по ключу
+func (r *IdempotencyRepository) Get(ctx context.Context, userID uint, key string) (*idempotency.Record, error) {
+	var rec idempotency.Record
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ?", userID, key).First(&rec).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to get idempotency key", err)
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return &rec, nil
+}
+
+// Complete сохраняет ответ на запрос
+func (r *IdempotencyRepository) Complete(ctx context.Context, rec *idempotency.Record) error {
+	if err := r.db.WithContext(ctx).Model(rec).Select("status_code", "headers", "body").Updates(rec).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to save idempotent response", err)
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Delete удаляет запись, освобождая ключ
+func (r *IdempotencyRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&idempotency.Record{}, id).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to delete idempotency key", err)
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired удаляет записи с истекшим сроком хранения
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&idempotency.Record{}).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to delete expired idempotency keys", err)
+		return fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return nil
+}
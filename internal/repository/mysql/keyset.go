@@ -0,0 +1,49 @@
+package mysql
+
+import (
+	"booktrading/internal/pkg/cursor"
+
+	"gorm.io/gorm"
+)
+
+// keysetScope ограничивает выборку страницей после (или перед) позицией курсора
+// в порядке (created_at, id). Выбирается на одну запись больше limit, чтобы
+// определить, есть ли следующая страница
+func keysetScope(table string, c *cursor.Cursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		createdAt := table + ".created_at"
+		id := table + ".id"
+
+		if c != nil {
+			op := ">"
+			if c.Backward {
+				op = "<"
+			}
+			db = db.Where("("+createdAt+" "+op+" ? OR ("+createdAt+" = ? AND "+id+" "+op+" ?))",
+				c.CreatedAt, c.CreatedAt, c.ID)
+		}
+
+		if c != nil && c.Backward {
+			db = db.Order(createdAt + " DESC").Order(id + " DESC")
+		} else {
+			db = db.Order(createdAt + " ASC").Order(id + " ASC")
+		}
+
+		return db.Limit(limit + 1)
+	}
+}
+
+// trimKeysetPage отрезает лишнюю запись и восстанавливает порядок по возрастанию
+// для страницы, полученной при движении назад
+func trimKeysetPage[T any](items []T, c *cursor.Cursor, limit int) ([]T, bool) {
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if c != nil && c.Backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	return items, hasMore
+}
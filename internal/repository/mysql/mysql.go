@@ -2,19 +2,20 @@ package mysql
 
 import (
 	"booktrading/internal/config"
-	"booktrading/internal/domain/book"
-	"booktrading/internal/domain/state"
-	"booktrading/internal/domain/tag"
-	"booktrading/internal/domain/token"
-	"booktrading/internal/domain/user"
 	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/metrics"
+	"booktrading/internal/pkg/migrate"
+	"booktrading/internal/pkg/tracing"
+	"booktrading/migrations"
+	"context"
 	"fmt"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-// InitDB инициализирует подключение к базе данных и выполняет миграции
+// InitDB инициализирует подключение к базе данных и проверяет, что к ней применены
+// все миграции. Схема не изменяется: миграции применяет команда cmd/migrate
 func InitDB(dsn string) (*gorm.DB, error) {
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	if err != nil {
@@ -22,21 +23,30 @@ func InitDB(dsn string) (*gorm.DB, error) {
 		return nil, err
 	}
 
-	// Автоматическая миграция моделей
-	err = db.AutoMigrate(
-		&user.User{},
-		&book.Book{},
-		&book.BookPhoto{},
-		&tag.Tag{},
-		&state.State{},
-		&token.RefreshToken{},
-	)
+	// Длительность и ошибки запросов публикуются в метриках
+	if err := db.Use(metrics.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register metrics plugin: %w", err)
+	}
+	// Каждый SQL запрос попадает в трассу вызвавшего его HTTP запроса
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	sqlDB, err := db.DB()
 	if err != nil {
-		logger.Error("Failed to migrate database", err)
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+
+	all, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate.New(sqlDB, all).Check(context.Background()); err != nil {
+		logger.Error("Database schema is not up to date, run: go run ./cmd/migrate up", err)
 		return nil, err
 	}
 
-	logger.Info("Database migration completed successfully")
+	logger.Info("Database schema is up to date")
 	return db, nil
 }
 
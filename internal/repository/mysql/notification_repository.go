@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) repository.NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create сохраняет уведомление
+func (r *NotificationRepository) Create(ctx context.Context, n *notification.Notification) error {
+	if err := r.db.WithContext(ctx).Create(n).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to create notification", err)
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// GetUserNotifications получает уведомления пользователя с пагинацией, новые первыми
+func (r *NotificationRepository) GetUserNotifications(ctx context.Context, userID uint, unreadOnly bool, page, pageSize int) ([]*notification.Notification, int64, error) {
+	var notifications []*notification.Notification
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&notification.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count notifications", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&notifications).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get notifications", err)
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// GetUnreadAfter получает непрочитанные уведомления пользователя с ID больше afterID в порядке создания
+func (r *NotificationRepository) GetUnreadAfter(ctx context.Context, userID, afterID uint, limit int) ([]*notification.Notification, error) {
+	var notifications []*notification.Notification
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND read_at IS NULL AND id > ?", userID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&notifications).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get unread notifications", err)
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// CountUnread получает число непрочитанных уведомлений пользователя
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&notification.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count unread notifications", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id uint) error {
+	var n notification.Notification
+	if err := r.db.WithContext(ctx).Select("id").Where("id = ? AND user_id = ?", id, userID).Take(&n).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to get notification", err)
+		return err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&notification.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", time.Now()).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to mark notification as read", err)
+		return err
+	}
+	return nil
+}
+
+// MarkAllRead отмечает все уведомления пользователя прочитанными
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).Model(&notification.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to mark notifications as read", err)
+		return err
+	}
+	return nil
+}
@@ -1,22 +1,16 @@
 package mysql
 
 import (
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/token"
 	"booktrading/internal/pkg/logger"
+	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
 	"time"
 )
 
-type RefreshToken struct {
-	ID        uint      `gorm:"primarykey"`
-	UserID    uint      `gorm:"not null"`
-	Token     string    `gorm:"type:varchar(255);not null;unique"`
-	ExpiresAt time.Time `gorm:"not null"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-}
-
 type RefreshTokenRepository struct {
 	db *gorm.DB
 }
@@ -25,56 +19,66 @@ func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
 	return &RefreshTokenRepository{db: db}
 }
 
-func (r *RefreshTokenRepository) Save(userID uint, token string, expiresAt time.Time) error {
-	refreshToken := &RefreshToken{
-		UserID:    userID,
-		Token:     token,
-		ExpiresAt: expiresAt,
-	}
-
-	if err := r.db.Create(refreshToken).Error; err != nil {
-		logger.Error("Failed to save refresh token", err)
+func (r *RefreshTokenRepository) Save(ctx context.Context, t *token.RefreshToken) error {
+	if err := r.db.WithContext(ctx).Create(t).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to save refresh token", err)
 		return fmt.Errorf("failed to save refresh token: %w", err)
 	}
 
 	return nil
 }
 
-func (r *RefreshTokenRepository) Validate(token string) (uint, error) {
-	var refreshToken RefreshToken
-	if err := r.db.Where("token = ? AND expires_at > ?", token, time.Now()).First(&refreshToken).Error; err != nil {
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*token.RefreshToken, error) {
+	var refreshToken token.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token = ?", tokenHash).First(&refreshToken).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return 0, errors.New("refresh token not found or expired")
+			return nil, repository.ErrNotFound
 		}
-		logger.Error("Failed to validate refresh token", err)
-		return 0, fmt.Errorf("failed to validate refresh token: %w", err)
+		logger.FromContext(ctx).Error("Failed to get refresh token", err)
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return &refreshToken, nil
+}
+
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&token.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		logger.FromContext(ctx).Error("Failed to mark refresh token as used", result.Error)
+		return false, fmt.Errorf("failed to mark refresh token as used: %w", result.Error)
 	}
 
-	return refreshToken.UserID, nil
+	return result.RowsAffected == 1, nil
 }
 
-func (r *RefreshTokenRepository) Delete(token string) error {
-	if err := r.db.Where("token = ?", token).Delete(&RefreshToken{}).Error; err != nil {
-		logger.Error("Failed to delete refresh token", err)
-		return fmt.Errorf("failed to delete refresh token: %w", err)
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	if err := r.db.WithContext(ctx).Model(&token.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to revoke refresh token family", err)
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
 	}
 
 	return nil
 }
 
-func (r *RefreshTokenRepository) DeleteExpired() error {
-	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&RefreshToken{}).Error; err != nil {
-		logger.Error("Failed to delete expired refresh tokens", err)
+func (r *RefreshTokenRepository) DeleteExpired(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&token.RefreshToken{}).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to delete expired refresh tokens", err)
 		return fmt.Errorf("failed to delete expired refresh tokens: %w", err)
 	}
 
 	return nil
 }
 
-func (r *RefreshTokenRepository) DeleteUserTokens(userID uint) error {
-	if err := r.db.Where("user_id = ?", userID).Delete(&RefreshToken{}).Error; err != nil {
-		logger.Error("Failed to delete user's refresh tokens", err)
-		return fmt.Errorf("failed to delete user's refresh tokens: %w", err)
+func (r *RefreshTokenRepository) RevokeUserTokens(ctx context.Context, userID uint) error {
+	if err := r.db.WithContext(ctx).Model(&token.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to revoke user's refresh tokens", err)
+		return fmt.Errorf("failed to revoke user's refresh tokens: %w", err)
 	}
 
 	return nil
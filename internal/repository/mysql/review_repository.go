@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/review"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type ReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) repository.ReviewRepository {
+	return &ReviewRepository{db: db}
+}
+
+// Create сохраняет отзыв. Если автор уже оставил отзыв на этот обмен,
+// возвращается review.ErrAlreadyReviewed
+func (r *ReviewRepository) Create(ctx context.Context, rv *review.Review) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&review.Review{}).
+			Where("trade_id = ? AND reviewer_id = ?", rv.TradeID, rv.ReviewerID).
+			Count(&count).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to check existing review", err)
+			return err
+		}
+		if count > 0 {
+			return review.ErrAlreadyReviewed
+		}
+
+		if err := tx.Create(rv).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to create review", err)
+			return fmt.Errorf("failed to create review: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetUserReviews получает отзывы о пользователе с пагинацией, новые первыми
+func (r *ReviewRepository) GetUserReviews(ctx context.Context, userID uint, page, pageSize int) ([]*review.Review, int64, error) {
+	var reviews []*review.Review
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&review.Review{}).Where("reviewee_id = ?", userID)
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count user reviews", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Reviewer").
+		Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&reviews).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get user reviews", err)
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+// GetRating получает среднюю оценку и число отзывов о пользователе
+func (r *ReviewRepository) GetRating(ctx context.Context, userID uint) (float64, int64, error) {
+	var result struct {
+		Rating float64
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&review.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS rating, COUNT(*) AS count").
+		Where("reviewee_id = ?", userID).
+		Scan(&result).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get user rating", err)
+		return 0, 0, err
+	}
+	return result.Rating, result.Count, nil
+}
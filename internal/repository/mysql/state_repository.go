@@ -1,11 +1,14 @@
 package mysql
 
 import (
+	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/state"
 	"booktrading/internal/pkg/logger"
+	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StateRepository struct {
@@ -16,75 +19,97 @@ func NewStateRepository(db *gorm.DB) *StateRepository {
 	return &StateRepository{db: db}
 }
 
-func (r *StateRepository) Create(s *state.State) error {
-	logger.Info("Creating state in repository with name: " + s.Name)
+func (r *StateRepository) Create(ctx context.Context, s *state.State) error {
+	logger.FromContext(ctx).Debug("Creating state in repository", "name", s.Name)
 
 	var count int64
-	if err := r.db.Model(&state.State{}).Where("name = ?", s.Name).Count(&count).Error; err != nil {
-		logger.Error("Failed to check state name uniqueness", err)
+	if err := r.db.WithContext(ctx).Model(&state.State{}).Where("name = ?", s.Name).Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to check state name uniqueness", err)
 		return err
 	}
 	if count > 0 {
 		return errors.New("state name must be unique")
 	}
 
-	if err := r.db.Create(s).Error; err != nil {
-		logger.Error("Failed to create state in database", err)
+	s.Version = 1
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Create(s).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to create state in database", err)
 		return err
 	}
 
-	logger.Info("State created successfully in database with ID: " + fmt.Sprintf("%d", s.ID))
+	logger.FromContext(ctx).Debug("State created in database", "state_id", s.ID)
 	return nil
 }
 
-func (r *StateRepository) GetByID(id uint) (*state.State, error) {
+func (r *StateRepository) GetByID(ctx context.Context, id uint) (*state.State, error) {
 	var s state.State
-	if err := r.db.First(&s, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Transitions.ToState").First(&s, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			logger.Error("State not found", fmt.Errorf("state with ID %d not found", id))
+			logger.FromContext(ctx).Error("State not found", fmt.Errorf("state with ID %d not found", id))
 			return nil, errors.New("state not found")
 		}
-		logger.Error("Failed to get state by ID", err)
+		logger.FromContext(ctx).Error("Failed to get state by ID", err)
 		return nil, err
 	}
 	return &s, nil
 }
 
-func (r *StateRepository) GetAll() ([]*state.State, error) {
+func (r *StateRepository) GetByName(ctx context.Context, name string) (*state.State, error) {
+	var s state.State
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&s).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			logger.FromContext(ctx).Error("State not found", fmt.Errorf("state with name %s not found", name))
+			return nil, errors.New("state not found")
+		}
+		logger.FromContext(ctx).Error("Failed to get state by name", err)
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *StateRepository) GetAll(ctx context.Context) ([]*state.State, error) {
 	var states []*state.State
-	if err := r.db.Find(&states).Error; err != nil {
-		logger.Error("Failed to get all states", err)
+	if err := r.db.WithContext(ctx).Preload("Transitions.ToState").Find(&states).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get all states", err)
 		return nil, err
 	}
 	return states, nil
 }
 
-func (r *StateRepository) Update(s *state.State) error {
+func (r *StateRepository) Update(ctx context.Context, s *state.State) error {
 	var count int64
-	if err := r.db.Model(&state.State{}).
+	if err := r.db.WithContext(ctx).Model(&state.State{}).
 		Where("name = ? AND id != ?", s.Name, s.ID).
 		Count(&count).Error; err != nil {
-		logger.Error("Failed to check state name uniqueness", err)
+		logger.FromContext(ctx).Error("Failed to check state name uniqueness", err)
 		return err
 	}
 	if count > 0 {
 		return errors.New("state name must be unique")
 	}
 
-	if err := r.db.Save(s).Error; err != nil {
-		logger.Error("Failed to update state", err)
+	updatedAt, err := updateVersioned(r.db.WithContext(ctx), &state.State{}, s.ID, s.Version, map[string]interface{}{
+		"name": s.Name,
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) && !errors.Is(err, repository.ErrVersionConflict) {
+			logger.FromContext(ctx).Error("Failed to update state", err)
+		}
 		return err
 	}
+
+	s.Version++
+	s.UpdatedAt = updatedAt
 	return nil
 }
 
-func (r *StateRepository) Delete(id uint) error {
+func (r *StateRepository) Delete(ctx context.Context, id, version uint) error {
 	var count int64
-	if err := r.db.Model(&state.State{}).
+	if err := r.db.WithContext(ctx).Model(&state.State{}).
 		Joins("JOIN books ON books.state_id = states.id").
 		Where("states.id = ?", id).
 		Count(&count).Error; err != nil {
-		logger.Error("Failed to check state usage in books", err)
+		logger.FromContext(ctx).Error("Failed to check state usage in books", err)
 		return err
 	}
 
@@ -92,9 +117,114 @@ func (r *StateRepository) Delete(id uint) error {
 		return errors.New("cannot delete state: it is used in books")
 	}
 
-	if err := r.db.Delete(&state.State{}, id).Error; err != nil {
-		logger.Error("Failed to delete state", err)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkVersion(tx, &state.State{}, id, version); err != nil {
+			return err
+		}
+
+		// Удаляем все переходы, связанные с состоянием
+		if err := tx.Where("from_state_id = ? OR to_state_id = ?", id, id).Delete(&state.Transition{}).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to delete state transitions", err)
+			return err
+		}
+
+		if err := tx.Delete(&state.State{}, id).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to delete state", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// GetTransitions получает разрешенные переходы из состояния
+func (r *StateRepository) GetTransitions(ctx context.Context, fromStateID uint) ([]*state.Transition, error) {
+	var transitions []*state.Transition
+	if err := r.db.WithContext(ctx).Preload("ToState").
+		Where("from_state_id = ?", fromStateID).
+		Find(&transitions).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get state transitions", err)
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// AddTransition добавляет разрешенный переход между состояниями
+func (r *StateRepository) AddTransition(ctx context.Context, t *state.Transition, version uint) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&state.State{}).
+		Where("id IN ?", []uint{t.FromStateID, t.ToStateID}).
+		Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to check states existence", err)
+		return err
+	}
+	if (t.FromStateID == t.ToStateID && count != 1) || (t.FromStateID != t.ToStateID && count != 2) {
+		return errors.New("state not found")
+	}
+
+	if err := r.db.WithContext(ctx).Model(&state.Transition{}).
+		Where("from_state_id = ? AND to_state_id = ?", t.FromStateID, t.ToStateID).
+		Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to check transition uniqueness", err)
 		return err
 	}
+	if count > 0 {
+		return errors.New("transition already exists")
+	}
+
+	// Переходы входят в представление исходного состояния, поэтому переход добавляется,
+	// только если версия состояния все еще равна version, и версия увеличивается
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := updateVersioned(tx, &state.State{}, t.FromStateID, version, map[string]interface{}{}); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(t).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to create state transition", err)
+			return err
+		}
+		return nil
+	})
+}
+
+// DeleteTransition удаляет разрешенный переход между состояниями, если версия
+// исходного состояния все еще равна version
+func (r *StateRepository) DeleteTransition(ctx context.Context, fromStateID, toStateID, version uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := updateVersioned(tx, &state.State{}, fromStateID, version, map[string]interface{}{}); err != nil {
+			return err
+		}
+		result := tx.Where("from_state_id = ? AND to_state_id = ?", fromStateID, toStateID).Delete(&state.Transition{})
+		if result.Error != nil {
+			logger.FromContext(ctx).Error("Failed to delete state transition", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// CheckTransition проверяет, что переход между состояниями разрешен
+func (r *StateRepository) CheckTransition(ctx context.Context, fromStateID, toStateID uint) error {
+	return checkStateTransition(r.db.WithContext(ctx), fromStateID, toStateID)
+}
+
+// checkStateTransition возвращает *state.TransitionError, если перехода нет в таблице
+// разрешенных переходов. Переход в то же самое состояние всегда разрешен.
+func checkStateTransition(db *gorm.DB, fromStateID, toStateID uint) error {
+	if fromStateID == toStateID {
+		return nil
+	}
+
+	var count int64
+	if err := db.Model(&state.Transition{}).
+		Where("from_state_id = ? AND to_state_id = ?", fromStateID, toStateID).
+		Count(&count).Error; err != nil {
+		logger.FromContext(db.Statement.Context).Error("Failed to check state transition", err)
+		return err
+	}
+	if count == 0 {
+		return &state.TransitionError{FromStateID: fromStateID, ToStateID: toStateID}
+	}
 	return nil
 }
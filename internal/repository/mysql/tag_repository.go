@@ -3,6 +3,7 @@ package mysql
 import (
 	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/tag"
+	"context"
 	"errors"
 	"fmt"
 
@@ -17,9 +18,9 @@ func NewTagRepository(db *gorm.DB) repository.TagRepository {
 	return &TagRepository{db: db}
 }
 
-func (r *TagRepository) Create(t *tag.Tag) error {
+func (r *TagRepository) Create(ctx context.Context, t *tag.Tag) error {
 	// Проверяем существование тега с таким именем
-	existingTag, err := r.GetByName(t.Name)
+	existingTag, err := r.GetByName(ctx, t.Name)
 	if err != nil {
 		return fmt.Errorf("failed to check tag existence: %w", err)
 	}
@@ -30,20 +31,24 @@ func (r *TagRepository) Create(t *tag.Tag) error {
 	}
 
 	// Создаем новый тег
-	return r.db.Create(t).Error
+	t.Version = 1
+	return r.db.WithContext(ctx).Create(t).Error
 }
 
-func (r *TagRepository) GetByID(id uint) (*tag.Tag, error) {
+func (r *TagRepository) GetByID(ctx context.Context, id uint) (*tag.Tag, error) {
 	var t tag.Tag
-	if err := r.db.First(&t, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&t, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, repository.ErrNotFound
+		}
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (r *TagRepository) GetByName(name string) (*tag.Tag, error) {
+func (r *TagRepository) GetByName(ctx context.Context, name string) (*tag.Tag, error) {
 	var t tag.Tag
-	if err := r.db.Where("name = ?", name).First(&t).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&t).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Возвращаем nil вместо ошибки, если тег не найден
 		}
@@ -52,20 +57,20 @@ func (r *TagRepository) GetByName(name string) (*tag.Tag, error) {
 	return &t, nil
 }
 
-func (r *TagRepository) GetAll() ([]*tag.Tag, error) {
+func (r *TagRepository) GetAll(ctx context.Context) ([]*tag.Tag, error) {
 	var tags []*tag.Tag
-	if err := r.db.Find(&tags).Error; err != nil {
+	if err := r.db.WithContext(ctx).Find(&tags).Error; err != nil {
 		return nil, err
 	}
 	return tags, nil
 }
 
-func (r *TagRepository) GetPopular(limit int) ([]*tag.TagWithCount, error) {
+func (r *TagRepository) GetPopular(ctx context.Context, limit int) ([]*tag.TagWithCount, error) {
 	var results []struct {
 		tag.Tag
 		BookCount int64
 	}
-	if err := r.db.Model(&tag.Tag{}).
+	if err := r.db.WithContext(ctx).Model(&tag.Tag{}).
 		Select("tags.*, COUNT(book_tags.book_id) as book_count").
 		Joins("LEFT JOIN book_tags ON book_tags.tag_id = tags.id").
 		Group("tags.id").
@@ -86,14 +91,24 @@ func (r *TagRepository) GetPopular(limit int) ([]*tag.TagWithCount, error) {
 	return tagsWithCount, nil
 }
 
-func (r *TagRepository) Update(t *tag.Tag) error {
-	return r.db.Save(t).Error
+func (r *TagRepository) Update(ctx context.Context, t *tag.Tag) error {
+	updatedAt, err := updateVersioned(r.db.WithContext(ctx), &tag.Tag{}, t.ID, t.Version, map[string]interface{}{
+		"name":  t.Name,
+		"photo": t.Photo,
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Version++
+	t.UpdatedAt = updatedAt
+	return nil
 }
 
-func (r *TagRepository) Delete(id uint) error {
+func (r *TagRepository) Delete(ctx context.Context, id, version uint) error {
 	// Проверяем, используется ли тег в книгах
 	var count int64
-	if err := r.db.Model(&tag.Tag{}).
+	if err := r.db.WithContext(ctx).Model(&tag.Tag{}).
 		Joins("JOIN book_tags ON book_tags.tag_id = tags.id").
 		Where("tags.id = ?", id).
 		Count(&count).Error; err != nil {
@@ -104,5 +119,10 @@ func (r *TagRepository) Delete(id uint) error {
 		return errors.New("cannot delete tag: it is used in books")
 	}
 
-	return r.db.Delete(&tag.Tag{}, id).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkVersion(tx, &tag.Tag{}, id, version); err != nil {
+			return err
+		}
+		return tx.Delete(&tag.Tag{}, id).Error
+	})
 }
@@ -0,0 +1,314 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TradeRepository struct {
+	db *gorm.DB
+}
+
+func NewTradeRepository(db *gorm.DB) repository.TradeRepository {
+	return &TradeRepository{db: db}
+}
+
+// Create сохраняет новое предложение обмена вместе с его позициями
+func (r *TradeRepository) Create(ctx context.Context, t *trade.Trade) error {
+	if err := r.db.WithContext(ctx).Create(t).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to create trade", err)
+		return fmt.Errorf("failed to create trade: %w", err)
+	}
+	return nil
+}
+
+// CreateCounter помечает исходное предложение как встречное и сохраняет новое в одной транзакции
+func (r *TradeRepository) CreateCounter(ctx context.Context, original *trade.Trade, counter *trade.Trade) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := setTradeStatus(tx, original.ID, trade.StatusPending, trade.StatusCountered); err != nil {
+			return err
+		}
+		original.Status = trade.StatusCountered
+
+		if err := tx.Create(counter).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to create counter trade", err)
+			return fmt.Errorf("failed to create counter trade: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetByID получает обмен по ID вместе с участниками и книгами
+func (r *TradeRepository) GetByID(ctx context.Context, id uint) (*trade.Trade, error) {
+	var t trade.Trade
+	if err := r.db.WithContext(ctx).Preload("Proposer").Preload("Recipient").
+		Preload("Items.Book.State").
+		First(&t, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.FromContext(ctx).Error("Failed to get trade by ID", err)
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetUserTrades получает обмены, в которых участвует пользователь, с пагинацией
+func (r *TradeRepository) GetUserTrades(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Trade, int64, error) {
+	var trades []*trade.Trade
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&trade.Trade{}).Where("proposer_id = ? OR recipient_id = ?", userID, userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count user trades", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Proposer").Preload("Recipient").
+		Preload("Items.Book.State").
+		Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&trades).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get user trades", err)
+		return nil, 0, err
+	}
+
+	return trades, total, nil
+}
+
+// CountCompleted получает число завершенных обменов пользователя, включая кольцевые
+func (r *TradeRepository) CountCompleted(ctx context.Context, userID uint) (int64, error) {
+	var trades, cycles int64
+	if err := r.db.WithContext(ctx).Model(&trade.Trade{}).
+		Where("(proposer_id = ? OR recipient_id = ?) AND status = ?", userID, userID, trade.StatusCompleted).
+		Count(&trades).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count completed trades", err)
+		return 0, err
+	}
+	if err := r.db.WithContext(ctx).Model(&trade.Cycle{}).
+		Where("id IN (?)", r.db.WithContext(ctx).Model(&trade.CycleParticipant{}).Select("cycle_id").Where("user_id = ?", userID)).
+		Where("status = ?", trade.StatusCompleted).
+		Count(&cycles).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count completed trade cycles", err)
+		return 0, err
+	}
+	return trades + cycles, nil
+}
+
+// CountByStatus возвращает число обменов в каждом статусе
+func (r *TradeRepository) CountByStatus(ctx context.Context) (map[trade.Status]int64, error) {
+	var rows []struct {
+		Status trade.Status
+		Count  int64
+	}
+	if err := r.db.WithContext(ctx).Model(&trade.Trade{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count trades by status", err)
+		return nil, err
+	}
+
+	counts := make(map[trade.Status]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// UpdateStatus переводит обмен из статуса from в t.Status и, если задано,
+// меняет состояние всех книг обмена в той же транзакции
+func (r *TradeRepository) UpdateStatus(ctx context.Context, t *trade.Trade, from trade.Status, change *trade.BookStateChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := setTradeStatus(tx, t.ID, from, t.Status); err != nil {
+			return err
+		}
+
+		if change == nil {
+			return nil
+		}
+
+		owners := make(map[uint]uint, len(t.Items))
+		for _, item := range t.Items {
+			if item.BookID == nil {
+				return trade.ErrBooksUnavailable
+			}
+			owners[*item.BookID] = item.FromUserID
+		}
+		if err := moveBooks(tx, owners, nil, change); err != nil {
+			return err
+		}
+
+		// Принятый обмен делает остальные ожидающие предложения с этими книгами неактуальными
+		if t.Status == trade.StatusAccepted {
+			return cancelCompetingOffers(tx, t.BookIDs(), t.ID, 0)
+		}
+
+		return nil
+	})
+}
+
+// CompleteTrade отмечает, что участник получил книги. Когда получение подтвердили
+// оба участника, обмен переводится в статус completed, а книги в той же транзакции
+// меняют состояние и переходят к получателям
+func (r *TradeRepository) CompleteTrade(ctx context.Context, t *trade.Trade, userID uint, change *trade.BookStateChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокируем обмен: подтверждения участников обрабатываются по очереди
+		var locked trade.Trade
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&locked, t.ID).Error; err != nil {
+			return err
+		}
+		if locked.Status != trade.StatusAccepted {
+			return trade.ErrStatusChanged
+		}
+
+		var completedAt **time.Time
+		var column string
+		switch userID {
+		case locked.ProposerID:
+			completedAt, column = &locked.ProposerCompletedAt, "proposer_completed_at"
+		case locked.RecipientID:
+			completedAt, column = &locked.RecipientCompletedAt, "recipient_completed_at"
+		default:
+			return fmt.Errorf("user %d is not a participant of trade %d", userID, t.ID)
+		}
+		if *completedAt == nil {
+			now := time.Now()
+			if err := tx.Model(&trade.Trade{}).Where("id = ?", t.ID).Update(column, now).Error; err != nil {
+				logger.FromContext(ctx).Error("Failed to confirm trade completion", err)
+				return err
+			}
+			*completedAt = &now
+		}
+
+		if !locked.BothCompleted() {
+			*t = locked
+			return nil
+		}
+
+		if err := setTradeStatus(tx, t.ID, trade.StatusAccepted, trade.StatusCompleted); err != nil {
+			return err
+		}
+		locked.Status = trade.StatusCompleted
+
+		owners := make(map[uint]uint, len(locked.Items))
+		recipients := make(map[uint]uint, len(locked.Items))
+		for _, item := range locked.Items {
+			if item.BookID == nil {
+				return trade.ErrBooksUnavailable
+			}
+			owners[*item.BookID] = item.FromUserID
+			recipients[*item.BookID] = item.ToUserID
+		}
+		if err := moveBooks(tx, owners, recipients, change); err != nil {
+			return err
+		}
+
+		*t = locked
+		return nil
+	})
+}
+
+// moveBooks блокирует книги, убеждается, что они все еще у прежних владельцев
+// (owners: ID книги -> ID владельца) и находятся в change.FromStateID,
+// и переводит их в change.ToStateID. Если задан recipients (ID книги -> ID
+// нового владельца), книги также передаются новым владельцам
+func moveBooks(tx *gorm.DB, owners, recipients map[uint]uint, change *trade.BookStateChange) error {
+	if err := checkStateTransition(tx, change.FromStateID, change.ToStateID); err != nil {
+		return err
+	}
+
+	bookIDs := make([]uint, 0, len(owners))
+	for id := range owners {
+		bookIDs = append(bookIDs, id)
+	}
+
+	var books []*book.Book
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", bookIDs).
+		Find(&books).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to lock trade books", err)
+		return err
+	}
+	if len(books) != len(owners) {
+		return trade.ErrBooksUnavailable
+	}
+	for _, b := range books {
+		if b.StateID != change.FromStateID || b.UserID != owners[b.ID] {
+			return trade.ErrBooksUnavailable
+		}
+	}
+
+	if err := tx.Model(&book.Book{}).
+		Where("id IN ?", bookIDs).
+		Updates(map[string]interface{}{
+			"state_id": change.ToStateID,
+			"version":  gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to update trade books state", err)
+		return err
+	}
+
+	// У книг обмена разные получатели, поэтому владелец меняется для каждой книги отдельно
+	for bookID, userID := range recipients {
+		if err := tx.Model(&book.Book{}).
+			Where("id = ?", bookID).
+			Update("user_id", userID).Error; err != nil {
+			logger.FromContext(tx.Statement.Context).Error("Failed to transfer trade book", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelCompetingOffers отменяет ожидающие обмены и кольцевые обмены с указанными книгами,
+// кроме обмена exceptTradeID и кольца exceptCycleID
+func cancelCompetingOffers(tx *gorm.DB, bookIDs []uint, exceptTradeID, exceptCycleID uint) error {
+	if err := tx.Model(&trade.Trade{}).
+		Where("status = ? AND id <> ?", trade.StatusPending, exceptTradeID).
+		Where("id IN (?)", tx.Model(&trade.Item{}).Select("trade_id").Where("book_id IN ?", bookIDs)).
+		Update("status", trade.StatusCancelled).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to cancel competing trades", err)
+		return err
+	}
+
+	if err := tx.Model(&trade.Cycle{}).
+		Where("status = ? AND id <> ?", trade.StatusPending, exceptCycleID).
+		Where("id IN (?)", tx.Model(&trade.CycleItem{}).Select("cycle_id").Where("book_id IN ?", bookIDs)).
+		Update("status", trade.StatusCancelled).Error; err != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to cancel competing trade cycles", err)
+		return err
+	}
+	return nil
+}
+
+// setTradeStatus атомарно меняет статус обмена, если он все еще равен from
+func setTradeStatus(tx *gorm.DB, id uint, from, to trade.Status) error {
+	result := tx.Model(&trade.Trade{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		logger.FromContext(tx.Statement.Context).Error("Failed to update trade status", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return trade.ErrStatusChanged
+	}
+	return nil
+}
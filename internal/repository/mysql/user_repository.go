@@ -4,7 +4,9 @@ import (
 	"booktrading/internal/domain/book"
 	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/cursor"
 	"booktrading/internal/pkg/logger"
+	"context"
 	"errors"
 	"fmt"
 
@@ -20,11 +22,11 @@ func NewUserRepository(db *gorm.DB) repository.UserRepository {
 }
 
 // Create создает нового пользователя
-func (r *UserRepository) Create(u *user.User) error {
+func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
 	// Проверяем уникальность логина
 	var count int64
-	if err := r.db.Model(u).Where("login = ?", u.Login).Count(&count).Error; err != nil {
-		logger.Error("Failed to check login uniqueness", err)
+	if err := r.db.WithContext(ctx).Model(u).Where("login = ?", u.Login).Count(&count).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to check login uniqueness", err)
 		return err
 	}
 	if count > 0 {
@@ -36,31 +38,32 @@ func (r *UserRepository) Create(u *user.User) error {
 		u.Username = u.Login
 	}
 
-	if err := r.db.Create(u).Error; err != nil {
-		logger.Error("Failed to create user", err)
+	u.Version = 1
+	if err := r.db.WithContext(ctx).Create(u).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to create user", err)
 		return err
 	}
 	return nil
 }
 
 // GetByID получает пользователя по ID
-func (r *UserRepository) GetByID(id uint) (*user.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*user.User, error) {
 	var u user.User
-	if err := r.db.First(&u, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&u, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			logger.Error("User not found", fmt.Errorf("user with ID %d not found", id))
+			logger.FromContext(ctx).Error("User not found", fmt.Errorf("user with ID %d not found", id))
 			return nil, repository.ErrNotFound
 		}
-		logger.Error("Failed to get user by ID", err)
+		logger.FromContext(ctx).Error("Failed to get user by ID", err)
 		return nil, err
 	}
 
 	// Загружаем только ID книг пользователя
 	var bookIDs []uint
-	if err := r.db.Model(&book.Book{}).
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).
 		Where("user_id = ?", id).
 		Pluck("id", &bookIDs).Error; err != nil {
-		logger.Error("Failed to get user's book IDs", err)
+		logger.FromContext(ctx).Error("Failed to get user's book IDs", err)
 		return nil, err
 	}
 	u.BookIDs = bookIDs
@@ -69,9 +72,9 @@ func (r *UserRepository) GetByID(id uint) (*user.User, error) {
 }
 
 // GetByLogin получает пользователя по логину
-func (r *UserRepository) GetByLogin(login string) (*user.User, error) {
+func (r *UserRepository) GetByLogin(ctx context.Context, login string) (*user.User, error) {
 	var u user.User
-	result := r.db.Where("login = ?", login).First(&u)
+	result := r.db.WithContext(ctx).Where("login = ?", login).First(&u)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -82,65 +85,102 @@ func (r *UserRepository) GetByLogin(login string) (*user.User, error) {
 }
 
 // GetAll получает всех пользователей с пагинацией
-func (r *UserRepository) GetAll(page, pageSize int) ([]*user.User, int64, error) {
+func (r *UserRepository) GetAll(ctx context.Context, page, pageSize int) ([]*user.User, int64, error) {
 	var users []*user.User
 	var total int64
 
 	// Получаем общее количество пользователей
-	if err := r.db.Model(&user.User{}).Count(&total).Error; err != nil {
-		logger.Error("Failed to count users", err)
+	if err := r.db.WithContext(ctx).Model(&user.User{}).Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count users", err)
 		return nil, 0, err
 	}
 
 	// Получаем пользователей с пагинацией
 	offset := (page - 1) * pageSize
-	if err := r.db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
-		logger.Error("Failed to get users", err)
+	if err := r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get users", err)
 		return nil, 0, err
 	}
 
-	// Загружаем только ID книг для каждого пользователя
+	if err := r.loadBookIDs(ctx, users); err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// GetAllByCursor получает страницу пользователей после (или перед) позицией курсора
+func (r *UserRepository) GetAllByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*user.User, bool, error) {
+	var users []*user.User
+	if err := r.db.WithContext(ctx).Scopes(keysetScope("users", c, limit)).Find(&users).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get users", err)
+		return nil, false, err
+	}
+
+	users, hasMore := trimKeysetPage(users, c, limit)
+	if err := r.loadBookIDs(ctx, users); err != nil {
+		return nil, false, err
+	}
+
+	return users, hasMore, nil
+}
+
+// loadBookIDs загружает только ID книг для каждого пользователя
+func (r *UserRepository) loadBookIDs(ctx context.Context, users []*user.User) error {
 	for _, u := range users {
 		var bookIDs []uint
-		if err := r.db.Model(&book.Book{}).
+		if err := r.db.WithContext(ctx).Model(&book.Book{}).
 			Where("user_id = ?", u.ID).
 			Pluck("id", &bookIDs).Error; err != nil {
-			logger.Error("Failed to get user's book IDs", err)
-			return nil, 0, err
+			logger.FromContext(ctx).Error("Failed to get user's book IDs", err)
+			return err
 		}
 		u.BookIDs = bookIDs
 	}
-
-	return users, total, nil
+	return nil
 }
 
-// Update обновляет пользователя в базе данных
-func (r *UserRepository) Update(user *user.User) error {
+// Update обновляет пользователя в базе данных, если его версия не изменилась с момента чтения
+func (r *UserRepository) Update(ctx context.Context, u *user.User) error {
 	updates := map[string]interface{}{}
 
-	if user.Username != "" {
-		updates["username"] = user.Username
+	if u.Username != "" {
+		updates["username"] = u.Username
+	}
+
+	if u.Password != "" {
+		updates["password"] = u.Password
+	}
+
+	if u.Avatar != "" {
+		updates["avatar"] = u.Avatar
 	}
 
-	if user.Password != "" {
-		updates["password"] = user.Password
+	if u.Role != "" {
+		updates["role"] = u.Role
 	}
 
-	if user.Avatar != "" {
-		updates["avatar"] = user.Avatar
+	updatedAt, err := updateVersioned(r.db.WithContext(ctx), &user.User{}, u.ID, u.Version, updates)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) && !errors.Is(err, repository.ErrVersionConflict) {
+			logger.FromContext(ctx).Error("Failed to update user", err)
+		}
+		return err
 	}
 
-	return r.db.Model(user).Updates(updates).Error
+	u.Version++
+	u.UpdatedAt = updatedAt
+	return nil
 }
 
-// Delete удаляет пользователя
-func (r *UserRepository) Delete(id uint) error {
+// Delete удаляет пользователя, если его версия не изменилась с момента чтения
+func (r *UserRepository) Delete(ctx context.Context, id, version uint) error {
 	// Проверяем наличие книг у пользователя
 	var count int64
-	if err := r.db.Model(&book.Book{}).
+	if err := r.db.WithContext(ctx).Model(&book.Book{}).
 		Where("user_id = ?", id).
 		Count(&count).Error; err != nil {
-		logger.Error("Failed to check user's books", err)
+		logger.FromContext(ctx).Error("Failed to check user's books", err)
 		return err
 	}
 
@@ -148,13 +188,15 @@ func (r *UserRepository) Delete(id uint) error {
 		return errors.New("cannot delete user: they have books")
 	}
 
-	result := r.db.Delete(&user.User{}, id)
-	if result.Error != nil {
-		logger.Error("Failed to delete user", result.Error)
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return repository.ErrNotFound
-	}
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkVersion(tx, &user.User{}, id, version); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&user.User{}, id).Error; err != nil {
+			logger.FromContext(ctx).Error("Failed to delete user", err)
+			return err
+		}
+		return nil
+	})
 }
@@ -0,0 +1,73 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/repository"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// updateVersioned обновляет запись id таблицы model, только если ее версия все еще равна
+// version, и увеличивает версию. Возвращает новое время изменения записи.
+// Если записи нет, возвращает repository.ErrNotFound, если ее уже изменили -
+// repository.ErrVersionConflict
+func updateVersioned(tx *gorm.DB, model interface{}, id, version uint, updates map[string]interface{}) (time.Time, error) {
+	// Время округляется до точности столбца updated_at, чтобы ETag обновленной записи
+	// совпал с ETag той же записи, прочитанной из базы
+	now := time.Now().Truncate(time.Millisecond)
+	updates["updated_at"] = now
+	updates["version"] = gorm.Expr("version + 1")
+
+	result := tx.Model(model).Where("id = ? AND version = ?", id, version).Updates(updates)
+	if result.Error != nil {
+		return time.Time{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := tx.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+			return time.Time{}, err
+		}
+		if count == 0 {
+			return time.Time{}, repository.ErrNotFound
+		}
+		return time.Time{}, repository.ErrVersionConflict
+	}
+	return now, nil
+}
+
+// checkVersion блокирует запись id таблицы model до конца транзакции и проверяет,
+// что ее версия равна version. Вызывается перед удалением записи
+func checkVersion(tx *gorm.DB, model interface{}, id, version uint) error {
+	var current struct{ Version uint }
+	err := tx.Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("version").Where("id = ?", id).Take(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current.Version != version {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
+
+// touch увеличивает версию и время изменения записи id таблицы model, когда меняются
+// связанные с ней данные, входящие в ее представление, например фотографии книги.
+// UPDATE блокирует строку записи до конца транзакции
+func touch(tx *gorm.DB, model interface{}, id uint) error {
+	result := tx.Model(model).Where("id = ?", id).Updates(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now().Truncate(time.Millisecond),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package mysql
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/wishlist"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) repository.WishlistRepository {
+	return &WishlistRepository{db: db}
+}
+
+// CreateItem сохраняет пожелание вместе со связями с тегами
+func (r *WishlistRepository) CreateItem(ctx context.Context, item *wishlist.Item) error {
+	if err := r.db.WithContext(ctx).Create(item).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to create wishlist item", err)
+		return fmt.Errorf("failed to create wishlist item: %w", err)
+	}
+	return nil
+}
+
+// GetItemByID получает пожелание по ID
+func (r *WishlistRepository) GetItemByID(ctx context.Context, id uint) (*wishlist.Item, error) {
+	var item wishlist.Item
+	if err := r.db.WithContext(ctx).Preload("Tags").First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// GetUserItems получает все пожелания пользователя
+func (r *WishlistRepository) GetUserItems(ctx context.Context, userID uint) ([]*wishlist.Item, error) {
+	var items []*wishlist.Item
+	if err := r.db.WithContext(ctx).Preload("Tags").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&items).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get wishlist items", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetAllItems получает пожелания всех пользователей
+func (r *WishlistRepository) GetAllItems(ctx context.Context) ([]*wishlist.Item, error) {
+	var items []*wishlist.Item
+	if err := r.db.WithContext(ctx).Preload("Tags").Find(&items).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get wishlist items", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteItem удаляет пожелание вместе с его совпадениями и связями с тегами
+func (r *WishlistRepository) DeleteItem(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		item := &wishlist.Item{}
+		item.ID = id
+		if err := tx.Model(item).Association("Tags").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&wishlist.Match{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&wishlist.Item{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// FindCandidates получает пожелания других пользователей, которые могут подойти книге.
+// ISBN, название и автор проверяются в запросе, теги проверяет wishlist.Item.Matches
+func (r *WishlistRepository) FindCandidates(ctx context.Context, b *book.Book) ([]*wishlist.Item, error) {
+	var items []*wishlist.Item
+	if err := r.db.WithContext(ctx).Preload("Tags").
+		Where("user_id <> ?", b.UserID).
+		Where("isbn = '' OR isbn = ?", b.ISBN).
+		Where("title = '' OR ? LIKE CONCAT('%', title, '%')", b.Title).
+		Where("author = '' OR ? LIKE CONCAT('%', author, '%')", b.Author).
+		Find(&items).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to find wishlist candidates", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// CreateMatches сохраняет совпадения, пропуская уже записанные пары пожелание-книга,
+// и возвращает только новые совпадения
+func (r *WishlistRepository) CreateMatches(ctx context.Context, matches []*wishlist.Match) ([]*wishlist.Match, error) {
+	var created []*wishlist.Match
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, m := range matches {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(m)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				created = append(created, m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("Failed to create wishlist matches", err)
+		return nil, fmt.Errorf("failed to create wishlist matches: %w", err)
+	}
+	return created, nil
+}
+
+// GetUserMatches получает совпадения по пожеланиям пользователя с пагинацией, новые первыми
+func (r *WishlistRepository) GetUserMatches(ctx context.Context, userID uint, page, pageSize int) ([]*wishlist.Match, int64, error) {
+	var matches []*wishlist.Match
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&wishlist.Match{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to count wishlist matches", err)
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Item.Tags").
+		Preload("Book.State").Preload("Book.User").Preload("Book.Tags").Preload("Book.Photos", orderPhotos).
+		Order("created_at DESC").Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&matches).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get wishlist matches", err)
+		return nil, 0, err
+	}
+
+	for _, m := range matches {
+		if m.Book != nil {
+			useThumbnails([]*book.Book{m.Book})
+		}
+	}
+
+	return matches, total, nil
+}
+
+// GetMatchUserIDs получает ID пользователей, чьи пожелания совпали с книгой
+func (r *WishlistRepository) GetMatchUserIDs(ctx context.Context, bookID uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.WithContext(ctx).Model(&wishlist.Match{}).
+		Where("book_id = ?", bookID).
+		Distinct().Pluck("user_id", &ids).Error; err != nil {
+		logger.FromContext(ctx).Error("Failed to get wishlist match users", err)
+		return nil, err
+	}
+	return ids, nil
+}
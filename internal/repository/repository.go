@@ -18,10 +18,16 @@ func NewMySQLConnection(cfg *config.DatabaseConfig) (*sql.DB, error) {
 
 func NewRepository(db *gorm.DB) *repository.Repository {
 	return &repository.Repository{
-		User:  mysql.NewUserRepository(db),
-		Book:  mysql.NewBookRepository(db),
-		Tag:   mysql.NewTagRepository(db),
-		State: mysql.NewStateRepository(db),
-		Token: mysql.NewRefreshTokenRepository(db),
+		User:         mysql.NewUserRepository(db),
+		Book:         mysql.NewBookRepository(db),
+		Tag:          mysql.NewTagRepository(db),
+		State:        mysql.NewStateRepository(db),
+		Trade:        mysql.NewTradeRepository(db),
+		Token:        mysql.NewRefreshTokenRepository(db),
+		Wishlist:     mysql.NewWishlistRepository(db),
+		Conversation: mysql.NewConversationRepository(db),
+		Notification: mysql.NewNotificationRepository(db),
+		Review:       mysql.NewReviewRepository(db),
+		Idempotency:  mysql.NewIdempotencyRepository(db),
 	}
 }
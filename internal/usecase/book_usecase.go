@@ -4,24 +4,47 @@ import (
 	"booktrading/internal/domain/book"
 	"booktrading/internal/domain/tag"
 	"booktrading/internal/pkg/cache"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/storage"
+	"booktrading/internal/pkg/tracing"
 	"booktrading/internal/repository/mysql"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"time"
 )
 
+// ErrInvalidSearchParams возвращается при недопустимых параметрах поиска книг
+var ErrInvalidSearchParams = errors.New("invalid search parameters")
+
+// bookPage - страница книг с общим числом книг, хранимая в кеше
+type bookPage struct {
+	Books []*book.Book `json:"books"`
+	Total int64        `json:"total"`
+}
+
 // BookUseCase определяет интерфейс для работы с книгами
 type BookUseCase interface {
-	CreateBook(book *book.Book, tagIDs []uint) error
-	GetBookByID(id uint) (*book.Book, error)
-	GetAllBooks(page, pageSize int) ([]*book.Book, int64, error)
-	GetBooksByTags(tagIDs []uint) ([]*book.Book, error)
-	AddTagsToBook(bookID uint, tagIDs []uint) error
-	UpdateBook(book *book.Book, tagIDs []uint) error
-	UpdateBookState(id uint, stateID uint) (*book.Book, error)
-	DeleteBook(id uint) error
-	GetUserBooks(userID uint, page, pageSize int) ([]*book.Book, int64, error)
-	CreatePhoto(photo *book.BookPhoto) error
-	DeletePhotos(bookID uint) error
+	CreateBook(ctx context.Context, book *book.Book, tagIDs []uint, photos []book.BookPhotoData) error
+	GetBookByID(ctx context.Context, id uint) (*book.Book, error)
+	GetBookForUpdate(ctx context.Context, id uint) (*book.Book, error)
+	GetAllBooks(ctx context.Context, page, pageSize int) ([]*book.Book, int64, error)
+	GetBooksByTags(ctx context.Context, tagIDs []uint) ([]*book.Book, error)
+	SearchBooks(ctx context.Context, params *book.SearchParams) (*book.SearchResult, error)
+	AddTagsToBook(ctx context.Context, bookID uint, tagIDs []uint, version uint) error
+	UpdateBook(ctx context.Context, book *book.Book, tagIDs []uint, photos []book.BookPhotoData) error
+	UpdateBookState(ctx context.Context, id uint, stateID uint, version uint) (*book.Book, error)
+	DeleteBook(ctx context.Context, id, version uint) error
+	GetUserBooks(ctx context.Context, userID uint, page, pageSize int) ([]*book.Book, int64, error)
+	GetAllBooksByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*book.Book, bool, error)
+	GetUserBooksByCursor(ctx context.Context, userID uint, c *cursor.Cursor, limit int) ([]*book.Book, bool, error)
+	CreatePhoto(ctx context.Context, photo *book.BookPhoto) error
+	UploadPhotos(ctx context.Context, bookID uint, files [][]byte, isMain bool, version uint) ([]*book.BookPhoto, error)
+	DeletePhoto(ctx context.Context, bookID, photoID, version uint) error
+	SetMainPhoto(ctx context.Context, bookID, photoID, version uint) ([]*book.BookPhoto, error)
+	ReorderPhotos(ctx context.Context, bookID uint, photoIDs []uint, version uint) ([]*book.BookPhoto, error)
+	DeletePhotos(ctx context.Context, bookID uint) error
 }
 
 // bookUseCase реализует интерфейс BookUseCase
@@ -29,23 +52,32 @@ type bookUseCase struct {
 	bookRepo  *mysql.BookRepository
 	tagRepo   *mysql.TagRepository
 	stateRepo *mysql.StateRepository
-	cache     *cache.Cache
+	cache     *cache.Loader
+	media     *storage.Media
+	matcher   WishlistMatcher
 	bookSvc   *book.Service
 }
 
 // NewBookUseCase создает новый экземпляр bookUseCase
-func NewBookUseCase(bookRepo *mysql.BookRepository, tagRepo *mysql.TagRepository, stateRepo *mysql.StateRepository, cache *cache.Cache) BookUseCase {
+func NewBookUseCase(bookRepo *mysql.BookRepository, tagRepo *mysql.TagRepository, stateRepo *mysql.StateRepository, cache *cache.Loader, media *storage.Media, matcher WishlistMatcher) BookUseCase {
 	return &bookUseCase{
 		bookRepo:  bookRepo,
 		tagRepo:   tagRepo,
 		stateRepo: stateRepo,
 		cache:     cache,
+		media:     media,
+		matcher:   matcher,
 		bookSvc:   book.NewService(),
 	}
 }
 
-// CreateBook создает новую книгу
-func (u *bookUseCase) CreateBook(book *book.Book, tagIDs []uint) error {
+// CreateBook создает новую книгу вместе с фотографиями. Фотографии проверяются
+// и сохраняются в хранилище до создания книги, а в базу книга и фотографии
+// записываются одной транзакцией
+func (u *bookUseCase) CreateBook(ctx context.Context, book *book.Book, tagIDs []uint, photos []book.BookPhotoData) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.CreateBook")
+	defer span.End()
+
 	// Если состояние не указано, устанавливаем состояние "available"
 	if book.StateID == 0 {
 		// Используем ID 1 для состояния "available"
@@ -55,7 +87,7 @@ func (u *bookUseCase) CreateBook(book *book.Book, tagIDs []uint) error {
 	// Получаем теги
 	var tags []*tag.Tag
 	for _, tagID := range tagIDs {
-		tag, err := u.tagRepo.GetByID(tagID)
+		tag, err := u.tagRepo.GetByID(ctx, tagID)
 		if err != nil {
 			return err
 		}
@@ -65,66 +97,133 @@ func (u *bookUseCase) CreateBook(book *book.Book, tagIDs []uint) error {
 	// Добавляем теги к книге
 	u.bookSvc.AddTags(book, tags)
 
+	saved, err := u.preparePhotos(ctx, photos)
+	if err != nil {
+		return err
+	}
+	book.Photos = saved
+
 	// Сохраняем в репозиторий
-	if err := u.bookRepo.Create(book); err != nil {
+	if err := u.bookRepo.Create(ctx, book); err != nil {
 		return err
 	}
 
-	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	// Новая книга может попасть в любой список. Ее ID мог быть закеширован как отсутствующий
+	cacheInvalidate(ctx, u.cache, bookDep(book.ID), listBooksDep)
+
+	u.matchWishlists(ctx, book)
 
 	return nil
 }
 
 // GetBookByID получает книгу по ID
-func (u *bookUseCase) GetBookByID(id uint) (*book.Book, error) {
-	// Попытка получить книгу из кеша
+func (u *bookUseCase) GetBookByID(ctx context.Context, id uint) (*book.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetBookByID")
+	defer span.End()
+
+	// Одновременные промахи по книге загружают ее из базы один раз. Отсутствие книги
+	// тоже кешируется и сбрасывается, когда книга с этим ID появится
 	cacheKey := fmt.Sprintf("books:id:%d", id)
-	if cached, found := u.cache.Get(cacheKey); found {
-		if book, ok := cached.(*book.Book); ok {
-			return book, nil
+	return cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) (*book.Book, []string, error) {
+		b, err := u.bookRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, nil, err
 		}
-	}
-
-	// Получение книги из репозитория
-	book, err := u.bookRepo.GetByID(id)
-	if err != nil {
-		return nil, err
-	}
+		return b, bookDeps(b), nil
+	}, bookDep(id))
+}
 
-	// Сохранение в кеш
-	u.cache.Set(cacheKey, book, 5*time.Minute)
+// GetBookForUpdate получает текущую книгу из базы мимо кеша. Кеш может отдать
+// устаревшую копию, пока обновляет ее, а по версии этой книги проверяется
+// If-Match и сохраняется изменение
+func (u *bookUseCase) GetBookForUpdate(ctx context.Context, id uint) (*book.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetBookForUpdate")
+	defer span.End()
 
-	return book, nil
+	return u.bookRepo.GetByID(ctx, id)
 }
 
 // GetBooksByTags получает книги по тегам
-func (u *bookUseCase) GetBooksByTags(tagIDs []uint) ([]*book.Book, error) {
-	// Попытка получить книги из кеша
+func (u *bookUseCase) GetBooksByTags(ctx context.Context, tagIDs []uint) ([]*book.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetBooksByTags")
+	defer span.End()
+
 	cacheKey := fmt.Sprintf("books:tags:%v", tagIDs)
-	if cached, found := u.cache.Get(cacheKey); found {
-		if books, ok := cached.([]*book.Book); ok {
-			return books, nil
+	return cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) ([]*book.Book, []string, error) {
+		books, err := u.bookRepo.GetByTags(ctx, tagIDs)
+		if err != nil {
+			return nil, nil, err
 		}
+		return books, bookDeps(books...), nil
+	}, listBooksDep)
+}
+
+// SearchBooks ищет книги по полнотекстовому запросу и фильтрам
+func (u *bookUseCase) SearchBooks(ctx context.Context, params *book.SearchParams) (*book.SearchResult, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.SearchBooks")
+	defer span.End()
+
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.PageSize < 1 {
+		params.PageSize = 10
+	}
+	if params.PageSize > 100 {
+		params.PageSize = 100
+	}
+	if params.TagMode == "" {
+		params.TagMode = book.TagModeOr
+	}
+	if !params.TagMode.IsValid() {
+		return nil, fmt.Errorf("%w: unknown tag mode %q", ErrInvalidSearchParams, params.TagMode)
+	}
+	if params.Sort == "" {
+		params.Sort = book.SortRelevance
 	}
+	if !params.Sort.IsValid() {
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidSearchParams, params.Sort)
+	}
+	if params.CreatedFrom != nil && params.CreatedTo != nil && params.CreatedFrom.After(*params.CreatedTo) {
+		return nil, fmt.Errorf("%w: created_from is after created_to", ErrInvalidSearchParams)
+	}
+	params.TagIDs = uniqueIDs(params.TagIDs)
 
-	// Получение книг из репозитория
-	books, err := u.bookRepo.GetByTags(tagIDs)
+	// Попытка получить результат из кеша
+	key, err := json.Marshal(params)
 	if err != nil {
 		return nil, err
 	}
+	cacheKey := "books:search:" + string(key)
+	return cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) (*book.SearchResult, []string, error) {
+		result, err := u.bookRepo.Search(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, bookDeps(result.Books...), nil
+	}, listBooksDep)
+}
 
-	// Сохранение в кеш
-	u.cache.Set(cacheKey, books, 5*time.Minute)
-
-	return books, nil
+// uniqueIDs удаляет повторяющиеся ID, сохраняя порядок
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
 }
 
-// AddTagsToBook добавляет теги к книге
-func (u *bookUseCase) AddTagsToBook(bookID uint, tagIDs []uint) error {
-	// Получаем книгу
-	book, err := u.GetBookByID(bookID)
+// AddTagsToBook добавляет теги к книге, если ее версия все еще равна version
+func (u *bookUseCase) AddTagsToBook(ctx context.Context, bookID uint, tagIDs []uint, version uint) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.AddTagsToBook")
+	defer span.End()
+
+	// Получаем книгу. Теги добавляются к текущим, а не к закешированным
+	book, err := u.GetBookForUpdate(ctx, bookID)
 	if err != nil {
 		return err
 	}
@@ -132,34 +231,40 @@ func (u *bookUseCase) AddTagsToBook(bookID uint, tagIDs []uint) error {
 	// Получаем теги
 	var tags []*tag.Tag
 	for _, tagID := range tagIDs {
-		tag, err := u.tagRepo.GetByID(tagID)
+		tag, err := u.tagRepo.GetByID(ctx, tagID)
 		if err != nil {
 			return err
 		}
 		tags = append(tags, tag)
 	}
 
-	// Добавляем теги через доменный сервис
+	// Добавляем теги через доменный сервис. Изменение сохраняется от версии,
+	// которую видел клиент
 	u.bookSvc.AddTags(book, tags)
+	book.Version = version
 
 	// Сохраняем в репозитории
-	if err := u.bookRepo.Update(book); err != nil {
+	if err := u.bookRepo.Update(ctx, book, nil); err != nil {
 		return err
 	}
 
-	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	// Изменение тегов и полей книги меняет состав списков
+	cacheInvalidate(ctx, u.cache, bookDep(book.ID), listBooksDep)
 
 	return nil
 }
 
-// UpdateBook обновляет существующую книгу
-func (u *bookUseCase) UpdateBook(book *book.Book, tagIDs []uint) error {
+// UpdateBook обновляет существующую книгу. Книга сохраняется, только если ее версия
+// в базе все еще равна book.Version, иначе возвращается repository.ErrVersionConflict.
+// Если photos не nil, фотографии книги заменяются ими в той же транзакции
+func (u *bookUseCase) UpdateBook(ctx context.Context, book *book.Book, tagIDs []uint, photos []book.BookPhotoData) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.UpdateBook")
+	defer span.End()
+
 	// Получаем теги
 	var tags []*tag.Tag
 	for _, tagID := range tagIDs {
-		tag, err := u.tagRepo.GetByID(tagID)
+		tag, err := u.tagRepo.GetByID(ctx, tagID)
 		if err != nil {
 			return err
 		}
@@ -169,62 +274,128 @@ func (u *bookUseCase) UpdateBook(book *book.Book, tagIDs []uint) error {
 	// Добавляем теги к книге
 	u.bookSvc.AddTags(book, tags)
 
+	saved, err := u.preparePhotos(ctx, photos)
+	if err != nil {
+		return err
+	}
+
 	// Обновляем в репозитории
-	if err := u.bookRepo.Update(book); err != nil {
+	if err := u.bookRepo.Update(ctx, book, saved); err != nil {
 		return err
 	}
+	if saved != nil {
+		book.Photos = saved
+	}
 
-	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	// Изменение тегов и полей книги меняет состав списков
+	cacheInvalidate(ctx, u.cache, bookDep(book.ID), listBooksDep)
+
+	u.matchWishlists(ctx, book)
 
 	return nil
 }
 
-// UpdateBookState обновляет состояние книги
-func (u *bookUseCase) UpdateBookState(id uint, stateID uint) (*book.Book, error) {
-	// Получаем существующую книгу
-	existingBook, err := u.GetBookByID(id)
+// UpdateBookState обновляет состояние книги, если ее версия все еще равна version
+func (u *bookUseCase) UpdateBookState(ctx context.Context, id uint, stateID uint, version uint) (*book.Book, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.UpdateBookState")
+	defer span.End()
+
+	// Получаем существующую книгу. Переход проверяется из текущего состояния
+	existingBook, err := u.GetBookForUpdate(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	// Проверяем существование нового состояния
-	if _, err := u.stateRepo.GetByID(stateID); err != nil {
+	if _, err := u.stateRepo.GetByID(ctx, stateID); err != nil {
 		return nil, fmt.Errorf("invalid state ID: %w", err)
 	}
 
-	// Обновляем состояние
+	// Проверяем, что переход из текущего состояния разрешен
+	if err := u.stateRepo.CheckTransition(ctx, existingBook.StateID, stateID); err != nil {
+		return nil, err
+	}
+
+	// Обновляем состояние. Изменение сохраняется от версии, которую видел клиент
+	previousStateID := existingBook.StateID
 	existingBook.StateID = stateID
+	existingBook.Version = version
 
 	// Обновляем в репозитории
-	if err := u.bookRepo.Update(existingBook); err != nil {
+	if err := u.bookRepo.Update(ctx, existingBook, nil); err != nil {
+		existingBook.StateID = previousStateID
 		return nil, err
 	}
 
-	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	// Книги отбираются по состоянию, поэтому меняется и состав списков
+	cacheInvalidate(ctx, u.cache, bookDep(existingBook.ID), listBooksDep)
+
+	u.matchWishlists(ctx, existingBook)
+	if err := u.matcher.NotifyStateChange(ctx, existingBook); err != nil {
+		logger.FromContext(ctx).Error(fmt.Sprintf("Failed to notify about state change of book %d", existingBook.ID), err)
+	}
 
 	return existingBook, nil
 }
 
-// DeleteBook удаляет книгу
-func (u *bookUseCase) DeleteBook(id uint) error {
+// matchWishlists ищет пожелания, которым соответствует книга. Ошибка сопоставления
+// не отменяет уже сохраненные изменения книги, поэтому только логируется, а само
+// сопоставление не прерывается отключением клиента
+func (u *bookUseCase) matchWishlists(ctx context.Context, b *book.Book) {
+	if err := u.matcher.MatchBook(context.WithoutCancel(ctx), b); err != nil {
+		logger.FromContext(ctx).Error(fmt.Sprintf("Failed to match book %d with wishlists", b.ID), err)
+	}
+}
+
+// DeleteBook удаляет книгу, если ее версия все еще равна version
+func (u *bookUseCase) DeleteBook(ctx context.Context, id, version uint) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.DeleteBook")
+	defer span.End()
+
 	// Удаляем из репозитория
-	if err := u.bookRepo.Delete(id); err != nil {
+	if err := u.bookRepo.Delete(ctx, id, version); err != nil {
 		return err
 	}
 
 	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	cacheInvalidate(ctx, u.cache, bookDep(id), listBooksDep)
 
 	return nil
 }
 
+// GetAllBooksByCursor получает страницу книг по курсору.
+// Возвращает книги и признак наличия записей дальше в направлении курсора
+func (u *bookUseCase) GetAllBooksByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*book.Book, bool, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetAllBooksByCursor")
+	defer span.End()
+
+	return u.bookRepo.GetAllByCursor(ctx, c, normalizeLimit(limit))
+}
+
+// GetUserBooksByCursor получает страницу книг пользователя по курсору
+func (u *bookUseCase) GetUserBooksByCursor(ctx context.Context, userID uint, c *cursor.Cursor, limit int) ([]*book.Book, bool, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetUserBooksByCursor")
+	defer span.End()
+
+	return u.bookRepo.GetUserBooksByCursor(ctx, userID, c, normalizeLimit(limit))
+}
+
+// normalizeLimit ограничивает размер страницы курсорной пагинации
+func normalizeLimit(limit int) int {
+	if limit < 1 {
+		return 10
+	}
+	if limit > 100 {
+		return 100
+	}
+	return limit
+}
+
 // GetAllBooks получает все книги с пагинацией
-func (u *bookUseCase) GetAllBooks(page, pageSize int) ([]*book.Book, int64, error) {
+func (u *bookUseCase) GetAllBooks(ctx context.Context, page, pageSize int) ([]*book.Book, int64, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetAllBooks")
+	defer span.End()
+
 	if page < 1 {
 		page = 1
 	}
@@ -232,36 +403,26 @@ func (u *bookUseCase) GetAllBooks(page, pageSize int) ([]*book.Book, int64, erro
 		pageSize = 10
 	}
 
-	// Попытка получить книги из кеша
 	cacheKey := fmt.Sprintf("books:page:%d:size:%d", page, pageSize)
-	if cached, found := u.cache.Get(cacheKey); found {
-		if result, ok := cached.(map[string]interface{}); ok {
-			if books, ok := result["books"].([]*book.Book); ok {
-				if total, ok := result["total"].(int64); ok {
-					return books, total, nil
-				}
-			}
+	cached, err := cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) (bookPage, []string, error) {
+		books, total, err := u.bookRepo.GetAll(ctx, page, pageSize)
+		if err != nil {
+			return bookPage{}, nil, err
 		}
-	}
-
-	// Получение книг из репозитория
-	books, total, err := u.bookRepo.GetAll(page, pageSize)
+		return bookPage{Books: books, Total: total}, bookDeps(books...), nil
+	}, listBooksDep)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Сохранение в кеш
-	result := map[string]interface{}{
-		"books": books,
-		"total": total,
-	}
-	u.cache.Set(cacheKey, result, 5*time.Minute)
-
-	return books, total, nil
+	return cached.Books, cached.Total, nil
 }
 
 // GetUserBooks получает книги пользователя с пагинацией
-func (u *bookUseCase) GetUserBooks(userID uint, page, pageSize int) ([]*book.Book, int64, error) {
+func (u *bookUseCase) GetUserBooks(ctx context.Context, userID uint, page, pageSize int) ([]*book.Book, int64, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.GetUserBooks")
+	defer span.End()
+
 	// Валидация параметров пагинации
 	if page < 1 {
 		page = 1
@@ -273,43 +434,209 @@ func (u *bookUseCase) GetUserBooks(userID uint, page, pageSize int) ([]*book.Boo
 		pageSize = 100
 	}
 
-	return u.bookRepo.GetUserBooks(userID, page, pageSize)
+	return u.bookRepo.GetUserBooks(ctx, userID, page, pageSize)
 }
 
-// CreatePhoto создает новую фотографию для книги
-func (u *bookUseCase) CreatePhoto(photo *book.BookPhoto) error {
+// CreatePhoto добавляет книге фотографию из base64 data URI
+func (u *bookUseCase) CreatePhoto(ctx context.Context, photo *book.BookPhoto) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.CreatePhoto")
+	defer span.End()
+
 	// Проверяем существование книги
-	if _, err := u.GetBookByID(photo.BookID); err != nil {
+	b, err := u.GetBookByID(ctx, photo.BookID)
+	if err != nil {
+		return err
+	}
+	if err := u.checkPhotoLimit(ctx, photo.BookID, 1); err != nil {
 		return err
 	}
 
-	// Сохраняем в репозитории
-	if err := u.bookRepo.CreatePhoto(photo); err != nil {
+	// Сохраняем изображение и его уменьшенные варианты в хранилище,
+	// в базе остаются только URL
+	image, err := u.media.SaveImageDataURI(ctx, photo.PhotoURL)
+	if err != nil {
 		return err
 	}
+	setPhotoImage(photo, image)
 
-	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	return u.addPhotos(ctx, photo.BookID, []*book.BookPhoto{photo}, b.Version)
+}
 
+// UploadPhotos добавляет книге фотографии из загруженных файлов. Количество и размер
+// файлов проверяются до сохранения изображений, а в базу все фотографии записываются
+// одной транзакцией, только если версия книги все еще равна version: при ошибке
+// не добавляется ни одна. Если isMain, главной становится первая из загруженных фотографий
+func (u *bookUseCase) UploadPhotos(ctx context.Context, bookID uint, files [][]byte, isMain bool, version uint) ([]*book.BookPhoto, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.UploadPhotos")
+	defer span.End()
+
+	if len(files) > book.MaxPhotos {
+		return nil, book.ErrTooManyPhotos
+	}
+	for i, data := range files {
+		if len(data) > storage.MaxImageSize {
+			return nil, fmt.Errorf("file %d: %w", i+1, storage.ErrImageTooLarge)
+		}
+	}
+	if _, err := u.GetBookByID(ctx, bookID); err != nil {
+		return nil, err
+	}
+	if err := u.checkPhotoLimit(ctx, bookID, len(files)); err != nil {
+		return nil, err
+	}
+
+	photos := make([]*book.BookPhoto, 0, len(files))
+	for i, data := range files {
+		image, err := u.media.SaveImage(ctx, data)
+		if err != nil {
+			return nil, fmt.Errorf("file %d: %w", i+1, err)
+		}
+		photo := &book.BookPhoto{BookID: bookID, IsMain: isMain && i == 0}
+		setPhotoImage(photo, image)
+		photos = append(photos, photo)
+	}
+
+	if err := u.addPhotos(ctx, bookID, photos, version); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// addPhotos сохраняет фотографии с URL уже сохраненных изображений
+func (u *bookUseCase) addPhotos(ctx context.Context, bookID uint, photos []*book.BookPhoto, version uint) error {
+	// Лимит проверяется повторно в репозитории под блокировкой книги
+	if err := u.bookRepo.CreatePhotos(ctx, bookID, photos, version); err != nil {
+		return err
+	}
+
+	u.invalidateBook(ctx, bookID)
+	return nil
+}
+
+// setPhotoImage записывает в фотографию URL сохраненного изображения и его вариантов
+func setPhotoImage(photo *book.BookPhoto, image *storage.Image) {
+	photo.PhotoURL = image.URL
+	photo.MediumURL = image.MediumURL
+	photo.ThumbnailURL = image.ThumbnailURL
+}
+
+// checkPhotoLimit проверяет, что к фотографиям книги можно добавить еще count штук.
+// Проверка выполняется до сохранения изображений, чтобы не загружать их впустую
+func (u *bookUseCase) checkPhotoLimit(ctx context.Context, bookID uint, count int) error {
+	photos, err := u.bookRepo.GetPhotos(ctx, bookID)
+	if err != nil {
+		return err
+	}
+	if len(photos)+count > book.MaxPhotos {
+		return book.ErrTooManyPhotos
+	}
 	return nil
 }
 
+// preparePhotos проверяет набор фотографий для создания или обновления книги
+// и сохраняет изображения в хранилище. Изображения сохраняются до изменения базы,
+// поэтому при ошибке книга и ее фотографии остаются прежними. Если главная
+// фотография не указана, главной становится первая. Для nil возвращает nil,
+// а для пустого набора - пустой срез
+func (u *bookUseCase) preparePhotos(ctx context.Context, data []book.BookPhotoData) ([]*book.BookPhoto, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if len(data) > book.MaxPhotos {
+		return nil, book.ErrTooManyPhotos
+	}
+
+	mainCount := 0
+	for _, d := range data {
+		if d.IsMain {
+			mainCount++
+		}
+	}
+	if mainCount > 1 {
+		return nil, book.ErrMultipleMainPhotos
+	}
+
+	photos := make([]*book.BookPhoto, 0, len(data))
+	for _, d := range data {
+		image, err := u.media.SaveImageDataURI(ctx, d.PhotoURL)
+		if err != nil {
+			return nil, err
+		}
+		photo := &book.BookPhoto{IsMain: d.IsMain}
+		setPhotoImage(photo, image)
+		photos = append(photos, photo)
+	}
+	if mainCount == 0 && len(photos) > 0 {
+		photos[0].IsMain = true
+	}
+	return photos, nil
+}
+
+// DeletePhoto удаляет фотографию книги, если версия книги все еще равна version
+func (u *bookUseCase) DeletePhoto(ctx context.Context, bookID, photoID, version uint) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.DeletePhoto")
+	defer span.End()
+
+	if err := u.bookRepo.DeletePhoto(ctx, bookID, photoID, version); err != nil {
+		return err
+	}
+
+	u.invalidateBook(ctx, bookID)
+	return nil
+}
+
+// SetMainPhoto делает фотографию главной, если версия книги все еще равна version,
+// и возвращает фотографии книги
+func (u *bookUseCase) SetMainPhoto(ctx context.Context, bookID, photoID, version uint) ([]*book.BookPhoto, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.SetMainPhoto")
+	defer span.End()
+
+	if err := u.bookRepo.SetMainPhoto(ctx, bookID, photoID, version); err != nil {
+		return nil, err
+	}
+
+	u.invalidateBook(ctx, bookID)
+	return u.bookRepo.GetPhotos(ctx, bookID)
+}
+
+// ReorderPhotos меняет порядок фотографий, если версия книги все еще равна version,
+// и возвращает их в новом порядке
+func (u *bookUseCase) ReorderPhotos(ctx context.Context, bookID uint, photoIDs []uint, version uint) ([]*book.BookPhoto, error) {
+	ctx, span := tracing.Start(ctx, "BookUseCase.ReorderPhotos")
+	defer span.End()
+
+	if err := u.bookRepo.ReorderPhotos(ctx, bookID, photoIDs, version); err != nil {
+		return nil, err
+	}
+
+	u.invalidateBook(ctx, bookID)
+	return u.bookRepo.GetPhotos(ctx, bookID)
+}
+
+// invalidateBook сбрасывает кеш книги после изменения фотографий. Фотографии не влияют
+// на отбор книг, поэтому сбрасываются только записи, содержащие саму книгу
+func (u *bookUseCase) invalidateBook(ctx context.Context, bookID uint) {
+	cacheInvalidate(ctx, u.cache, bookDep(bookID))
+}
+
 // DeletePhotos удаляет все фотографии книги
-func (u *bookUseCase) DeletePhotos(bookID uint) error {
+func (u *bookUseCase) DeletePhotos(ctx context.Context, bookID uint) error {
+	ctx, span := tracing.Start(ctx, "BookUseCase.DeletePhotos")
+	defer span.End()
+
 	// Проверяем существование книги
-	if _, err := u.GetBookByID(bookID); err != nil {
+	b, err := u.GetBookByID(ctx, bookID)
+	if err != nil {
 		return err
 	}
 
 	// Удаляем из репозитория
-	if err := u.bookRepo.DeletePhotos(bookID); err != nil {
+	if err := u.bookRepo.DeletePhotos(ctx, bookID, b.Version); err != nil {
 		return err
 	}
 
 	// Инвалидация кеша
-	u.cache.DeletePattern("books:")
-	u.cache.Delete("books:all")
+	u.invalidateBook(ctx, bookID)
 
 	return nil
 }
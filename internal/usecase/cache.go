@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/pkg/cache"
+	"booktrading/internal/pkg/logger"
+	"context"
+	"fmt"
+)
+
+// cacheInvalidate удаляет из кеша все значения, зависящие от deps
+func cacheInvalidate(ctx context.Context, c cache.Cache, deps ...string) {
+	if err := c.InvalidateTags(ctx, deps...); err != nil {
+		logger.FromContext(ctx).Error("Failed to invalidate cache", err, "deps", deps)
+	}
+}
+
+// Зависимости записей кеша. Запись помечается зависимостями от всех сущностей, данные
+// которых в нее попали, и удаляется при изменении любой из них
+const (
+	// listBooksDep - списки и подборки книг. Меняются при добавлении и удалении книг и
+	// при изменении полей, по которым книги отбираются: названия, тегов, состояния
+	listBooksDep = "list:books"
+	// listTagsDep - списки тегов. Меняются при добавлении тегов
+	listTagsDep = "list:tags"
+)
+
+func bookDep(id uint) string  { return fmt.Sprintf("book:%d", id) }
+func userDep(id uint) string  { return fmt.Sprintf("user:%d", id) }
+func tagDep(id uint) string   { return fmt.Sprintf("tag:%d", id) }
+func stateDep(id uint) string { return fmt.Sprintf("state:%d", id) }
+
+// bookDeps возвращает зависимости записи кеша с книгами: сами книги, их владельцев,
+// теги и состояния, которые отдаются вместе с книгой
+func bookDeps(books ...*book.Book) []string {
+	seen := make(map[string]struct{})
+	var deps []string
+	add := func(dep string) {
+		if _, ok := seen[dep]; !ok {
+			seen[dep] = struct{}{}
+			deps = append(deps, dep)
+		}
+	}
+
+	for _, b := range books {
+		add(bookDep(b.ID))
+		add(userDep(b.UserID))
+		add(stateDep(b.StateID))
+		for _, t := range b.Tags {
+			add(tagDep(t.ID))
+		}
+	}
+	return deps
+}
+
+// bookIDDeps возвращает зависимости от книг по их ID
+func bookIDDeps(ids []uint) []string {
+	deps := make([]string, 0, len(ids))
+	for _, id := range ids {
+		deps = append(deps, bookDep(id))
+	}
+	return deps
+}
@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/conversation"
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrConversationNotFound  = errors.New("conversation not found")
+	ErrConversationForbidden = errors.New("user is not a participant of the conversation")
+	ErrInvalidConversation   = errors.New("invalid conversation")
+)
+
+// ConversationUseCase определяет интерфейс для работы с перепиской
+type ConversationUseCase interface {
+	StartConversation(ctx context.Context, userID uint, dto *conversation.CreateConversationDTO) (*conversation.Conversation, error)
+	GetUserConversations(ctx context.Context, userID uint, page, pageSize int) ([]*conversation.Conversation, int64, error)
+	GetUnreadTotal(ctx context.Context, userID uint) (int64, error)
+	GetConversation(ctx context.Context, userID, id uint) (*conversation.Conversation, error)
+	SendMessage(ctx context.Context, userID, id uint, dto *conversation.SendMessageDTO) (*conversation.Message, error)
+	GetMessages(ctx context.Context, userID, id uint, c *cursor.Cursor, limit int) ([]*conversation.Message, bool, error)
+	MarkRead(ctx context.Context, userID, id uint) error
+}
+
+// conversationUseCase реализует интерфейс ConversationUseCase
+type conversationUseCase struct {
+	conversationRepo repository.ConversationRepository
+	bookRepo         repository.BookRepository
+	tradeRepo        repository.TradeRepository
+	notifier         Notifier
+}
+
+// NewConversationUseCase создает новый экземпляр conversationUseCase
+func NewConversationUseCase(
+	conversationRepo repository.ConversationRepository,
+	bookRepo repository.BookRepository,
+	tradeRepo repository.TradeRepository,
+	notifier Notifier,
+) ConversationUseCase {
+	return &conversationUseCase{
+		conversationRepo: conversationRepo,
+		bookRepo:         bookRepo,
+		tradeRepo:        tradeRepo,
+		notifier:         notifier,
+	}
+}
+
+// StartConversation начинает переписку о книге с ее владельцем или переписку участников обмена.
+// Если такая переписка уже есть, сообщение добавляется в нее
+func (u *conversationUseCase) StartConversation(ctx context.Context, userID uint, dto *conversation.CreateConversationDTO) (*conversation.Conversation, error) {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.StartConversation")
+	defer span.End()
+
+	body := conversation.NormalizeBody(dto.Message)
+	if body == "" {
+		return nil, fmt.Errorf("%w: message must not be empty", ErrInvalidConversation)
+	}
+
+	var (
+		existing *conversation.Conversation
+		c        *conversation.Conversation
+		err      error
+	)
+	switch {
+	case dto.BookID != nil && dto.TradeID == nil:
+		existing, c, err = u.bookConversation(ctx, userID, *dto.BookID)
+	case dto.TradeID != nil && dto.BookID == nil:
+		existing, c, err = u.tradeConversation(ctx, userID, *dto.TradeID)
+	default:
+		return nil, fmt.Errorf("%w: exactly one of book_id or trade_id is required", ErrInvalidConversation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message := &conversation.Message{SenderID: userID, Body: body}
+	if existing != nil {
+		message.ConversationID = existing.ID
+		if err := u.conversationRepo.CreateMessage(ctx, message); err != nil {
+			return nil, err
+		}
+		u.notifyMessage(ctx, existing, message)
+		return u.GetConversation(ctx, userID, existing.ID)
+	}
+
+	if err := u.conversationRepo.Create(ctx, c, message); err != nil {
+		return nil, err
+	}
+	u.notifyMessage(ctx, c, message)
+	return u.GetConversation(ctx, userID, c.ID)
+}
+
+// GetUserConversations получает переписки пользователя с пагинацией
+func (u *conversationUseCase) GetUserConversations(ctx context.Context, userID uint, page, pageSize int) ([]*conversation.Conversation, int64, error) {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.GetUserConversations")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	conversations, total, err := u.conversationRepo.GetUserConversations(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, c := range conversations {
+		c.ForUser(userID)
+	}
+	return conversations, total, nil
+}
+
+// GetUnreadTotal получает общее число непрочитанных сообщений пользователя
+func (u *conversationUseCase) GetUnreadTotal(ctx context.Context, userID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.GetUnreadTotal")
+	defer span.End()
+
+	return u.conversationRepo.GetUnreadTotal(ctx, userID)
+}
+
+// GetConversation получает переписку, если пользователь является ее участником
+func (u *conversationUseCase) GetConversation(ctx context.Context, userID, id uint) (*conversation.Conversation, error) {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.GetConversation")
+	defer span.End()
+
+	c, err := u.conversationRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrConversationNotFound
+		}
+		return nil, err
+	}
+	if !c.IsParticipant(userID) {
+		return nil, ErrConversationForbidden
+	}
+	return c.ForUser(userID), nil
+}
+
+// SendMessage отправляет сообщение в переписку
+func (u *conversationUseCase) SendMessage(ctx context.Context, userID, id uint, dto *conversation.SendMessageDTO) (*conversation.Message, error) {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.SendMessage")
+	defer span.End()
+
+	body := conversation.NormalizeBody(dto.Body)
+	if body == "" {
+		return nil, fmt.Errorf("%w: message must not be empty", ErrInvalidConversation)
+	}
+
+	c, err := u.GetConversation(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &conversation.Message{ConversationID: id, SenderID: userID, Body: body}
+	if err := u.conversationRepo.CreateMessage(ctx, message); err != nil {
+		return nil, err
+	}
+	u.notifyMessage(ctx, c, message)
+	return message, nil
+}
+
+// GetMessages получает страницу сообщений переписки
+func (u *conversationUseCase) GetMessages(ctx context.Context, userID, id uint, c *cursor.Cursor, limit int) ([]*conversation.Message, bool, error) {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.GetMessages")
+	defer span.End()
+
+	if _, err := u.GetConversation(ctx, userID, id); err != nil {
+		return nil, false, err
+	}
+	return u.conversationRepo.GetMessagesByCursor(ctx, id, c, limit)
+}
+
+// MarkRead отмечает все сообщения переписки прочитанными для пользователя
+func (u *conversationUseCase) MarkRead(ctx context.Context, userID, id uint) error {
+	ctx, span := tracing.Start(ctx, "ConversationUseCase.MarkRead")
+	defer span.End()
+
+	if _, err := u.GetConversation(ctx, userID, id); err != nil {
+		return err
+	}
+	return u.conversationRepo.MarkRead(ctx, id, userID)
+}
+
+// notifyMessage уведомляет о новом сообщении всех участников переписки, кроме отправителя
+func (u *conversationUseCase) notifyMessage(ctx context.Context, c *conversation.Conversation, m *conversation.Message) {
+	for _, p := range c.Participants {
+		if p.UserID == m.SenderID {
+			continue
+		}
+		u.notifier.Notify(ctx, p.UserID, notification.TypeMessage, map[string]interface{}{
+			"conversation_id": m.ConversationID,
+			"message_id":      m.ID,
+			"sender_id":       m.SenderID,
+			"body":            m.Body,
+		})
+	}
+}
+
+// bookConversation находит переписку пользователя с владельцем книги или готовит новую
+func (u *conversationUseCase) bookConversation(ctx context.Context, userID, bookID uint) (*conversation.Conversation, *conversation.Conversation, error) {
+	b, err := u.bookRepo.GetByID(ctx, bookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: book %d not found", ErrInvalidConversation, bookID)
+		}
+		return nil, nil, err
+	}
+	if b.UserID == userID {
+		return nil, nil, fmt.Errorf("%w: cannot start a conversation about your own book", ErrInvalidConversation)
+	}
+
+	existing, err := u.conversationRepo.FindByBook(ctx, bookID, userID)
+	if err == nil {
+		return existing, nil, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	return nil, &conversation.Conversation{
+		BookID: &bookID,
+		Participants: []*conversation.Participant{
+			{UserID: userID},
+			{UserID: b.UserID},
+		},
+	}, nil
+}
+
+// tradeConversation находит переписку участников обмена или готовит новую
+func (u *conversationUseCase) tradeConversation(ctx context.Context, userID, tradeID uint) (*conversation.Conversation, *conversation.Conversation, error) {
+	t, err := u.tradeRepo.GetByID(ctx, tradeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: trade %d not found", ErrInvalidConversation, tradeID)
+		}
+		return nil, nil, err
+	}
+	if !t.IsParticipant(userID) {
+		return nil, nil, ErrConversationForbidden
+	}
+
+	existing, err := u.conversationRepo.FindByTrade(ctx, tradeID)
+	if err == nil {
+		return existing, nil, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	return nil, &conversation.Conversation{
+		TradeID: &tradeID,
+		Participants: []*conversation.Participant{
+			{UserID: t.ProposerID},
+			{UserID: t.RecipientID},
+		},
+	}, nil
+}
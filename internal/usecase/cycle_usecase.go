@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/cache"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrCycleNotFound = errors.New("trade cycle not found")
+
+// maxCycleCandidates ограничивает число колец, перебираемых за один поиск
+const maxCycleCandidates = 1000
+
+// CycleUseCase определяет интерфейс для работы с кольцевыми обменами
+type CycleUseCase interface {
+	FindCycles(ctx context.Context) ([]*trade.Cycle, error)
+	GetCycleByID(ctx context.Context, userID, id uint) (*trade.Cycle, error)
+	GetUserCycles(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Cycle, int64, error)
+	AcceptCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error)
+	RejectCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error)
+	CancelCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error)
+	CompleteCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error)
+}
+
+// cycleUseCase реализует интерфейс CycleUseCase
+type cycleUseCase struct {
+	tradeRepo    repository.TradeRepository
+	bookRepo     repository.BookRepository
+	wishlistRepo repository.WishlistRepository
+	stateRepo    repository.StateRepository
+	cache        cache.Cache
+	notifier     Notifier
+	maxLength    int
+	// scanMu не дает фоновому и ручному поиску предложить одни и те же кольца дважды
+	scanMu sync.Mutex
+}
+
+// NewCycleUseCase создает новый экземпляр cycleUseCase
+func NewCycleUseCase(
+	tradeRepo repository.TradeRepository,
+	bookRepo repository.BookRepository,
+	wishlistRepo repository.WishlistRepository,
+	stateRepo repository.StateRepository,
+	cache cache.Cache,
+	notifier Notifier,
+	maxLength int,
+) CycleUseCase {
+	return &cycleUseCase{
+		tradeRepo:    tradeRepo,
+		bookRepo:     bookRepo,
+		wishlistRepo: wishlistRepo,
+		stateRepo:    stateRepo,
+		cache:        cache,
+		notifier:     notifier,
+		maxLength:    maxLength,
+	}
+}
+
+// FindCycles ищет кольцевые обмены среди доступных книг и вишлистов и предлагает
+// найденные кольца всем их участникам. Книги, уже предложенные в ожидающих кольцах,
+// и ранее предложенные наборы книг не учитываются
+func (u *cycleUseCase) FindCycles(ctx context.Context) ([]*trade.Cycle, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.FindCycles")
+	defer span.End()
+
+	u.scanMu.Lock()
+	defer u.scanMu.Unlock()
+
+	available, err := u.stateRepo.GetByName(ctx, string(book.StateAvailable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available state: %w", err)
+	}
+	books, err := u.bookRepo.GetByState(ctx, available.ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := u.wishlistRepo.GetAllItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	busyIDs, err := u.tradeRepo.GetPendingCycleBookIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	busy := make(map[uint]bool, len(busyIDs))
+	for _, id := range busyIDs {
+		busy[id] = true
+	}
+
+	keys, err := u.tradeRepo.GetCycleKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exclude := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		exclude[key] = true
+	}
+
+	var wants []trade.Want
+	for _, b := range books {
+		if busy[b.ID] {
+			continue
+		}
+		for _, item := range items {
+			if item.UserID != b.UserID && item.Matches(b) {
+				wants = append(wants, trade.Want{Wisher: item.UserID, Owner: b.UserID, BookID: b.ID})
+			}
+		}
+	}
+
+	var cycles []*trade.Cycle
+	for _, wantCycle := range trade.FindCycles(wants, u.maxLength, maxCycleCandidates, exclude) {
+		c := trade.NewCycle(wantCycle)
+		if err := u.tradeRepo.CreateCycle(ctx, c); err != nil {
+			return nil, err
+		}
+		created, err := u.tradeRepo.GetCycleByID(ctx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		u.notifyParticipants(ctx, created, notification.TypeTradeCycle)
+		cycles = append(cycles, created)
+	}
+
+	if len(cycles) > 0 {
+		logger.FromContext(ctx).Info("Proposed trade cycles", "count", len(cycles))
+	}
+	return cycles, nil
+}
+
+// GetCycleByID получает кольцевой обмен по ID, если пользователь является его участником
+func (u *cycleUseCase) GetCycleByID(ctx context.Context, userID, id uint) (*trade.Cycle, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.GetCycleByID")
+	defer span.End()
+
+	c, err := u.getCycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	return c, nil
+}
+
+// GetUserCycles получает кольцевые обмены пользователя с пагинацией
+func (u *cycleUseCase) GetUserCycles(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Cycle, int64, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.GetUserCycles")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	if status != "" && !status.IsValid() {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrInvalidTradeOffer, status)
+	}
+
+	return u.tradeRepo.GetUserCycles(ctx, userID, status, page, pageSize)
+}
+
+// AcceptCycle записывает согласие участника. Когда обмен приняли все участники,
+// все его книги переходят в состояние "trading". Если какая-то книга уже недоступна,
+// кольцо отменяется
+func (u *cycleUseCase) AcceptCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.AcceptCycle")
+	defer span.End()
+
+	c, err := u.getCycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	if c.Status != trade.StatusPending {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	change, err := u.stateChange(ctx, book.StateAvailable, book.StateTrading)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.tradeRepo.AcceptCycle(ctx, c, userID, change); err != nil {
+		if errors.Is(err, trade.ErrBooksUnavailable) {
+			// Кольцо уже не может состояться - снимаем его, чтобы участники не ждали
+			c.Status = trade.StatusCancelled
+			if cancelErr := u.tradeRepo.UpdateCycleStatus(ctx, c, trade.StatusPending, nil); cancelErr != nil {
+				logger.FromContext(ctx).Error("Failed to cancel unavailable trade cycle", cancelErr)
+			}
+		}
+		return nil, err
+	}
+
+	if c.Status == trade.StatusAccepted {
+		u.invalidateBooks(ctx, c)
+		u.notifyParticipants(ctx, c, notification.TypeTradeAccepted)
+	}
+
+	return u.tradeRepo.GetCycleByID(ctx, c.ID)
+}
+
+// RejectCycle отклоняет ожидающий кольцевой обмен. Достаточно отказа одного участника
+func (u *cycleUseCase) RejectCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.RejectCycle")
+	defer span.End()
+
+	c, err := u.getCycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	if c.Status != trade.StatusPending {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	return u.transition(ctx, c, trade.StatusRejected, nil)
+}
+
+// CancelCycle отменяет принятый кольцевой обмен, книги снова становятся доступными
+func (u *cycleUseCase) CancelCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.CancelCycle")
+	defer span.End()
+
+	c, err := u.getCycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	if c.Status != trade.StatusAccepted {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	change, err := u.stateChange(ctx, book.StateTrading, book.StateAvailable)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.transition(ctx, c, trade.StatusCancelled, change)
+}
+
+// CompleteCycle подтверждает, что участник получил книгу. Кольцевой обмен завершается,
+// когда получение подтвердили все участники: книги переходят в состояние "traded"
+// и к новым владельцам
+func (u *cycleUseCase) CompleteCycle(ctx context.Context, userID, id uint) (*trade.Cycle, error) {
+	ctx, span := tracing.Start(ctx, "CycleUseCase.CompleteCycle")
+	defer span.End()
+
+	c, err := u.getCycle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	if c.Status != trade.StatusAccepted {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	change, err := u.stateChange(ctx, book.StateTrading, book.StateTraded)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.tradeRepo.CompleteCycle(ctx, c, userID, change); err != nil {
+		return nil, err
+	}
+
+	// Книги сменили владельцев - вместе с книгами сбрасываем и всех участников
+	if c.Status == trade.StatusCompleted {
+		u.invalidateBooks(ctx, c)
+		deps := make([]string, 0, len(c.Participants))
+		for _, p := range c.Participants {
+			deps = append(deps, userDep(p.UserID))
+		}
+		cacheInvalidate(ctx, u.cache, deps...)
+	}
+
+	return u.tradeRepo.GetCycleByID(ctx, c.ID)
+}
+
+// getCycle получает кольцевой обмен из репозитория, преобразуя ошибку отсутствия записи
+func (u *cycleUseCase) getCycle(ctx context.Context, id uint) (*trade.Cycle, error) {
+	c, err := u.tradeRepo.GetCycleByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCycleNotFound
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// transition меняет статус кольцевого обмена и, если нужно, состояние его книг
+func (u *cycleUseCase) transition(ctx context.Context, c *trade.Cycle, to trade.Status, change *trade.BookStateChange) (*trade.Cycle, error) {
+	from := c.Status
+	c.Status = to
+	if err := u.tradeRepo.UpdateCycleStatus(ctx, c, from, change); err != nil {
+		c.Status = from
+		return nil, err
+	}
+
+	if change != nil {
+		u.invalidateBooks(ctx, c)
+	}
+
+	return u.tradeRepo.GetCycleByID(ctx, c.ID)
+}
+
+// stateChange находит ID состояний книг по их названиям
+func (u *cycleUseCase) stateChange(ctx context.Context, from, to book.BookState) (*trade.BookStateChange, error) {
+	fromState, err := u.stateRepo.GetByName(ctx, string(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state %q: %w", from, err)
+	}
+	toState, err := u.stateRepo.GetByName(ctx, string(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state %q: %w", to, err)
+	}
+
+	return &trade.BookStateChange{FromStateID: fromState.ID, ToStateID: toState.ID}, nil
+}
+
+// notifyParticipants отправляет уведомление о кольцевом обмене всем его участникам
+func (u *cycleUseCase) notifyParticipants(ctx context.Context, c *trade.Cycle, t notification.Type) {
+	for _, p := range c.Participants {
+		u.notifier.Notify(ctx, p.UserID, t, map[string]interface{}{
+			"cycle_id": c.ID,
+		})
+	}
+}
+
+// invalidateBooks сбрасывает кеш книг кольца и списков книг после изменения их состояния
+func (u *cycleUseCase) invalidateBooks(ctx context.Context, c *trade.Cycle) {
+	cacheInvalidate(ctx, u.cache, append(bookIDDeps(c.BookIDs()), listBooksDep)...)
+}
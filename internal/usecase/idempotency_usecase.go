@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/idempotency"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
+	"time"
+)
+
+// IdempotencyUseCase определяет интерфейс для повторного выполнения запросов
+// с ключом идемпотентности
+type IdempotencyUseCase interface {
+	Begin(ctx context.Context, userID uint, key, requestHash string) (*idempotency.Record, error)
+	Complete(ctx context.Context, rec *idempotency.Record, statusCode int, headers map[string]string, body []byte) error
+	Release(ctx context.Context, rec *idempotency.Record) error
+	CleanupExpired(ctx context.Context) error
+}
+
+// idempotencyUseCase реализует интерфейс IdempotencyUseCase
+type idempotencyUseCase struct {
+	idempotencyRepo repository.IdempotencyRepository
+	ttl             time.Duration
+}
+
+// NewIdempotencyUseCase создает новый экземпляр IdempotencyUseCase.
+// Ответы на запросы хранятся ttl
+func NewIdempotencyUseCase(idempotencyRepo repository.IdempotencyRepository, ttl time.Duration) IdempotencyUseCase {
+	return &idempotencyUseCase{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+	}
+}
+
+// Begin занимает ключ для запроса. Если ключ свободен, возвращает новую запись,
+// и запрос нужно выполнить, а затем вызвать Complete или Release. Если на запрос
+// с этим ключом уже есть ответ, возвращает запись с ним (rec.Completed()).
+// Ключ, использованный для другого запроса, дает idempotency.ErrKeyReused,
+// а ключ выполняющегося запроса - idempotency.ErrInProgress
+func (u *idempotencyUseCase) Begin(ctx context.Context, userID uint, key, requestHash string) (*idempotency.Record, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.Begin")
+	defer span.End()
+
+	// Вторая попытка нужна, если найденная запись истекла или была освобождена
+	// между созданием и чтением
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now()
+		rec := &idempotency.Record{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   now.Add(u.ttl),
+		}
+		created, err := u.idempotencyRepo.Create(ctx, rec)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			return rec, nil
+		}
+
+		existing, err := u.idempotencyRepo.Get(ctx, userID, key)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if existing.IsExpired(now) {
+			if err := u.idempotencyRepo.Delete(ctx, existing.ID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, idempotency.ErrKeyReused
+		}
+		if !existing.Completed() {
+			return nil, idempotency.ErrInProgress
+		}
+		return existing, nil
+	}
+
+	return nil, idempotency.ErrInProgress
+}
+
+// Complete сохраняет ответ на запрос, чтобы повторить его при повторе запроса
+func (u *idempotencyUseCase) Complete(ctx context.Context, rec *idempotency.Record, statusCode int, headers map[string]string, body []byte) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.Complete")
+	defer span.End()
+
+	rec.StatusCode = statusCode
+	rec.Headers = headers
+	rec.Body = body
+	return u.idempotencyRepo.Complete(ctx, rec)
+}
+
+// Release освобождает ключ запроса, который не удалось выполнить,
+// чтобы клиент мог повторить его с тем же ключом
+func (u *idempotencyUseCase) Release(ctx context.Context, rec *idempotency.Record) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.Release")
+	defer span.End()
+
+	return u.idempotencyRepo.Delete(ctx, rec.ID)
+}
+
+// CleanupExpired удаляет ключи с истекшим сроком хранения
+func (u *idempotencyUseCase) CleanupExpired(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyUseCase.CleanupExpired")
+	defer span.End()
+
+	return u.idempotencyRepo.DeleteExpired(ctx)
+}
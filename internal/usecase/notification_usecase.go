@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/notify"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// maxMissedNotifications ограничивает число пропущенных уведомлений,
+// отправляемых клиенту при переподключении
+const maxMissedNotifications = 100
+
+// Notifier отправляет уведомления пользователям
+type Notifier interface {
+	// Notify сохраняет уведомление во входящих пользователя и отправляет его
+	// подключенным клиентам. Ошибка не должна отменять уже выполненное действие,
+	// поэтому она только логируется
+	Notify(ctx context.Context, userID uint, t notification.Type, data interface{})
+}
+
+// NotificationUseCase определяет интерфейс для работы с уведомлениями
+type NotificationUseCase interface {
+	Notifier
+	GetUserNotifications(ctx context.Context, userID uint, unreadOnly bool, page, pageSize int) ([]*notification.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID, id uint) error
+	MarkAllRead(ctx context.Context, userID uint) error
+	Subscribe(userID uint) *notify.Subscription
+	Unsubscribe(s *notify.Subscription)
+	GetMissed(ctx context.Context, userID, lastID uint) ([]*notification.Notification, error)
+}
+
+// notificationUseCase реализует интерфейс NotificationUseCase
+type notificationUseCase struct {
+	notificationRepo repository.NotificationRepository
+	hub              *notify.Hub
+}
+
+// NewNotificationUseCase создает новый экземпляр notificationUseCase
+func NewNotificationUseCase(notificationRepo repository.NotificationRepository, hub *notify.Hub) NotificationUseCase {
+	return &notificationUseCase{
+		notificationRepo: notificationRepo,
+		hub:              hub,
+	}
+}
+
+// Notify сохраняет уведомление и отправляет его подключенным клиентам пользователя
+func (u *notificationUseCase) Notify(ctx context.Context, userID uint, t notification.Type, data interface{}) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.Notify")
+	defer span.End()
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.FromContext(ctx).Error(fmt.Sprintf("Failed to encode %s notification", t), err)
+		return
+	}
+
+	// Уведомление о уже выполненном действии сохраняется, даже если клиент успел отключиться
+	n := &notification.Notification{UserID: userID, Type: t, Data: payload}
+	if err := u.notificationRepo.Create(context.WithoutCancel(ctx), n); err != nil {
+		logger.FromContext(ctx).Error(fmt.Sprintf("Failed to save %s notification for user %d", t, userID), err)
+		return
+	}
+
+	event, err := NotificationEvent(n)
+	if err != nil {
+		logger.FromContext(ctx).Error(fmt.Sprintf("Failed to encode %s notification", t), err)
+		return
+	}
+	u.hub.Publish(userID, event)
+}
+
+// GetUserNotifications получает уведомления пользователя с пагинацией
+func (u *notificationUseCase) GetUserNotifications(ctx context.Context, userID uint, unreadOnly bool, page, pageSize int) ([]*notification.Notification, int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.GetUserNotifications")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	return u.notificationRepo.GetUserNotifications(ctx, userID, unreadOnly, page, pageSize)
+}
+
+// CountUnread получает число непрочитанных уведомлений пользователя
+func (u *notificationUseCase) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.CountUnread")
+	defer span.End()
+
+	return u.notificationRepo.CountUnread(ctx, userID)
+}
+
+// MarkRead отмечает уведомление пользователя прочитанным
+func (u *notificationUseCase) MarkRead(ctx context.Context, userID, id uint) error {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.MarkRead")
+	defer span.End()
+
+	if err := u.notificationRepo.MarkRead(ctx, userID, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrNotificationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// MarkAllRead отмечает все уведомления пользователя прочитанными
+func (u *notificationUseCase) MarkAllRead(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.MarkAllRead")
+	defer span.End()
+
+	return u.notificationRepo.MarkAllRead(ctx, userID)
+}
+
+// Subscribe подписывает подключение пользователя на новые уведомления
+func (u *notificationUseCase) Subscribe(userID uint) *notify.Subscription {
+	return u.hub.Subscribe(userID)
+}
+
+// Unsubscribe отменяет подписку подключения
+func (u *notificationUseCase) Unsubscribe(s *notify.Subscription) {
+	u.hub.Unsubscribe(s)
+}
+
+// GetMissed получает непрочитанные уведомления, созданные после уведомления lastID,
+// чтобы доставить их клиенту после переподключения
+func (u *notificationUseCase) GetMissed(ctx context.Context, userID, lastID uint) ([]*notification.Notification, error) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.GetMissed")
+	defer span.End()
+
+	return u.notificationRepo.GetUnreadAfter(ctx, userID, lastID, maxMissedNotifications)
+}
+
+// NotificationEvent преобразует уведомление в событие для отправки клиенту
+func NotificationEvent(n *notification.Notification) (notify.Event, error) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return notify.Event{}, err
+	}
+	return notify.Event{ID: n.ID, Type: string(n.Type), Data: data}, nil
+}
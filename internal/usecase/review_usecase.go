@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/review"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
+	"strings"
+)
+
+// ReviewUseCase определяет интерфейс для работы с отзывами
+type ReviewUseCase interface {
+	CreateReview(ctx context.Context, userID, tradeID uint, dto *review.CreateReviewDTO) (*review.Review, error)
+	GetUserReviews(ctx context.Context, userID uint, page, pageSize int) ([]*review.Review, int64, error)
+}
+
+// reviewUseCase реализует интерфейс ReviewUseCase
+type reviewUseCase struct {
+	reviewRepo repository.ReviewRepository
+	tradeRepo  repository.TradeRepository
+	userRepo   repository.UserRepository
+}
+
+// NewReviewUseCase создает новый экземпляр reviewUseCase
+func NewReviewUseCase(
+	reviewRepo repository.ReviewRepository,
+	tradeRepo repository.TradeRepository,
+	userRepo repository.UserRepository,
+) ReviewUseCase {
+	return &reviewUseCase{
+		reviewRepo: reviewRepo,
+		tradeRepo:  tradeRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// CreateReview сохраняет отзыв участника завершенного обмена о другом участнике.
+// Каждый участник может оставить только один отзыв на обмен
+func (u *reviewUseCase) CreateReview(ctx context.Context, userID, tradeID uint, dto *review.CreateReviewDTO) (*review.Review, error) {
+	ctx, span := tracing.Start(ctx, "ReviewUseCase.CreateReview")
+	defer span.End()
+
+	t, err := u.tradeRepo.GetByID(ctx, tradeID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTradeNotFound
+		}
+		return nil, err
+	}
+	if !t.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	if t.Status != trade.StatusCompleted {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	revieweeID := t.RecipientID
+	if userID == t.RecipientID {
+		revieweeID = t.ProposerID
+	}
+
+	r := &review.Review{
+		TradeID:    t.ID,
+		ReviewerID: userID,
+		RevieweeID: revieweeID,
+		Rating:     dto.Rating,
+		Comment:    strings.TrimSpace(dto.Comment),
+	}
+	if err := u.reviewRepo.Create(ctx, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetUserReviews получает отзывы о пользователе с пагинацией
+func (u *reviewUseCase) GetUserReviews(ctx context.Context, userID uint, page, pageSize int) ([]*review.Review, int64, error) {
+	ctx, span := tracing.Start(ctx, "ReviewUseCase.GetUserReviews")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, 0, ErrUserNotFound
+		}
+		return nil, 0, err
+	}
+
+	return u.reviewRepo.GetUserReviews(ctx, userID, page, pageSize)
+}
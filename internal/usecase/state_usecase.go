@@ -3,60 +3,143 @@ package usecase
 import (
 	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/state"
+	"booktrading/internal/pkg/cache"
 	"booktrading/internal/pkg/logger"
-	"fmt"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
 )
 
 // StateUseCase определяет интерфейс для работы с состояниями книг
 type StateUseCase interface {
-	Create(s *state.State) error
-	GetByID(id uint) (*state.State, error)
-	GetAll() ([]*state.State, error)
-	Update(s *state.State) error
-	Delete(id uint) error
+	Create(ctx context.Context, s *state.State) error
+	GetByID(ctx context.Context, id uint) (*state.State, error)
+	GetAll(ctx context.Context) ([]*state.State, error)
+	Update(ctx context.Context, s *state.State) error
+	Delete(ctx context.Context, id, version uint) error
+	GetTransitions(ctx context.Context, fromStateID uint) ([]*state.Transition, error)
+	AddTransition(ctx context.Context, fromStateID uint, dto *state.CreateTransitionDTO, version uint) (*state.Transition, error)
+	DeleteTransition(ctx context.Context, fromStateID, toStateID, version uint) error
 }
 
 // stateUseCase реализует интерфейс StateUseCase
 type stateUseCase struct {
 	stateRepo repository.StateRepository
+	cache     cache.Cache
 }
 
 // NewStateUseCase создает новый экземпляр stateUseCase
-func NewStateUseCase(stateRepo repository.StateRepository) StateUseCase {
+func NewStateUseCase(stateRepo repository.StateRepository, cache cache.Cache) StateUseCase {
 	return &stateUseCase{
 		stateRepo: stateRepo,
+		cache:     cache,
 	}
 }
 
 // Create создает новое состояние
-func (u *stateUseCase) Create(s *state.State) error {
-	logger.Info("Creating state in usecase with name: " + s.Name)
+func (u *stateUseCase) Create(ctx context.Context, s *state.State) error {
+	ctx, span := tracing.Start(ctx, "StateUseCase.Create")
+	defer span.End()
 
-	if err := u.stateRepo.Create(s); err != nil {
-		logger.Error("Failed to create state in repository", err)
+	logger.FromContext(ctx).Debug("Creating state", "name", s.Name)
+
+	if err := u.stateRepo.Create(ctx, s); err != nil {
+		logger.FromContext(ctx).Error("Failed to create state in repository", err)
 		return err
 	}
 
-	logger.Info("State created successfully with ID: " + fmt.Sprintf("%d", s.ID))
+	logger.FromContext(ctx).Info("State created", "state_id", s.ID)
 	return nil
 }
 
 // GetByID получает состояние по ID
-func (u *stateUseCase) GetByID(id uint) (*state.State, error) {
-	return u.stateRepo.GetByID(id)
+func (u *stateUseCase) GetByID(ctx context.Context, id uint) (*state.State, error) {
+	ctx, span := tracing.Start(ctx, "StateUseCase.GetByID")
+	defer span.End()
+
+	return u.stateRepo.GetByID(ctx, id)
 }
 
 // GetAll получает список всех состояний
-func (u *stateUseCase) GetAll() ([]*state.State, error) {
-	return u.stateRepo.GetAll()
+func (u *stateUseCase) GetAll(ctx context.Context) ([]*state.State, error) {
+	ctx, span := tracing.Start(ctx, "StateUseCase.GetAll")
+	defer span.End()
+
+	return u.stateRepo.GetAll(ctx)
+}
+
+// Update обновляет существующее состояние, если его версия все еще равна s.Version
+func (u *stateUseCase) Update(ctx context.Context, s *state.State) error {
+	ctx, span := tracing.Start(ctx, "StateUseCase.Update")
+	defer span.End()
+
+	if err := u.stateRepo.Update(ctx, s); err != nil {
+		return err
+	}
+
+	// Состояние отдается вместе с книгами, поэтому сбрасываем книги в этом состоянии
+	cacheInvalidate(ctx, u.cache, stateDep(s.ID))
+	return nil
+}
+
+// Delete удаляет состояние по ID, если его версия все еще равна version
+func (u *stateUseCase) Delete(ctx context.Context, id, version uint) error {
+	ctx, span := tracing.Start(ctx, "StateUseCase.Delete")
+	defer span.End()
+
+	if err := u.stateRepo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+
+	cacheInvalidate(ctx, u.cache, stateDep(id))
+	return nil
+}
+
+// GetTransitions получает разрешенные переходы из состояния
+func (u *stateUseCase) GetTransitions(ctx context.Context, fromStateID uint) ([]*state.Transition, error) {
+	ctx, span := tracing.Start(ctx, "StateUseCase.GetTransitions")
+	defer span.End()
+
+	if _, err := u.stateRepo.GetByID(ctx, fromStateID); err != nil {
+		return nil, err
+	}
+	return u.stateRepo.GetTransitions(ctx, fromStateID)
 }
 
-// Update обновляет существующее состояние
-func (u *stateUseCase) Update(s *state.State) error {
-	return u.stateRepo.Update(s)
+// AddTransition разрешает переход из одного состояния в другое, если версия
+// исходного состояния все еще равна version
+func (u *stateUseCase) AddTransition(ctx context.Context, fromStateID uint, dto *state.CreateTransitionDTO, version uint) (*state.Transition, error) {
+	ctx, span := tracing.Start(ctx, "StateUseCase.AddTransition")
+	defer span.End()
+
+	if fromStateID == dto.ToStateID {
+		return nil, errors.New("transition to the same state is always allowed")
+	}
+
+	t := &state.Transition{
+		FromStateID: fromStateID,
+		ToStateID:   dto.ToStateID,
+	}
+	if err := u.stateRepo.AddTransition(ctx, t, version); err != nil {
+		logger.FromContext(ctx).Error("Failed to add state transition", err)
+		return nil, err
+	}
+
+	// Версия состояния увеличилась, а состояние отдается вместе с книгами
+	cacheInvalidate(ctx, u.cache, stateDep(fromStateID))
+	return t, nil
 }
 
-// Delete удаляет состояние по ID
-func (u *stateUseCase) Delete(id uint) error {
-	return u.stateRepo.Delete(id)
+// DeleteTransition запрещает переход из одного состояния в другое, если версия
+// исходного состояния все еще равна version
+func (u *stateUseCase) DeleteTransition(ctx context.Context, fromStateID, toStateID, version uint) error {
+	ctx, span := tracing.Start(ctx, "StateUseCase.DeleteTransition")
+	defer span.End()
+
+	if err := u.stateRepo.DeleteTransition(ctx, fromStateID, toStateID, version); err != nil {
+		return err
+	}
+
+	cacheInvalidate(ctx, u.cache, stateDep(fromStateID))
+	return nil
 }
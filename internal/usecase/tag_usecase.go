@@ -5,42 +5,50 @@ import (
 	"booktrading/internal/domain/tag"
 	"booktrading/internal/pkg/cache"
 	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/storage"
+	"booktrading/internal/pkg/tracing"
+	"context"
 	_ "encoding/json"
+	"errors"
 	"fmt"
-	"time"
 )
 
 // TagUseCase определяет интерфейс для работы с тегами
 type TagUseCase interface {
-	CreateTag(tag *tag.Tag) error
-	GetTagByID(id uint) (*tag.Tag, error)
-	GetTagByName(name string) (*tag.Tag, error)
-	GetAllTags() ([]*tag.Tag, error)
-	GetPopularTags(limit int) ([]*tag.TagWithCount, error)
-	UpdateTag(id uint, dto *tag.UpdateTagDTO) (*tag.Tag, error)
-	DeleteTag(id uint) error
+	CreateTag(ctx context.Context, tag *tag.Tag) error
+	GetTagByID(ctx context.Context, id uint) (*tag.Tag, error)
+	GetTagByName(ctx context.Context, name string) (*tag.Tag, error)
+	GetAllTags(ctx context.Context) ([]*tag.Tag, error)
+	GetPopularTags(ctx context.Context, limit int) ([]*tag.TagWithCount, error)
+	UpdateTag(ctx context.Context, id uint, dto *tag.UpdateTagDTO, version uint) (*tag.Tag, error)
+	DeleteTag(ctx context.Context, id, version uint) error
 }
 
 // tagUseCase реализует интерфейс TagUseCase
 type tagUseCase struct {
 	tagRepo  repository.TagRepository
 	bookRepo repository.BookRepository
-	cache    *cache.Cache
+	cache    *cache.Loader
+	media    *storage.Media
 }
 
 // NewTagUseCase создает новый экземпляр TagUseCase
-func NewTagUseCase(tagRepo repository.TagRepository, bookRepo repository.BookRepository, cache *cache.Cache) TagUseCase {
+func NewTagUseCase(tagRepo repository.TagRepository, bookRepo repository.BookRepository, cache *cache.Loader, media *storage.Media) TagUseCase {
 	return &tagUseCase{
 		tagRepo:  tagRepo,
 		bookRepo: bookRepo,
 		cache:    cache,
+		media:    media,
 	}
 }
 
 // CreateTag создает новый тег
-func (u *tagUseCase) CreateTag(t *tag.Tag) error {
+func (u *tagUseCase) CreateTag(ctx context.Context, t *tag.Tag) error {
+	ctx, span := tracing.Start(ctx, "TagUseCase.CreateTag")
+	defer span.End()
+
 	// Check if tag with same name exists
-	existingTag, err := u.tagRepo.GetByName(t.Name)
+	existingTag, err := u.tagRepo.GetByName(ctx, t.Name)
 	if err != nil {
 		return fmt.Errorf("failed to check tag existence: %w", err)
 	}
@@ -48,114 +56,114 @@ func (u *tagUseCase) CreateTag(t *tag.Tag) error {
 		return fmt.Errorf("tag with name %s already exists", t.Name)
 	}
 
+	// Сохраняем фото в хранилище, в базе остается только его URL
+	photoURL, err := u.media.SaveDataURI(ctx, t.Photo)
+	if err != nil {
+		return err
+	}
+	t.Photo = photoURL
+
 	// Create tag
-	if err := u.tagRepo.Create(t); err != nil {
+	if err := u.tagRepo.Create(ctx, t); err != nil {
 		return fmt.Errorf("failed to create tag: %w", err)
 	}
 
-	// Инвалидируем кеш
-	u.cache.DeletePattern("tags:")
-	u.cache.Delete("tags:all")
+	// Новый тег появляется в списках тегов
+	cacheInvalidate(ctx, u.cache, listTagsDep)
 
 	return nil
 }
 
 // GetTagByID получает тег по ID
-func (u *tagUseCase) GetTagByID(id uint) (*tag.Tag, error) {
-	// Попытка получить тег из кеша
+func (u *tagUseCase) GetTagByID(ctx context.Context, id uint) (*tag.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.GetTagByID")
+	defer span.End()
+
+	// Отсутствие тега тоже кешируется и сбрасывается, когда тег с этим ID появится
 	cacheKey := fmt.Sprintf("tags:id:%d", id)
-	if cached, found := u.cache.Get(cacheKey); found {
-		if t, ok := cached.(*tag.Tag); ok {
-			return t, nil
+	return cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) (*tag.Tag, []string, error) {
+		t, err := u.tagRepo.GetByID(ctx, id)
+		if err != nil {
+			if !errors.Is(err, repository.ErrNotFound) {
+				logger.FromContext(ctx).Error("Failed to get tag from repository", err)
+			}
+			return nil, nil, err
 		}
-	}
-
-	// Получение тега из репозитория
-	t, err := u.tagRepo.GetByID(id)
-	if err != nil {
-		logger.Error("Failed to get tag from repository", err)
-		return nil, err
-	}
-
-	// Сохранение в кеш
-	u.cache.Set(cacheKey, t, 5*time.Minute)
-
-	return t, nil
+		return t, nil, nil
+	}, tagDep(id))
 }
 
 // GetTagByName получает тег по имени
-func (u *tagUseCase) GetTagByName(name string) (*tag.Tag, error) {
-	// Попытка получить тег из кеша
+func (u *tagUseCase) GetTagByName(ctx context.Context, name string) (*tag.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.GetTagByName")
+	defer span.End()
+
+	// Если тега нет, кешируется nil. Такая запись сбрасывается вместе со списками тегов,
+	// то есть при добавлении или переименовании тега
 	cacheKey := fmt.Sprintf("tags:name:%s", name)
-	if cached, found := u.cache.Get(cacheKey); found {
-		if t, ok := cached.(*tag.Tag); ok {
-			return t, nil
+	return cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) (*tag.Tag, []string, error) {
+		t, err := u.tagRepo.GetByName(ctx, name)
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to get tag from repository", err)
+			return nil, nil, err
 		}
-	}
-
-	// Получение тега из репозитория
-	t, err := u.tagRepo.GetByName(name)
-	if err != nil {
-		logger.Error("Failed to get tag from repository", err)
-		return nil, err
-	}
-
-	// Сохранение в кеш
-	u.cache.Set(cacheKey, t, 5*time.Minute)
-
-	return t, nil
+		if t == nil {
+			return nil, nil, nil
+		}
+		return t, []string{tagDep(t.ID)}, nil
+	}, listTagsDep)
 }
 
 // GetAllTags получает список всех тегов
-func (u *tagUseCase) GetAllTags() ([]*tag.Tag, error) {
-	// Попытка получить теги из кеша
-	cacheKey := "tags:all"
-	if cached, found := u.cache.Get(cacheKey); found {
-		if tags, ok := cached.([]*tag.Tag); ok {
-			return tags, nil
+func (u *tagUseCase) GetAllTags(ctx context.Context) ([]*tag.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.GetAllTags")
+	defer span.End()
+
+	return cache.Load(ctx, u.cache, "tags:all", func(ctx context.Context) ([]*tag.Tag, []string, error) {
+		tags, err := u.tagRepo.GetAll(ctx)
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to get tags from repository", err)
+			return nil, nil, err
 		}
-	}
-
-	// Получение тегов из репозитория
-	tags, err := u.tagRepo.GetAll()
-	if err != nil {
-		logger.Error("Failed to get tags from repository", err)
-		return nil, err
-	}
-
-	// Сохранение в кеш
-	u.cache.Set(cacheKey, tags, 5*time.Minute)
 
-	return tags, nil
+		deps := make([]string, 0, len(tags))
+		for _, t := range tags {
+			deps = append(deps, tagDep(t.ID))
+		}
+		return tags, deps, nil
+	}, listTagsDep)
 }
 
 // GetPopularTags получает список популярных тегов
-func (u *tagUseCase) GetPopularTags(limit int) ([]*tag.TagWithCount, error) {
-	// Попытка получить теги из кеша
+func (u *tagUseCase) GetPopularTags(ctx context.Context, limit int) ([]*tag.TagWithCount, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.GetPopularTags")
+	defer span.End()
+
+	// Популярность зависит от числа книг с тегом, поэтому запись сбрасывается
+	// и при изменении книг
 	cacheKey := fmt.Sprintf("tags:popular:%d", limit)
-	if cached, found := u.cache.Get(cacheKey); found {
-		if tags, ok := cached.([]*tag.TagWithCount); ok {
-			return tags, nil
+	return cache.Load(ctx, u.cache, cacheKey, func(ctx context.Context) ([]*tag.TagWithCount, []string, error) {
+		tags, err := u.tagRepo.GetPopular(ctx, limit)
+		if err != nil {
+			logger.FromContext(ctx).Error("Failed to get popular tags from repository", err)
+			return nil, nil, err
 		}
-	}
 
-	// Получение тегов из репозитория
-	tags, err := u.tagRepo.GetPopular(limit)
-	if err != nil {
-		logger.Error("Failed to get popular tags from repository", err)
-		return nil, err
-	}
-
-	// Сохранение в кеш
-	u.cache.Set(cacheKey, tags, 5*time.Minute)
-
-	return tags, nil
+		deps := make([]string, 0, len(tags))
+		for _, t := range tags {
+			deps = append(deps, tagDep(t.Tag.ID))
+		}
+		return tags, deps, nil
+	}, listTagsDep, listBooksDep)
 }
 
-// UpdateTag обновляет существующий тег
-func (u *tagUseCase) UpdateTag(id uint, dto *tag.UpdateTagDTO) (*tag.Tag, error) {
+// UpdateTag обновляет существующий тег, если его версия все еще равна version
+func (u *tagUseCase) UpdateTag(ctx context.Context, id uint, dto *tag.UpdateTagDTO, version uint) (*tag.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagUseCase.UpdateTag")
+	defer span.End()
+
 	// Get existing tag
-	existingTag, err := u.tagRepo.GetByID(id)
+	existingTag, err := u.tagRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tag: %w", err)
 	}
@@ -167,7 +175,7 @@ func (u *tagUseCase) UpdateTag(id uint, dto *tag.UpdateTagDTO) (*tag.Tag, error)
 	if dto.Name != "" {
 		// Check if new name is already taken by another tag
 		if existingTag.Name != dto.Name {
-			tagWithName, err := u.tagRepo.GetByName(dto.Name)
+			tagWithName, err := u.tagRepo.GetByName(ctx, dto.Name)
 			if err != nil {
 				return nil, fmt.Errorf("failed to check tag name: %w", err)
 			}
@@ -180,25 +188,33 @@ func (u *tagUseCase) UpdateTag(id uint, dto *tag.UpdateTagDTO) (*tag.Tag, error)
 
 	// Update photo if provided
 	if dto.Photo != "" {
-		existingTag.Photo = dto.Photo
+		photoURL, err := u.media.SaveDataURI(ctx, dto.Photo)
+		if err != nil {
+			return nil, err
+		}
+		existingTag.Photo = photoURL
 	}
 
 	// Save changes
-	if err := u.tagRepo.Update(existingTag); err != nil {
+	existingTag.Version = version
+	if err := u.tagRepo.Update(ctx, existingTag); err != nil {
 		return nil, fmt.Errorf("failed to update tag: %w", err)
 	}
 
-	// Инвалидация кеша
-	u.cache.DeletePattern("tags:")
-	u.cache.Delete("tags:all")
+	// Сбрасываем тег вместе с книгами и списками, в которые он входит. Поиск по имени
+	// зависит от списка тегов: переименованный тег мог быть закеширован как отсутствующий
+	cacheInvalidate(ctx, u.cache, tagDep(existingTag.ID), listTagsDep)
 
 	return existingTag, nil
 }
 
-// DeleteTag удаляет тег по ID
-func (u *tagUseCase) DeleteTag(id uint) error {
+// DeleteTag удаляет тег по ID, если его версия все еще равна version
+func (u *tagUseCase) DeleteTag(ctx context.Context, id, version uint) error {
+	ctx, span := tracing.Start(ctx, "TagUseCase.DeleteTag")
+	defer span.End()
+
 	// Проверяем, используется ли тег в книгах
-	books, err := u.bookRepo.GetByTags([]uint{id})
+	books, err := u.bookRepo.GetByTags(ctx, []uint{id})
 	if err != nil {
 		return err
 	}
@@ -207,13 +223,12 @@ func (u *tagUseCase) DeleteTag(id uint) error {
 	}
 
 	// Удаляем тег
-	if err := u.tagRepo.Delete(id); err != nil {
+	if err := u.tagRepo.Delete(ctx, id, version); err != nil {
 		return err
 	}
 
 	// Инвалидация кеша
-	u.cache.DeletePattern("tags:")
-	u.cache.Delete("tags:all")
+	cacheInvalidate(ctx, u.cache, tagDep(id))
 
 	return nil
 }
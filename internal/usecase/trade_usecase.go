@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/pkg/cache"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTradeNotFound      = errors.New("trade not found")
+	ErrTradeForbidden     = errors.New("user is not allowed to perform this action on the trade")
+	ErrInvalidTradeStatus = errors.New("action is not allowed in the current trade status")
+	ErrInvalidTradeOffer  = errors.New("invalid trade offer")
+)
+
+// TradeUseCase определяет интерфейс для работы с обменами книгами
+type TradeUseCase interface {
+	ProposeTrade(ctx context.Context, proposerID uint, dto *trade.CreateTradeDTO) (*trade.Trade, error)
+	GetTradeByID(ctx context.Context, userID, id uint) (*trade.Trade, error)
+	GetUserTrades(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Trade, int64, error)
+	AcceptTrade(ctx context.Context, userID, id uint) (*trade.Trade, error)
+	RejectTrade(ctx context.Context, userID, id uint) (*trade.Trade, error)
+	CancelTrade(ctx context.Context, userID, id uint) (*trade.Trade, error)
+	CounterTrade(ctx context.Context, userID, id uint, dto *trade.CounterTradeDTO) (*trade.Trade, error)
+	CompleteTrade(ctx context.Context, userID, id uint) (*trade.Trade, error)
+}
+
+// tradeUseCase реализует интерфейс TradeUseCase
+type tradeUseCase struct {
+	tradeRepo repository.TradeRepository
+	bookRepo  repository.BookRepository
+	userRepo  repository.UserRepository
+	stateRepo repository.StateRepository
+	cache     cache.Cache
+	notifier  Notifier
+}
+
+// NewTradeUseCase создает новый экземпляр tradeUseCase
+func NewTradeUseCase(
+	tradeRepo repository.TradeRepository,
+	bookRepo repository.BookRepository,
+	userRepo repository.UserRepository,
+	stateRepo repository.StateRepository,
+	cache cache.Cache,
+	notifier Notifier,
+) TradeUseCase {
+	return &tradeUseCase{
+		tradeRepo: tradeRepo,
+		bookRepo:  bookRepo,
+		userRepo:  userRepo,
+		stateRepo: stateRepo,
+		cache:     cache,
+		notifier:  notifier,
+	}
+}
+
+// ProposeTrade создает новое предложение обмена
+func (u *tradeUseCase) ProposeTrade(ctx context.Context, proposerID uint, dto *trade.CreateTradeDTO) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.ProposeTrade")
+	defer span.End()
+
+	if dto.RecipientID == proposerID {
+		return nil, fmt.Errorf("%w: cannot trade with yourself", ErrInvalidTradeOffer)
+	}
+
+	if _, err := u.userRepo.GetByID(ctx, dto.RecipientID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("%w: recipient not found", ErrInvalidTradeOffer)
+		}
+		return nil, err
+	}
+
+	items, err := u.buildItems(ctx, proposerID, dto.RecipientID, dto.OfferedBookIDs, dto.RequestedBookIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &trade.Trade{
+		ProposerID:  proposerID,
+		RecipientID: dto.RecipientID,
+		Status:      trade.StatusPending,
+		Message:     dto.Message,
+		Items:       items,
+	}
+
+	if err := u.tradeRepo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+
+	u.notifier.Notify(ctx, t.RecipientID, notification.TypeTradeOffer, map[string]interface{}{
+		"trade_id":    t.ID,
+		"proposer_id": t.ProposerID,
+	})
+
+	return u.tradeRepo.GetByID(ctx, t.ID)
+}
+
+// GetTradeByID получает обмен по ID, если пользователь является его участником
+func (u *tradeUseCase) GetTradeByID(ctx context.Context, userID, id uint) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.GetTradeByID")
+	defer span.End()
+
+	t, err := u.getTrade(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	return t, nil
+}
+
+// GetUserTrades получает обмены пользователя с пагинацией
+func (u *tradeUseCase) GetUserTrades(ctx context.Context, userID uint, status trade.Status, page, pageSize int) ([]*trade.Trade, int64, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.GetUserTrades")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	if status != "" && !status.IsValid() {
+		return nil, 0, fmt.Errorf("%w: unknown status %q", ErrInvalidTradeOffer, status)
+	}
+
+	return u.tradeRepo.GetUserTrades(ctx, userID, status, page, pageSize)
+}
+
+// AcceptTrade принимает предложение и переводит все книги обмена в состояние "trading"
+func (u *tradeUseCase) AcceptTrade(ctx context.Context, userID, id uint) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.AcceptTrade")
+	defer span.End()
+
+	t, err := u.getTrade(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.RecipientID != userID {
+		return nil, ErrTradeForbidden
+	}
+	if t.Status != trade.StatusPending {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	change, err := u.stateChange(ctx, book.StateAvailable, book.StateTrading)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted, err := u.transition(ctx, t, trade.StatusAccepted, change)
+	if err != nil {
+		return nil, err
+	}
+
+	u.notifier.Notify(ctx, t.ProposerID, notification.TypeTradeAccepted, map[string]interface{}{
+		"trade_id":     t.ID,
+		"recipient_id": t.RecipientID,
+	})
+
+	return accepted, nil
+}
+
+// RejectTrade отклоняет предложение обмена
+func (u *tradeUseCase) RejectTrade(ctx context.Context, userID, id uint) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.RejectTrade")
+	defer span.End()
+
+	t, err := u.getTrade(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.RecipientID != userID {
+		return nil, ErrTradeForbidden
+	}
+	if t.Status != trade.StatusPending {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	return u.transition(ctx, t, trade.StatusRejected, nil)
+}
+
+// CancelTrade отменяет обмен. Ожидающее предложение может отменить только его автор,
+// принятый обмен - любой из участников, при этом книги снова становятся доступными
+func (u *tradeUseCase) CancelTrade(ctx context.Context, userID, id uint) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.CancelTrade")
+	defer span.End()
+
+	t, err := u.getTrade(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Status {
+	case trade.StatusPending:
+		if t.ProposerID != userID {
+			return nil, ErrTradeForbidden
+		}
+		return u.transition(ctx, t, trade.StatusCancelled, nil)
+	case trade.StatusAccepted:
+		if !t.IsParticipant(userID) {
+			return nil, ErrTradeForbidden
+		}
+		change, err := u.stateChange(ctx, book.StateTrading, book.StateAvailable)
+		if err != nil {
+			return nil, err
+		}
+		return u.transition(ctx, t, trade.StatusCancelled, change)
+	default:
+		return nil, ErrInvalidTradeStatus
+	}
+}
+
+// CounterTrade создает встречное предложение в ответ на ожидающее предложение
+func (u *tradeUseCase) CounterTrade(ctx context.Context, userID, id uint, dto *trade.CounterTradeDTO) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.CounterTrade")
+	defer span.End()
+
+	original, err := u.getTrade(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if original.RecipientID != userID {
+		return nil, ErrTradeForbidden
+	}
+	if original.Status != trade.StatusPending {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	items, err := u.buildItems(ctx, userID, original.ProposerID, dto.OfferedBookIDs, dto.RequestedBookIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	parentID := original.ID
+	counter := &trade.Trade{
+		ProposerID:  userID,
+		RecipientID: original.ProposerID,
+		Status:      trade.StatusPending,
+		ParentID:    &parentID,
+		Message:     dto.Message,
+		Items:       items,
+	}
+
+	if err := u.tradeRepo.CreateCounter(ctx, original, counter); err != nil {
+		return nil, err
+	}
+
+	u.notifier.Notify(ctx, counter.RecipientID, notification.TypeTradeOffer, map[string]interface{}{
+		"trade_id":    counter.ID,
+		"proposer_id": counter.ProposerID,
+		"parent_id":   original.ID,
+	})
+
+	return u.tradeRepo.GetByID(ctx, counter.ID)
+}
+
+// CompleteTrade подтверждает, что участник получил книги. Обмен завершается, когда
+// получение подтвердили оба участника: книги переходят в состояние "traded"
+// и к новым владельцам
+func (u *tradeUseCase) CompleteTrade(ctx context.Context, userID, id uint) (*trade.Trade, error) {
+	ctx, span := tracing.Start(ctx, "TradeUseCase.CompleteTrade")
+	defer span.End()
+
+	t, err := u.getTrade(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsParticipant(userID) {
+		return nil, ErrTradeForbidden
+	}
+	if t.Status != trade.StatusAccepted {
+		return nil, ErrInvalidTradeStatus
+	}
+
+	change, err := u.stateChange(ctx, book.StateTrading, book.StateTraded)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.tradeRepo.CompleteTrade(ctx, t, userID, change); err != nil {
+		return nil, err
+	}
+
+	// Книги сменили состояние и владельцев - сбрасываем их, списки книг
+	// и обоих участников вместе со списками их книг
+	if t.Status == trade.StatusCompleted {
+		deps := append(bookIDDeps(t.BookIDs()), listBooksDep, userDep(t.ProposerID), userDep(t.RecipientID))
+		cacheInvalidate(ctx, u.cache, deps...)
+	}
+
+	return u.tradeRepo.GetByID(ctx, t.ID)
+}
+
+// getTrade получает обмен из репозитория, преобразуя ошибку отсутствия записи
+func (u *tradeUseCase) getTrade(ctx context.Context, id uint) (*trade.Trade, error) {
+	t, err := u.tradeRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTradeNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// transition меняет статус обмена и, если нужно, состояние его книг
+func (u *tradeUseCase) transition(ctx context.Context, t *trade.Trade, to trade.Status, change *trade.BookStateChange) (*trade.Trade, error) {
+	from := t.Status
+	t.Status = to
+	if err := u.tradeRepo.UpdateStatus(ctx, t, from, change); err != nil {
+		t.Status = from
+		return nil, err
+	}
+
+	// Состояние книг изменилось - сбрасываем их и списки, отбирающие книги по состоянию
+	if change != nil {
+		cacheInvalidate(ctx, u.cache, append(bookIDDeps(t.BookIDs()), listBooksDep)...)
+	}
+
+	return u.tradeRepo.GetByID(ctx, t.ID)
+}
+
+// stateChange находит ID состояний книг по их названиям
+func (u *tradeUseCase) stateChange(ctx context.Context, from, to book.BookState) (*trade.BookStateChange, error) {
+	fromState, err := u.stateRepo.GetByName(ctx, string(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state %q: %w", from, err)
+	}
+	toState, err := u.stateRepo.GetByName(ctx, string(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state %q: %w", to, err)
+	}
+
+	return &trade.BookStateChange{FromStateID: fromState.ID, ToStateID: toState.ID}, nil
+}
+
+// buildItems проверяет книги обеих сторон и формирует позиции обмена
+func (u *tradeUseCase) buildItems(ctx context.Context, proposerID, recipientID uint, offeredIDs, requestedIDs []uint) ([]*trade.Item, error) {
+	available, err := u.stateRepo.GetByName(ctx, string(book.StateAvailable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available state: %w", err)
+	}
+
+	seen := make(map[uint]bool)
+	var items []*trade.Item
+
+	addItems := func(bookIDs []uint, fromUserID, toUserID uint) error {
+		for _, bookID := range bookIDs {
+			if seen[bookID] {
+				return fmt.Errorf("%w: book %d is listed more than once", ErrInvalidTradeOffer, bookID)
+			}
+			seen[bookID] = true
+
+			b, err := u.bookRepo.GetByID(ctx, bookID)
+			if err != nil {
+				if errors.Is(err, repository.ErrNotFound) {
+					return fmt.Errorf("%w: book %d not found", ErrInvalidTradeOffer, bookID)
+				}
+				return err
+			}
+			if b.UserID != fromUserID {
+				return fmt.Errorf("%w: book %d does not belong to user %d", ErrInvalidTradeOffer, bookID, fromUserID)
+			}
+			if b.StateID != available.ID {
+				return fmt.Errorf("%w: book %d is not available", ErrInvalidTradeOffer, bookID)
+			}
+
+			items = append(items, &trade.Item{
+				BookID:     &b.ID,
+				FromUserID: fromUserID,
+				ToUserID:   toUserID,
+			})
+		}
+		return nil
+	}
+
+	if err := addItems(offeredIDs, proposerID, recipientID); err != nil {
+		return nil, err
+	}
+	if err := addItems(requestedIDs, recipientID, proposerID); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,593 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/state"
+	"booktrading/internal/domain/trade"
+	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/cache"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Участники и книги тестовых обменов
+const (
+	proposerID  uint = 10
+	recipientID uint = 20
+	outsiderID  uint = 30
+
+	proposerBookID  uint = 100
+	recipientBookID uint = 200
+	tradingBookID   uint = 300
+	foreignBookID   uint = 400
+
+	availableStateID uint = 1
+	tradingStateID   uint = 2
+	tradedStateID    uint = 3
+)
+
+// fakeTradeRepo хранит обмены в памяти и проверяет статус так же, как MySQL реализация.
+// Методы, которые не нужны тестам, не реализованы
+type fakeTradeRepo struct {
+	repository.TradeRepository
+	trades  map[uint]*trade.Trade
+	nextID  uint
+	changes []*trade.BookStateChange
+}
+
+func newFakeTradeRepo(trades ...*trade.Trade) *fakeTradeRepo {
+	r := &fakeTradeRepo{trades: make(map[uint]*trade.Trade)}
+	for _, t := range trades {
+		r.trades[t.ID] = t
+		if t.ID > r.nextID {
+			r.nextID = t.ID
+		}
+	}
+	return r
+}
+
+func (r *fakeTradeRepo) GetByID(ctx context.Context, id uint) (*trade.Trade, error) {
+	t, ok := r.trades[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *fakeTradeRepo) Create(ctx context.Context, t *trade.Trade) error {
+	r.nextID++
+	t.ID = r.nextID
+	stored := *t
+	r.trades[t.ID] = &stored
+	return nil
+}
+
+func (r *fakeTradeRepo) CreateCounter(ctx context.Context, original *trade.Trade, counter *trade.Trade) error {
+	stored := r.trades[original.ID]
+	if stored.Status != trade.StatusPending {
+		return trade.ErrStatusChanged
+	}
+	stored.Status = trade.StatusCountered
+	original.Status = trade.StatusCountered
+	return r.Create(ctx, counter)
+}
+
+func (r *fakeTradeRepo) UpdateStatus(ctx context.Context, t *trade.Trade, from trade.Status, change *trade.BookStateChange) error {
+	stored := r.trades[t.ID]
+	if stored.Status != from {
+		return trade.ErrStatusChanged
+	}
+	stored.Status = t.Status
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func (r *fakeTradeRepo) CompleteTrade(ctx context.Context, t *trade.Trade, userID uint, change *trade.BookStateChange) error {
+	stored := r.trades[t.ID]
+	if stored.Status != trade.StatusAccepted {
+		return trade.ErrStatusChanged
+	}
+
+	now := time.Now()
+	if userID == stored.ProposerID {
+		stored.ProposerCompletedAt = &now
+	} else {
+		stored.RecipientCompletedAt = &now
+	}
+	if stored.BothCompleted() {
+		stored.Status = trade.StatusCompleted
+		r.changes = append(r.changes, change)
+	}
+
+	*t = *stored
+	return nil
+}
+
+// fakeBookRepo отдает книги по ID
+type fakeBookRepo struct {
+	repository.BookRepository
+	books map[uint]*book.Book
+}
+
+func (r *fakeBookRepo) GetByID(ctx context.Context, id uint) (*book.Book, error) {
+	b, ok := r.books[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return b, nil
+}
+
+// fakeUserRepo отдает пользователей по ID
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[uint]*user.User
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uint) (*user.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return u, nil
+}
+
+// fakeStateRepo отдает состояния книг по названию
+type fakeStateRepo struct {
+	repository.StateRepository
+	ids map[string]uint
+}
+
+func (r *fakeStateRepo) GetByName(ctx context.Context, name string) (*state.State, error) {
+	id, ok := r.ids[name]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	s := &state.State{Name: name}
+	s.ID = id
+	return s, nil
+}
+
+// sentNotification - уведомление, отправленное через fakeNotifier
+type sentNotification struct {
+	userID uint
+	typ    notification.Type
+}
+
+// fakeNotifier запоминает отправленные уведомления
+type fakeNotifier struct {
+	sent []sentNotification
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, userID uint, t notification.Type, data interface{}) {
+	n.sent = append(n.sent, sentNotification{userID: userID, typ: t})
+}
+
+// tradeFixture - usecase обменов поверх фейковых репозиториев
+type tradeFixture struct {
+	usecase  TradeUseCase
+	trades   *fakeTradeRepo
+	cache    *cache.Memory
+	notifier *fakeNotifier
+}
+
+func newTradeFixture(t *testing.T, trades ...*trade.Trade) *tradeFixture {
+	t.Helper()
+	books := &fakeBookRepo{books: map[uint]*book.Book{
+		proposerBookID:  {ID: proposerBookID, UserID: proposerID, StateID: availableStateID},
+		recipientBookID: {ID: recipientBookID, UserID: recipientID, StateID: availableStateID},
+		tradingBookID:   {ID: tradingBookID, UserID: proposerID, StateID: tradingStateID},
+		foreignBookID:   {ID: foreignBookID, UserID: outsiderID, StateID: availableStateID},
+	}}
+	users := &fakeUserRepo{users: map[uint]*user.User{
+		proposerID:  {ID: proposerID},
+		recipientID: {ID: recipientID},
+		outsiderID:  {ID: outsiderID},
+	}}
+	states := &fakeStateRepo{ids: map[string]uint{
+		string(book.StateAvailable): availableStateID,
+		string(book.StateTrading):   tradingStateID,
+		string(book.StateTraded):    tradedStateID,
+	}}
+
+	f := &tradeFixture{
+		trades:   newFakeTradeRepo(trades...),
+		cache:    cache.NewMemory(time.Minute, time.Hour),
+		notifier: &fakeNotifier{},
+	}
+	t.Cleanup(func() { f.cache.Close() })
+	f.usecase = NewTradeUseCase(f.trades, books, users, states, f.cache, f.notifier)
+	return f
+}
+
+// newTestTrade создает обмен книги предлагающего на книгу получателя
+func newTestTrade(status trade.Status) *trade.Trade {
+	proposerBook, recipientBook := proposerBookID, recipientBookID
+	t := &trade.Trade{
+		ProposerID:  proposerID,
+		RecipientID: recipientID,
+		Status:      status,
+		Items: []*trade.Item{
+			{BookID: &proposerBook, FromUserID: proposerID, ToUserID: recipientID},
+			{BookID: &recipientBook, FromUserID: recipientID, ToUserID: proposerID},
+		},
+	}
+	t.ID = 1
+	return t
+}
+
+func TestTradeTransitions(t *testing.T) {
+	accept := func(u TradeUseCase, userID, id uint) (*trade.Trade, error) {
+		return u.AcceptTrade(context.Background(), userID, id)
+	}
+	reject := func(u TradeUseCase, userID, id uint) (*trade.Trade, error) {
+		return u.RejectTrade(context.Background(), userID, id)
+	}
+	cancel := func(u TradeUseCase, userID, id uint) (*trade.Trade, error) {
+		return u.CancelTrade(context.Background(), userID, id)
+	}
+	complete := func(u TradeUseCase, userID, id uint) (*trade.Trade, error) {
+		return u.CompleteTrade(context.Background(), userID, id)
+	}
+	proposerCompleted := func(t *trade.Trade) {
+		now := time.Now()
+		t.ProposerCompletedAt = &now
+	}
+
+	tests := []struct {
+		name       string
+		status     trade.Status
+		prepare    func(t *trade.Trade)
+		action     func(u TradeUseCase, userID, id uint) (*trade.Trade, error)
+		userID     uint
+		tradeID    uint
+		wantErr    error
+		wantStatus trade.Status
+		wantChange *trade.BookStateChange
+		wantNotify []sentNotification
+	}{
+		{
+			name:       "recipient accepts",
+			status:     trade.StatusPending,
+			action:     accept,
+			userID:     recipientID,
+			wantStatus: trade.StatusAccepted,
+			wantChange: &trade.BookStateChange{FromStateID: availableStateID, ToStateID: tradingStateID},
+			wantNotify: []sentNotification{{userID: proposerID, typ: notification.TypeTradeAccepted}},
+		},
+		{
+			name:    "proposer cannot accept",
+			status:  trade.StatusPending,
+			action:  accept,
+			userID:  proposerID,
+			wantErr: ErrTradeForbidden,
+		},
+		{
+			name:    "accept accepted",
+			status:  trade.StatusAccepted,
+			action:  accept,
+			userID:  recipientID,
+			wantErr: ErrInvalidTradeStatus,
+		},
+		{
+			name:    "accept unknown trade",
+			status:  trade.StatusPending,
+			action:  accept,
+			userID:  recipientID,
+			tradeID: 404,
+			wantErr: ErrTradeNotFound,
+		},
+		{
+			name:       "recipient rejects",
+			status:     trade.StatusPending,
+			action:     reject,
+			userID:     recipientID,
+			wantStatus: trade.StatusRejected,
+		},
+		{
+			name:    "proposer cannot reject",
+			status:  trade.StatusPending,
+			action:  reject,
+			userID:  proposerID,
+			wantErr: ErrTradeForbidden,
+		},
+		{
+			name:    "reject countered",
+			status:  trade.StatusCountered,
+			action:  reject,
+			userID:  recipientID,
+			wantErr: ErrInvalidTradeStatus,
+		},
+		{
+			name:       "proposer cancels pending",
+			status:     trade.StatusPending,
+			action:     cancel,
+			userID:     proposerID,
+			wantStatus: trade.StatusCancelled,
+		},
+		{
+			name:    "recipient cannot cancel pending",
+			status:  trade.StatusPending,
+			action:  cancel,
+			userID:  recipientID,
+			wantErr: ErrTradeForbidden,
+		},
+		{
+			name:       "recipient cancels accepted",
+			status:     trade.StatusAccepted,
+			action:     cancel,
+			userID:     recipientID,
+			wantStatus: trade.StatusCancelled,
+			wantChange: &trade.BookStateChange{FromStateID: tradingStateID, ToStateID: availableStateID},
+		},
+		{
+			name:    "outsider cannot cancel accepted",
+			status:  trade.StatusAccepted,
+			action:  cancel,
+			userID:  outsiderID,
+			wantErr: ErrTradeForbidden,
+		},
+		{
+			name:    "cancel completed",
+			status:  trade.StatusCompleted,
+			action:  cancel,
+			userID:  proposerID,
+			wantErr: ErrInvalidTradeStatus,
+		},
+		{
+			name:       "first completion confirmation",
+			status:     trade.StatusAccepted,
+			action:     complete,
+			userID:     proposerID,
+			wantStatus: trade.StatusAccepted,
+		},
+		{
+			name:       "repeated completion confirmation",
+			status:     trade.StatusAccepted,
+			prepare:    proposerCompleted,
+			action:     complete,
+			userID:     proposerID,
+			wantStatus: trade.StatusAccepted,
+		},
+		{
+			name:       "second completion confirmation",
+			status:     trade.StatusAccepted,
+			prepare:    proposerCompleted,
+			action:     complete,
+			userID:     recipientID,
+			wantStatus: trade.StatusCompleted,
+			wantChange: &trade.BookStateChange{FromStateID: tradingStateID, ToStateID: tradedStateID},
+		},
+		{
+			name:    "outsider cannot complete",
+			status:  trade.StatusAccepted,
+			action:  complete,
+			userID:  outsiderID,
+			wantErr: ErrTradeForbidden,
+		},
+		{
+			name:    "complete pending",
+			status:  trade.StatusPending,
+			action:  complete,
+			userID:  recipientID,
+			wantErr: ErrInvalidTradeStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initial := newTestTrade(tt.status)
+			if tt.prepare != nil {
+				tt.prepare(initial)
+			}
+			f := newTradeFixture(t, initial)
+			id := tt.tradeID
+			if id == 0 {
+				id = initial.ID
+			}
+
+			got, err := tt.action(f.usecase, tt.userID, id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if stored := f.trades.trades[initial.ID]; stored.Status != tt.status {
+					t.Errorf("stored status = %s after error, want unchanged %s", stored.Status, tt.status)
+				}
+				if len(f.trades.changes) != 0 || len(f.notifier.sent) != 0 {
+					t.Errorf("failed action changed books %v or sent notifications %v", f.trades.changes, f.notifier.sent)
+				}
+				return
+			}
+
+			if got.Status != tt.wantStatus {
+				t.Errorf("returned status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if stored := f.trades.trades[initial.ID]; stored.Status != tt.wantStatus {
+				t.Errorf("stored status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+
+			var change *trade.BookStateChange
+			for _, c := range f.trades.changes {
+				if c != nil {
+					change = c
+				}
+			}
+			if (change == nil) != (tt.wantChange == nil) || (change != nil && *change != *tt.wantChange) {
+				t.Errorf("book state change = %+v, want %+v", change, tt.wantChange)
+			}
+
+			if len(f.notifier.sent) != len(tt.wantNotify) {
+				t.Fatalf("notifications = %v, want %v", f.notifier.sent, tt.wantNotify)
+			}
+			for i := range tt.wantNotify {
+				if f.notifier.sent[i] != tt.wantNotify[i] {
+					t.Errorf("notification %d = %v, want %v", i, f.notifier.sent[i], tt.wantNotify[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteTradeInvalidatesCache(t *testing.T) {
+	initial := newTestTrade(trade.StatusAccepted)
+	f := newTradeFixture(t, initial)
+	ctx := context.Background()
+
+	// Записи, которые устаревают, когда книги переходят к новым владельцам
+	entries := map[string]string{
+		"book":      bookDep(proposerBookID),
+		"books":     listBooksDep,
+		"proposer":  userDep(proposerID),
+		"recipient": userDep(recipientID),
+	}
+	for key, dep := range entries {
+		f.cache.Set(ctx, key, []byte("{}"), 0, dep)
+	}
+	f.cache.Set(ctx, "outsider", []byte("{}"), 0, userDep(outsiderID))
+
+	if _, err := f.usecase.CompleteTrade(ctx, proposerID, initial.ID); err != nil {
+		t.Fatalf("CompleteTrade() error = %v", err)
+	}
+	if n := f.cache.ItemCount(); n != len(entries)+1 {
+		t.Fatalf("cache has %d entries after the first confirmation, want all %d kept", n, len(entries)+1)
+	}
+
+	if _, err := f.usecase.CompleteTrade(ctx, recipientID, initial.ID); err != nil {
+		t.Fatalf("CompleteTrade() error = %v", err)
+	}
+	for key := range entries {
+		if _, found, _ := f.cache.Get(ctx, key); found {
+			t.Errorf("cache entry %q was not invalidated after the trade was completed", key)
+		}
+	}
+	if _, found, _ := f.cache.Get(ctx, "outsider"); !found {
+		t.Error("cache entry of a user outside the trade was invalidated")
+	}
+}
+
+func TestProposeTrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		dto       trade.CreateTradeDTO
+		wantErr   error
+		wantItems int
+	}{
+		{
+			name:      "valid offer",
+			dto:       trade.CreateTradeDTO{RecipientID: recipientID, OfferedBookIDs: []uint{proposerBookID}, RequestedBookIDs: []uint{recipientBookID}},
+			wantItems: 2,
+		},
+		{
+			name:    "trade with yourself",
+			dto:     trade.CreateTradeDTO{RecipientID: proposerID, OfferedBookIDs: []uint{proposerBookID}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+		{
+			name:    "unknown recipient",
+			dto:     trade.CreateTradeDTO{RecipientID: 404, OfferedBookIDs: []uint{proposerBookID}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+		{
+			name:    "unknown book",
+			dto:     trade.CreateTradeDTO{RecipientID: recipientID, OfferedBookIDs: []uint{404}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+		{
+			name:    "book of another user",
+			dto:     trade.CreateTradeDTO{RecipientID: recipientID, OfferedBookIDs: []uint{foreignBookID}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+		{
+			name:    "requested book of another user",
+			dto:     trade.CreateTradeDTO{RecipientID: recipientID, OfferedBookIDs: []uint{proposerBookID}, RequestedBookIDs: []uint{foreignBookID}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+		{
+			name:    "book already in trade",
+			dto:     trade.CreateTradeDTO{RecipientID: recipientID, OfferedBookIDs: []uint{tradingBookID}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+		{
+			name:    "book listed twice",
+			dto:     trade.CreateTradeDTO{RecipientID: recipientID, OfferedBookIDs: []uint{proposerBookID, proposerBookID}},
+			wantErr: ErrInvalidTradeOffer,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTradeFixture(t)
+
+			got, err := f.usecase.ProposeTrade(context.Background(), proposerID, &tt.dto)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ProposeTrade() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(f.trades.trades) != 0 {
+					t.Errorf("invalid offer was saved")
+				}
+				return
+			}
+
+			if got.Status != trade.StatusPending || got.ProposerID != proposerID || got.RecipientID != recipientID {
+				t.Errorf("ProposeTrade() = %+v, want pending trade between proposer and recipient", got)
+			}
+			if len(got.Items) != tt.wantItems {
+				t.Errorf("ProposeTrade() has %d items, want %d", len(got.Items), tt.wantItems)
+			}
+			want := sentNotification{userID: recipientID, typ: notification.TypeTradeOffer}
+			if len(f.notifier.sent) != 1 || f.notifier.sent[0] != want {
+				t.Errorf("notifications = %v, want %v", f.notifier.sent, want)
+			}
+		})
+	}
+}
+
+func TestCounterTrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  trade.Status
+		userID  uint
+		wantErr error
+	}{
+		{name: "recipient counters", status: trade.StatusPending, userID: recipientID},
+		{name: "proposer cannot counter", status: trade.StatusPending, userID: proposerID, wantErr: ErrTradeForbidden},
+		{name: "counter accepted", status: trade.StatusAccepted, userID: recipientID, wantErr: ErrInvalidTradeStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := newTestTrade(tt.status)
+			f := newTradeFixture(t, original)
+			dto := &trade.CounterTradeDTO{OfferedBookIDs: []uint{recipientBookID}, RequestedBookIDs: []uint{proposerBookID}}
+
+			counter, err := f.usecase.CounterTrade(context.Background(), tt.userID, original.ID, dto)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CounterTrade() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if stored := f.trades.trades[original.ID]; stored.Status != tt.status {
+					t.Errorf("original status = %s after error, want unchanged %s", stored.Status, tt.status)
+				}
+				return
+			}
+
+			if stored := f.trades.trades[original.ID]; stored.Status != trade.StatusCountered {
+				t.Errorf("original status = %s, want %s", stored.Status, trade.StatusCountered)
+			}
+			if counter.ProposerID != recipientID || counter.RecipientID != proposerID ||
+				counter.ParentID == nil || *counter.ParentID != original.ID || counter.Status != trade.StatusPending {
+				t.Errorf("CounterTrade() = %+v, want pending counter offer to the original proposer", counter)
+			}
+			want := sentNotification{userID: proposerID, typ: notification.TypeTradeOffer}
+			if len(f.notifier.sent) != 1 || f.notifier.sent[0] != want {
+				t.Errorf("notifications = %v, want %v", f.notifier.sent, want)
+			}
+		})
+	}
+}
@@ -4,10 +4,16 @@ import (
 	"booktrading/internal/domain/repository"
 	"booktrading/internal/domain/response"
 	"booktrading/internal/domain/user"
+	"booktrading/internal/pkg/cache"
+	"booktrading/internal/pkg/cursor"
+	"booktrading/internal/pkg/jwt"
+	"booktrading/internal/pkg/storage"
+	"booktrading/internal/pkg/tracing"
+	"context"
 	"errors"
 	"fmt"
+	"math"
 
-	"github.com/go-chi/jwtauth/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -18,32 +24,51 @@ var (
 )
 
 type UserUseCase interface {
-	Register(dto *user.CreateUserDTO) (*user.User, error)
-	Login(dto *user.LoginDTO) (*response.TokenResponse, uint, error)
-	Logout(refreshToken string) error
-	GetByID(id uint) (*user.User, error)
-	GetAll(page, pageSize int) ([]*user.User, int64, error)
-	Update(id uint, dto *user.UpdateUserDTO) (*user.User, error)
-	Delete(id uint) error
-	GenerateTokenPair(user *user.User) (*response.TokenResponse, error)
-	ValidateRefreshToken(token string) (*user.User, error)
+	Register(ctx context.Context, dto *user.CreateUserDTO) (*user.User, error)
+	Login(ctx context.Context, dto *user.LoginDTO) (*response.TokenResponse, uint, error)
+	Refresh(ctx context.Context, refreshToken string) (*response.TokenResponse, error)
+	Logout(ctx context.Context, refreshToken string) error
+	GetByID(ctx context.Context, id uint) (*user.User, error)
+	GetAll(ctx context.Context, page, pageSize int) ([]*user.User, int64, error)
+	GetAllByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*user.User, bool, error)
+	Update(ctx context.Context, id uint, dto *user.UpdateUserDTO, version uint) (*user.User, error)
+	UpdateRole(ctx context.Context, id uint, role user.Role, version uint) (*user.User, error)
+	Delete(ctx context.Context, id, version uint) error
 }
 
 type userUseCase struct {
-	userRepo repository.UserRepository
-	jwtAuth  *jwtauth.JWTAuth
+	userRepo     repository.UserRepository
+	reviewRepo   repository.ReviewRepository
+	tradeRepo    repository.TradeRepository
+	tokenService *jwt.Service
+	media        *storage.Media
+	cache        cache.Cache
 }
 
-func NewUserUseCase(userRepo repository.UserRepository, jwtAuth *jwtauth.JWTAuth) UserUseCase {
+func NewUserUseCase(
+	userRepo repository.UserRepository,
+	reviewRepo repository.ReviewRepository,
+	tradeRepo repository.TradeRepository,
+	tokenService *jwt.Service,
+	media *storage.Media,
+	cache cache.Cache,
+) UserUseCase {
 	return &userUseCase{
-		userRepo: userRepo,
-		jwtAuth:  jwtAuth,
+		userRepo:     userRepo,
+		reviewRepo:   reviewRepo,
+		tradeRepo:    tradeRepo,
+		tokenService: tokenService,
+		media:        media,
+		cache:        cache,
 	}
 }
 
-func (u *userUseCase) Register(dto *user.CreateUserDTO) (*user.User, error) {
+func (u *userUseCase) Register(ctx context.Context, dto *user.CreateUserDTO) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Register")
+	defer span.End()
+
 	// Проверяем, существует ли пользователь
-	existingUser, err := u.userRepo.GetByLogin(dto.Login)
+	existingUser, err := u.userRepo.GetByLogin(ctx, dto.Login)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check user existence: %w", err)
 	}
@@ -66,16 +91,19 @@ func (u *userUseCase) Register(dto *user.CreateUserDTO) (*user.User, error) {
 		newUser.Username = newUser.Login
 	}
 
-	if err := u.userRepo.Create(newUser); err != nil {
+	if err := u.userRepo.Create(ctx, newUser); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	return newUser, nil
 }
 
-func (u *userUseCase) Login(dto *user.LoginDTO) (*response.TokenResponse, uint, error) {
+func (u *userUseCase) Login(ctx context.Context, dto *user.LoginDTO) (*response.TokenResponse, uint, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Login")
+	defer span.End()
+
 	// Get user by login
-	existingUser, err := u.userRepo.GetByLogin(dto.Login)
+	existingUser, err := u.userRepo.GetByLogin(ctx, dto.Login)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -88,38 +116,80 @@ func (u *userUseCase) Login(dto *user.LoginDTO) (*response.TokenResponse, uint,
 		return nil, 0, ErrInvalidCredentials
 	}
 
-	// Generate JWT token pair
-	tokenPair, err := u.GenerateTokenPair(existingUser)
+	// Generate JWT token pair, начиная новое семейство refresh токенов
+	tokenPair, err := u.tokenService.GenerateTokenPair(ctx, existingUser)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to generate token pair: %w", err)
 	}
 
-	return tokenPair, existingUser.ID, nil
+	return toTokenResponse(tokenPair), existingUser.ID, nil
 }
 
-func (u *userUseCase) GetByID(id uint) (*user.User, error) {
-	user, err := u.userRepo.GetByID(id)
+func (u *userUseCase) GetByID(ctx context.Context, id uint) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetByID")
+	defer span.End()
+
+	user, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
+
+	reputation, err := u.reputation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Reputation = reputation
+
 	return user, nil
 }
 
-func (u *userUseCase) GetAll(page, pageSize int) ([]*user.User, int64, error) {
+// reputation собирает среднюю оценку пользователя и число его завершенных обменов
+func (u *userUseCase) reputation(ctx context.Context, id uint) (*user.Reputation, error) {
+	rating, count, err := u.reviewRepo.GetRating(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user rating: %w", err)
+	}
+	completed, err := u.tradeRepo.CountCompleted(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count completed trades: %w", err)
+	}
+	return &user.Reputation{
+		Rating:          math.Round(rating*100) / 100,
+		ReviewCount:     count,
+		CompletedTrades: completed,
+	}, nil
+}
+
+func (u *userUseCase) GetAll(ctx context.Context, page, pageSize int) ([]*user.User, int64, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetAll")
+	defer span.End()
+
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 {
 		pageSize = 10
 	}
-	return u.userRepo.GetAll(page, pageSize)
+	return u.userRepo.GetAll(ctx, page, pageSize)
+}
+
+// GetAllByCursor получает страницу пользователей по курсору
+func (u *userUseCase) GetAllByCursor(ctx context.Context, c *cursor.Cursor, limit int) ([]*user.User, bool, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetAllByCursor")
+	defer span.End()
+
+	return u.userRepo.GetAllByCursor(ctx, c, normalizeLimit(limit))
 }
 
-func (u *userUseCase) Update(id uint, dto *user.UpdateUserDTO) (*user.User, error) {
-	existingUser, err := u.userRepo.GetByID(id)
+// Update обновляет профиль пользователя, если его версия все еще равна version
+func (u *userUseCase) Update(ctx context.Context, id uint, dto *user.UpdateUserDTO, version uint) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Update")
+	defer span.End()
+
+	existingUser, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			return nil, ErrUserNotFound
@@ -127,95 +197,98 @@ func (u *userUseCase) Update(id uint, dto *user.UpdateUserDTO) (*user.User, erro
 		return nil, err
 	}
 
+	// Сохраняем аватар в хранилище, в базе остается только его URL
+	avatarURL, err := u.media.SaveDataURI(ctx, dto.Avatar)
+	if err != nil {
+		return nil, err
+	}
+	dto.Avatar = avatarURL
+
 	// Обновляем поля из DTO
 	existingUser.UpdateFromDTO(dto)
+	existingUser.Version = version
 
-	if err := u.userRepo.Update(existingUser); err != nil {
+	if err := u.userRepo.Update(ctx, existingUser); err != nil {
 		return nil, err
 	}
 
+	// Владелец отдается вместе с книгами, поэтому сбрасываем его книги
+	cacheInvalidate(ctx, u.cache, userDep(id))
+
 	return existingUser, nil
 }
 
-func (u *userUseCase) Delete(id uint) error {
-	if err := u.userRepo.Delete(id); err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return ErrUserNotFound
-		}
-		return err
+// UpdateRole изменяет роль пользователя, если его версия все еще равна version
+func (u *userUseCase) UpdateRole(ctx context.Context, id uint, role user.Role, version uint) (*user.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.UpdateRole")
+	defer span.End()
+
+	if !role.IsValid() {
+		return nil, fmt.Errorf("invalid role: %s", role)
 	}
-	return nil
-}
 
-// Logout выполняет выход пользователя из системы
-func (u *userUseCase) Logout(refreshToken string) error {
-	// Валидируем токен перед инвалидацией
-	token, err := u.jwtAuth.Decode(refreshToken)
+	existingUser, err := u.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return fmt.Errorf("invalid refresh token: %w", err)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
 	}
 
-	// Проверяем тип токена
-	tokenType, ok := token.PrivateClaims()["type"].(string)
-	if !ok || tokenType != "refresh" {
-		return errors.New("invalid token type")
+	existingUser.Role = role
+	existingUser.Version = version
+	if err := u.userRepo.Update(ctx, existingUser); err != nil {
+		return nil, err
 	}
+	cacheInvalidate(ctx, u.cache, userDep(id))
 
-	return nil
+	return existingUser, nil
 }
 
-// GenerateTokenPair генерирует пару токенов для пользователя
-func (u *userUseCase) GenerateTokenPair(user *user.User) (*response.TokenResponse, error) {
-	// Генерируем access token
-	_, accessToken, err := u.jwtAuth.Encode(map[string]interface{}{
-		"user_id": user.ID,
-		"login":   user.Login,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
-	}
+// Delete удаляет пользователя, если его версия все еще равна version
+func (u *userUseCase) Delete(ctx context.Context, id, version uint) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Delete")
+	defer span.End()
 
-	// Генерируем refresh token
-	_, refreshToken, err := u.jwtAuth.Encode(map[string]interface{}{
-		"user_id": user.ID,
-		"type":    "refresh",
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	if err := u.userRepo.Delete(ctx, id, version); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return err
 	}
 
-	tokenResponse := &response.TokenResponse{
-		Token:        accessToken,
-		RefreshToken: refreshToken,
-	}
-	return tokenResponse, nil
+	cacheInvalidate(ctx, u.cache, userDep(id))
+
+	// Удаленный пользователь больше не может обновлять токены
+	return u.tokenService.RevokeAllUserTokens(ctx, id)
 }
 
-// ValidateRefreshToken проверяет refresh token
-func (u *userUseCase) ValidateRefreshToken(token string) (*user.User, error) {
-	// Валидируем токен
-	jwtToken, err := u.jwtAuth.Decode(token)
+// Refresh обменивает refresh token на новую пару токенов.
+// Предъявленный refresh token становится недействительным
+func (u *userUseCase) Refresh(ctx context.Context, refreshToken string) (*response.TokenResponse, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Refresh")
+	defer span.End()
+
+	tokenPair, err := u.tokenService.RefreshTokenPair(ctx, refreshToken)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode token: %w", err)
+		return nil, err
 	}
+	return toTokenResponse(tokenPair), nil
+}
 
-	// Проверяем тип токена
-	tokenType, ok := jwtToken.PrivateClaims()["type"].(string)
-	if !ok || tokenType != "refresh" {
-		return nil, errors.New("invalid token type")
-	}
+// Logout выполняет выход пользователя из системы, отзывая все refresh токены сессии
+func (u *userUseCase) Logout(ctx context.Context, refreshToken string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Logout")
+	defer span.End()
 
-	// Получаем ID пользователя
-	userID, ok := jwtToken.PrivateClaims()["user_id"].(float64)
-	if !ok {
-		return nil, errors.New("invalid user ID in token")
-	}
+	return u.tokenService.RevokeRefreshToken(ctx, refreshToken)
+}
 
-	// Получаем пользователя
-	user, err := u.userRepo.GetByID(uint(userID))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+// toTokenResponse преобразует пару токенов в ответ API
+func toTokenResponse(tokenPair *jwt.TokenPair) *response.TokenResponse {
+	return &response.TokenResponse{
+		Token:        tokenPair.AccessToken,
+		RefreshToken: tokenPair.RefreshToken,
+		ExpiresIn:    tokenPair.ExpiresIn,
 	}
-
-	return user, nil
 }
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"booktrading/internal/domain/book"
+	"booktrading/internal/domain/notification"
+	"booktrading/internal/domain/repository"
+	"booktrading/internal/domain/wishlist"
+	"booktrading/internal/pkg/logger"
+	"booktrading/internal/pkg/tracing"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrWishlistItemNotFound = errors.New("wishlist item not found")
+	ErrInvalidWishlistItem  = errors.New("invalid wishlist item")
+)
+
+// WishlistUseCase определяет интерфейс для работы с вишлистами
+type WishlistUseCase interface {
+	CreateItem(ctx context.Context, userID uint, dto *wishlist.CreateItemDTO) (*wishlist.Item, error)
+	GetUserItems(ctx context.Context, userID uint) ([]*wishlist.Item, error)
+	DeleteItem(ctx context.Context, userID, itemID uint) error
+	GetUserMatches(ctx context.Context, userID uint, page, pageSize int) ([]*wishlist.Match, int64, error)
+}
+
+// WishlistMatcher сопоставляет книги с пожеланиями пользователей
+type WishlistMatcher interface {
+	// MatchBook записывает совпадения книги с вишлистами, если книга доступна для обмена
+	MatchBook(ctx context.Context, b *book.Book) error
+	// NotifyStateChange уведомляет пользователей, чьи пожелания совпали с книгой, о смене ее состояния
+	NotifyStateChange(ctx context.Context, b *book.Book) error
+}
+
+// wishlistUseCase реализует интерфейс WishlistUseCase
+type wishlistUseCase struct {
+	wishlistRepo repository.WishlistRepository
+	tagRepo      repository.TagRepository
+}
+
+// NewWishlistUseCase создает новый экземпляр wishlistUseCase
+func NewWishlistUseCase(wishlistRepo repository.WishlistRepository, tagRepo repository.TagRepository) WishlistUseCase {
+	return &wishlistUseCase{
+		wishlistRepo: wishlistRepo,
+		tagRepo:      tagRepo,
+	}
+}
+
+// CreateItem добавляет пожелание в вишлист пользователя
+func (u *wishlistUseCase) CreateItem(ctx context.Context, userID uint, dto *wishlist.CreateItemDTO) (*wishlist.Item, error) {
+	ctx, span := tracing.Start(ctx, "WishlistUseCase.CreateItem")
+	defer span.End()
+
+	if !dto.HasCriteria() {
+		return nil, fmt.Errorf("%w: at least one of title, author, isbn or tag_ids is required", ErrInvalidWishlistItem)
+	}
+
+	item := dto.ToItem(userID)
+	for _, tagID := range uniqueIDs(dto.TagIDs) {
+		t, err := u.tagRepo.GetByID(ctx, tagID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, fmt.Errorf("%w: tag %d not found", ErrInvalidWishlistItem, tagID)
+			}
+			return nil, err
+		}
+		item.Tags = append(item.Tags, t)
+	}
+
+	if err := u.wishlistRepo.CreateItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetUserItems получает вишлист пользователя
+func (u *wishlistUseCase) GetUserItems(ctx context.Context, userID uint) ([]*wishlist.Item, error) {
+	ctx, span := tracing.Start(ctx, "WishlistUseCase.GetUserItems")
+	defer span.End()
+
+	return u.wishlistRepo.GetUserItems(ctx, userID)
+}
+
+// DeleteItem удаляет пожелание из вишлиста пользователя
+func (u *wishlistUseCase) DeleteItem(ctx context.Context, userID, itemID uint) error {
+	ctx, span := tracing.Start(ctx, "WishlistUseCase.DeleteItem")
+	defer span.End()
+
+	item, err := u.wishlistRepo.GetItemByID(ctx, itemID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWishlistItemNotFound
+		}
+		return err
+	}
+	// Пожелание другого пользователя считается отсутствующим
+	if item.UserID != userID {
+		return ErrWishlistItemNotFound
+	}
+
+	return u.wishlistRepo.DeleteItem(ctx, itemID)
+}
+
+// GetUserMatches получает книги, найденные по вишлисту пользователя, с пагинацией
+func (u *wishlistUseCase) GetUserMatches(ctx context.Context, userID uint, page, pageSize int) ([]*wishlist.Match, int64, error) {
+	ctx, span := tracing.Start(ctx, "WishlistUseCase.GetUserMatches")
+	defer span.End()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	return u.wishlistRepo.GetUserMatches(ctx, userID, page, pageSize)
+}
+
+// wishlistMatcher реализует интерфейс WishlistMatcher
+type wishlistMatcher struct {
+	wishlistRepo repository.WishlistRepository
+	stateRepo    repository.StateRepository
+	notifier     Notifier
+}
+
+// NewWishlistMatcher создает новый экземпляр wishlistMatcher
+func NewWishlistMatcher(wishlistRepo repository.WishlistRepository, stateRepo repository.StateRepository, notifier Notifier) WishlistMatcher {
+	return &wishlistMatcher{
+		wishlistRepo: wishlistRepo,
+		stateRepo:    stateRepo,
+		notifier:     notifier,
+	}
+}
+
+// MatchBook находит пожелания, которым соответствует доступная книга, и записывает совпадения.
+// Повторный вызов для той же книги новых совпадений не создает
+func (m *wishlistMatcher) MatchBook(ctx context.Context, b *book.Book) error {
+	ctx, span := tracing.Start(ctx, "WishlistMatcher.MatchBook")
+	defer span.End()
+
+	available, err := m.stateRepo.GetByName(ctx, string(book.StateAvailable))
+	if err != nil {
+		return fmt.Errorf("failed to get available state: %w", err)
+	}
+	if b.StateID != available.ID {
+		return nil
+	}
+
+	candidates, err := m.wishlistRepo.FindCandidates(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	var matches []*wishlist.Match
+	for _, item := range candidates {
+		if item.Matches(b) {
+			matches = append(matches, &wishlist.Match{
+				ItemID: item.ID,
+				BookID: b.ID,
+				UserID: item.UserID,
+			})
+		}
+	}
+
+	created, err := m.wishlistRepo.CreateMatches(ctx, matches)
+	if err != nil {
+		return err
+	}
+	if len(created) > 0 {
+		logger.FromContext(ctx).Info("Book matched wishlist items", "book_id", b.ID, "matches", len(created))
+	}
+
+	for _, match := range created {
+		m.notifier.Notify(ctx, match.UserID, notification.TypeWishlistMatch, map[string]interface{}{
+			"item_id": match.ItemID,
+			"book_id": match.BookID,
+			"title":   b.Title,
+		})
+	}
+	return nil
+}
+
+// NotifyStateChange уведомляет пользователей, которым книга была найдена по вишлисту,
+// о новом состоянии книги
+func (m *wishlistMatcher) NotifyStateChange(ctx context.Context, b *book.Book) error {
+	ctx, span := tracing.Start(ctx, "WishlistMatcher.NotifyStateChange")
+	defer span.End()
+
+	userIDs, err := m.wishlistRepo.GetMatchUserIDs(ctx, b.ID)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	s, err := m.stateRepo.GetByID(ctx, b.StateID)
+	if err != nil {
+		return fmt.Errorf("failed to get state %d: %w", b.StateID, err)
+	}
+
+	for _, userID := range userIDs {
+		if userID == b.UserID {
+			continue
+		}
+		m.notifier.Notify(ctx, userID, notification.TypeBookStateChanged, map[string]interface{}{
+			"book_id":  b.ID,
+			"title":    b.Title,
+			"state_id": s.ID,
+			"state":    s.Name,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+DROP TABLE refresh_tokens;
+DROP TABLE book_tags;
+DROP TABLE book_photos;
+DROP TABLE books;
+DROP TABLE tags;
+DROP TABLE state_transitions;
+DROP TABLE states;
+DROP TABLE users;
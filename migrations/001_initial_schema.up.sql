@@ -0,0 +1,98 @@
+-- Начальная схема: пользователи, книги, теги, состояния и refresh токены
+CREATE TABLE users (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    login VARCHAR(50) NOT NULL,
+    username VARCHAR(50) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    avatar TEXT,
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_users_login (login)
+);
+
+CREATE TABLE states (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    name VARCHAR(50) NOT NULL,
+    PRIMARY KEY (id),
+    CONSTRAINT uni_states_name UNIQUE (name)
+);
+
+CREATE TABLE state_transitions (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    from_state_id BIGINT UNSIGNED NOT NULL,
+    to_state_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_state_transition (from_state_id, to_state_id),
+    CONSTRAINT fk_states_transitions FOREIGN KEY (from_state_id) REFERENCES states (id),
+    CONSTRAINT fk_state_transitions_to_state FOREIGN KEY (to_state_id) REFERENCES states (id)
+);
+
+CREATE TABLE tags (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    name VARCHAR(255) NOT NULL,
+    photo TEXT,
+    PRIMARY KEY (id),
+    CONSTRAINT uni_tags_name UNIQUE (name)
+);
+
+CREATE TABLE books (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    title VARCHAR(255) NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    description MEDIUMTEXT,
+    user_id BIGINT UNSIGNED NOT NULL,
+    state_id BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_books_title (title),
+    INDEX idx_books_author (author),
+    INDEX idx_books_user_id (user_id),
+    INDEX idx_books_state_id (state_id),
+    CONSTRAINT fk_books_user FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_books_state FOREIGN KEY (state_id) REFERENCES states (id)
+);
+
+CREATE TABLE book_photos (
+    id INT UNSIGNED NOT NULL AUTO_INCREMENT,
+    book_id INT UNSIGNED NOT NULL,
+    photo_url MEDIUMTEXT NOT NULL,
+    is_main BOOLEAN DEFAULT FALSE,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_book_photos_book_id (book_id),
+    CONSTRAINT fk_books_photos FOREIGN KEY (book_id) REFERENCES books (id)
+);
+
+CREATE TABLE book_tags (
+    book_id INT UNSIGNED NOT NULL,
+    tag_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (book_id, tag_id),
+    CONSTRAINT fk_book_tags_book FOREIGN KEY (book_id) REFERENCES books (id),
+    CONSTRAINT fk_book_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE refresh_tokens (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    token VARCHAR(255) NOT NULL,
+    family_id VARCHAR(64) NOT NULL,
+    expires_at DATETIME(3) NOT NULL,
+    used_at DATETIME(3) NULL,
+    revoked_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_refresh_tokens_token_hash (token),
+    INDEX idx_refresh_tokens_family_id (family_id),
+    INDEX idx_refresh_tokens_user_id (user_id)
+);
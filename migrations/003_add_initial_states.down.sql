@@ -0,0 +1 @@
+DELETE FROM states WHERE id IN (1, 2, 3);
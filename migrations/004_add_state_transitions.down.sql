@@ -0,0 +1 @@
+DELETE FROM state_transitions WHERE (from_state_id, to_state_id) IN ((1, 2), (2, 1), (2, 3));
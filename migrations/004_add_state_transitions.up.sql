@@ -0,0 +1,5 @@
+-- Разрешенные переходы между состояниями книг
+INSERT INTO state_transitions (from_state_id, to_state_id, created_at, updated_at) VALUES
+(1, 2, NOW(), NOW()),  -- available -> trading: книга участвует в обмене
+(2, 1, NOW(), NOW()),  -- trading -> available: обмен отменен
+(2, 3, NOW(), NOW());  -- trading -> traded: обмен завершен
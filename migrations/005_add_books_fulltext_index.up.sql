@@ -0,0 +1,2 @@
+-- Полнотекстовый индекс для поиска книг по названию, автору и описанию
+ALTER TABLE books ADD FULLTEXT INDEX idx_books_fulltext (title, author, description);
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP INDEX idx_users_created_at;
+ALTER TABLE books DROP INDEX idx_books_created_at;
@@ -0,0 +1,5 @@
+-- Индексы для курсорной пагинации по (created_at, id).
+-- InnoDB добавляет первичный ключ в каждый вторичный индекс,
+-- поэтому индекса по created_at достаточно для упорядочивания по (created_at, id)
+ALTER TABLE books ADD INDEX idx_books_created_at (created_at);
+ALTER TABLE users ADD INDEX idx_users_created_at (created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE book_photos DROP COLUMN thumbnail_url;
+ALTER TABLE book_photos DROP COLUMN medium_url;
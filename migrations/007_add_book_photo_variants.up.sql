@@ -0,0 +1,4 @@
+-- URL уменьшенных вариантов фотографий книг.
+-- Для существующих фотографий варианты строит cmd/migrate-photos
+ALTER TABLE book_photos ADD COLUMN medium_url VARCHAR(255) NOT NULL DEFAULT '' AFTER photo_url;
+ALTER TABLE book_photos ADD COLUMN thumbnail_url VARCHAR(255) NOT NULL DEFAULT '' AFTER medium_url;
@@ -0,0 +1 @@
+ALTER TABLE book_photos DROP COLUMN position;
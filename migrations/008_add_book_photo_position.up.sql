@@ -0,0 +1,10 @@
+-- Порядок фотографий в галерее книги
+ALTER TABLE book_photos ADD COLUMN position INT NOT NULL DEFAULT 0 AFTER is_main;
+
+-- Существующие фотографии нумеруются в порядке добавления
+UPDATE book_photos p
+JOIN (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY book_id ORDER BY id) - 1 AS pos
+    FROM book_photos
+) ordered ON ordered.id = p.id
+SET p.position = ordered.pos;
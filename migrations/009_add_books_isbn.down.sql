@@ -0,0 +1,2 @@
+ALTER TABLE books DROP INDEX idx_books_isbn;
+ALTER TABLE books DROP COLUMN isbn;
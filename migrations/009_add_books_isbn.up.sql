@@ -0,0 +1,3 @@
+-- ISBN книги (без дефисов) для поиска по вишлистам.
+ALTER TABLE books ADD COLUMN isbn VARCHAR(13) NOT NULL DEFAULT '' AFTER description;
+ALTER TABLE books ADD INDEX idx_books_isbn (isbn);
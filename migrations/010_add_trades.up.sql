@@ -0,0 +1,31 @@
+-- Предложения обмена и книги, которые в них передаются
+CREATE TABLE trades (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    proposer_id BIGINT UNSIGNED NOT NULL,
+    recipient_id BIGINT UNSIGNED NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    parent_id BIGINT UNSIGNED,
+    message TEXT,
+    PRIMARY KEY (id),
+    INDEX idx_trades_status (status),
+    INDEX idx_trades_parent_id (parent_id),
+    INDEX idx_trades_proposer_id (proposer_id),
+    INDEX idx_trades_recipient_id (recipient_id),
+    CONSTRAINT fk_trades_proposer FOREIGN KEY (proposer_id) REFERENCES users (id),
+    CONSTRAINT fk_trades_recipient FOREIGN KEY (recipient_id) REFERENCES users (id)
+);
+
+CREATE TABLE trade_items (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    book_id INT UNSIGNED NOT NULL,
+    from_user_id BIGINT UNSIGNED NOT NULL,
+    to_user_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_trade_items_trade_id (trade_id),
+    INDEX idx_trade_items_book_id (book_id),
+    CONSTRAINT fk_trades_items FOREIGN KEY (trade_id) REFERENCES trades (id),
+    CONSTRAINT fk_trade_items_book FOREIGN KEY (book_id) REFERENCES books (id)
+);
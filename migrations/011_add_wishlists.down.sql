@@ -0,0 +1,3 @@
+DROP TABLE wishlist_matches;
+DROP TABLE wishlist_item_tags;
+DROP TABLE wishlist_items;
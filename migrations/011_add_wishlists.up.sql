@@ -0,0 +1,35 @@
+-- Вишлисты пользователей и найденные по ним книги
+CREATE TABLE wishlist_items (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    author VARCHAR(255) NOT NULL DEFAULT '',
+    isbn VARCHAR(13) NOT NULL DEFAULT '',
+    PRIMARY KEY (id),
+    INDEX idx_wishlist_items_user_id (user_id),
+    INDEX idx_wishlist_items_isbn (isbn)
+);
+
+CREATE TABLE wishlist_item_tags (
+    item_id BIGINT UNSIGNED NOT NULL,
+    tag_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (item_id, tag_id),
+    CONSTRAINT fk_wishlist_item_tags_item FOREIGN KEY (item_id) REFERENCES wishlist_items (id),
+    CONSTRAINT fk_wishlist_item_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE wishlist_matches (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    item_id BIGINT UNSIGNED NOT NULL,
+    book_id INT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_wishlist_match (item_id, book_id),
+    INDEX idx_wishlist_matches_book_id (book_id),
+    INDEX idx_wishlist_matches_user_id (user_id),
+    CONSTRAINT fk_wishlist_matches_item FOREIGN KEY (item_id) REFERENCES wishlist_items (id),
+    CONSTRAINT fk_wishlist_matches_book FOREIGN KEY (book_id) REFERENCES books (id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE trade_cycle_items;
+DROP TABLE trade_cycle_participants;
+DROP TABLE trade_cycles;
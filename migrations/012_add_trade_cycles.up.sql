@@ -0,0 +1,36 @@
+-- Кольцевые обмены между несколькими пользователями
+CREATE TABLE trade_cycles (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    status VARCHAR(20) NOT NULL,
+    book_key VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_trade_cycles_status (status),
+    INDEX idx_trade_cycles_book_key (book_key)
+);
+
+CREATE TABLE trade_cycle_participants (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    cycle_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    accepted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_cycle_participant (cycle_id, user_id),
+    INDEX idx_trade_cycle_participants_user_id (user_id),
+    CONSTRAINT fk_trade_cycles_participants FOREIGN KEY (cycle_id) REFERENCES trade_cycles (id),
+    CONSTRAINT fk_trade_cycle_participants_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE trade_cycle_items (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    cycle_id BIGINT UNSIGNED NOT NULL,
+    book_id INT UNSIGNED NOT NULL,
+    from_user_id BIGINT UNSIGNED NOT NULL,
+    to_user_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (id),
+    INDEX idx_trade_cycle_items_cycle_id (cycle_id),
+    INDEX idx_trade_cycle_items_book_id (book_id),
+    CONSTRAINT fk_trade_cycles_items FOREIGN KEY (cycle_id) REFERENCES trade_cycles (id),
+    CONSTRAINT fk_trade_cycle_items_book FOREIGN KEY (book_id) REFERENCES books (id)
+);
@@ -0,0 +1,36 @@
+-- Переписка о книгах и обменах
+CREATE TABLE conversations (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    book_id BIGINT UNSIGNED,
+    trade_id BIGINT UNSIGNED,
+    last_message_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_conversations_book_id (book_id),
+    INDEX idx_conversations_trade_id (trade_id),
+    INDEX idx_conversations_last_message_at (last_message_at)
+);
+
+CREATE TABLE conversation_participants (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    conversation_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    unread_count BIGINT NOT NULL DEFAULT 0,
+    last_read_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_conversation_participant (conversation_id, user_id),
+    INDEX idx_conversation_participants_user_id (user_id),
+    CONSTRAINT fk_conversations_participants FOREIGN KEY (conversation_id) REFERENCES conversations (id),
+    CONSTRAINT fk_conversation_participants_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE messages (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    conversation_id BIGINT UNSIGNED NOT NULL,
+    sender_id BIGINT UNSIGNED NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_messages_conversation_created (conversation_id, created_at)
+);
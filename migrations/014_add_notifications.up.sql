@@ -0,0 +1,12 @@
+-- Входящие уведомления пользователей
+CREATE TABLE notifications (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    data JSON,
+    read_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_notifications_user_read (user_id, read_at)
+);
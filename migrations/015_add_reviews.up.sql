@@ -0,0 +1,15 @@
+-- Отзывы участников завершенных обменов друг о друге
+CREATE TABLE reviews (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    trade_id BIGINT UNSIGNED NOT NULL,
+    reviewer_id BIGINT UNSIGNED NOT NULL,
+    reviewee_id BIGINT UNSIGNED NOT NULL,
+    rating TINYINT UNSIGNED NOT NULL,
+    comment TEXT,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_review_trade_reviewer (trade_id, reviewer_id),
+    INDEX idx_reviews_reviewee_id (reviewee_id),
+    CONSTRAINT fk_reviews_reviewer FOREIGN KEY (reviewer_id) REFERENCES users (id)
+);
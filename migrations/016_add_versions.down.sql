@@ -0,0 +1,4 @@
+ALTER TABLE states DROP COLUMN version;
+ALTER TABLE tags DROP COLUMN version;
+ALTER TABLE users DROP COLUMN version;
+ALTER TABLE books DROP COLUMN version;
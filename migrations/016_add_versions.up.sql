@@ -0,0 +1,6 @@
+-- Версии записей для ETag и оптимистичной блокировки: каждое изменение увеличивает
+-- версию, а обновление с устаревшей версией отклоняется.
+ALTER TABLE books ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
+ALTER TABLE users ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
+ALTER TABLE tags ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
+ALTER TABLE states ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1;
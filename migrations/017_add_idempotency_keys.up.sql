@@ -0,0 +1,15 @@
+-- Ключи идемпотентности POST запросов и сохраненные ответы на них
+CREATE TABLE idempotency_keys (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    created_at DATETIME(3) NULL,
+    user_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code SMALLINT UNSIGNED NOT NULL DEFAULT 0,
+    headers TEXT,
+    body MEDIUMBLOB,
+    expires_at DATETIME(3) NOT NULL,
+    PRIMARY KEY (id),
+    UNIQUE INDEX idx_idempotency_user_key (user_id, idempotency_key),
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE trade_cycle_participants DROP COLUMN completed_at;
+ALTER TABLE trades DROP COLUMN recipient_completed_at;
+ALTER TABLE trades DROP COLUMN proposer_completed_at;
@@ -0,0 +1,5 @@
+-- Подтверждения получения книг: обмен завершается, только когда получение
+-- подтвердили все его участники
+ALTER TABLE trades ADD COLUMN proposer_completed_at DATETIME(3) NULL;
+ALTER TABLE trades ADD COLUMN recipient_completed_at DATETIME(3) NULL;
+ALTER TABLE trade_cycle_participants ADD COLUMN completed_at DATETIME(3) NULL;
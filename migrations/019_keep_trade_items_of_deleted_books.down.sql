@@ -0,0 +1,10 @@
+-- Позиции удаленных книг не могут существовать без ссылки на книгу
+DELETE FROM trade_cycle_items WHERE book_id IS NULL;
+ALTER TABLE trade_cycle_items DROP FOREIGN KEY fk_trade_cycle_items_book;
+ALTER TABLE trade_cycle_items MODIFY book_id INT UNSIGNED NOT NULL;
+ALTER TABLE trade_cycle_items ADD CONSTRAINT fk_trade_cycle_items_book FOREIGN KEY (book_id) REFERENCES books (id);
+
+DELETE FROM trade_items WHERE book_id IS NULL;
+ALTER TABLE trade_items DROP FOREIGN KEY fk_trade_items_book;
+ALTER TABLE trade_items MODIFY book_id INT UNSIGNED NOT NULL;
+ALTER TABLE trade_items ADD CONSTRAINT fk_trade_items_book FOREIGN KEY (book_id) REFERENCES books (id);
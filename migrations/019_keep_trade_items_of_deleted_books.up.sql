@@ -0,0 +1,9 @@
+-- История обменов сохраняется после удаления книги: позиция обмена остается,
+-- а ссылка на книгу обнуляется
+ALTER TABLE trade_items DROP FOREIGN KEY fk_trade_items_book;
+ALTER TABLE trade_items MODIFY book_id INT UNSIGNED NULL;
+ALTER TABLE trade_items ADD CONSTRAINT fk_trade_items_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE SET NULL;
+
+ALTER TABLE trade_cycle_items DROP FOREIGN KEY fk_trade_cycle_items_book;
+ALTER TABLE trade_cycle_items MODIFY book_id INT UNSIGNED NULL;
+ALTER TABLE trade_cycle_items ADD CONSTRAINT fk_trade_cycle_items_book FOREIGN KEY (book_id) REFERENCES books (id) ON DELETE SET NULL;
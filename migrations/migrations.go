@@ -0,0 +1,13 @@
+// Package migrations содержит SQL миграции схемы базы данных.
+//
+// Каждая миграция состоит из файлов NNN_name.up.sql и NNN_name.down.sql,
+// миграции применяются в порядке номеров. Уже примененные миграции изменять
+// нельзя: их контрольные суммы проверяются при каждом запуске.
+package migrations
+
+import "embed"
+
+// FS содержит файлы миграций, встроенные в исполняемый файл
+//
+//go:embed *.sql
+var FS embed.FS